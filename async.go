@@ -0,0 +1,259 @@
+package slogbuffer
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrClosed is returned when record is logged using AsyncHandler that was closed.
+var ErrClosed = errors.New("slogbuffer: handler closed")
+
+// OverflowPolicy controls what AsyncHandler does with records when its queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock makes logging call wait until there is space in the queue.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest drops record that is being logged and returns ErrBufferFull.
+	OverflowDropNewest
+	// OverflowDropOldest drops the oldest queued record to make space for the new one.
+	OverflowDropOldest
+)
+
+// AsyncOption configures optional behaviour of AsyncHandler.
+type AsyncOption func(*asyncOptions)
+
+// asyncOptions holds optional configuration of AsyncHandler.
+type asyncOptions struct {
+	queueSize int
+	overflow  OverflowPolicy
+	// onError is called with errors of real handler, if set
+	onError func(error)
+}
+
+// WithQueueSize sets maximum number of records waiting for delivery. Default is 1024.
+func WithQueueSize(size int) AsyncOption {
+	return func(o *asyncOptions) {
+		o.queueSize = size
+	}
+}
+
+// WithOverflowPolicy sets what happens with records when queue is full. Default is OverflowBlock.
+func WithOverflowPolicy(policy OverflowPolicy) AsyncOption {
+	return func(o *asyncOptions) {
+		o.overflow = policy
+	}
+}
+
+// WithAsyncErrorHandler sets function that is called (from background goroutine) with errors
+// real handler returns, since they can not be returned to caller that logged the record.
+func WithAsyncErrorHandler(onError func(error)) AsyncOption {
+	return func(o *asyncOptions) {
+		o.onError = onError
+	}
+}
+
+// AsyncHandler is [slog.Handler] that queues records and delivers them to real handler from
+// background goroutine, so logging does not wait for real handler (e.g. network sink).
+// Queued records can be drained using Flush and handler is stopped using Close.
+type AsyncHandler struct {
+	// real is handler to which records are delivered, with attributes and groups
+	// of this handler already applied
+	real slog.Handler
+	// queue is shared with all derived handlers
+	queue *asyncQueue
+}
+
+// NewAsyncHandler creates handler that delivers records to provided real handler asynchronously.
+// Close has to be called to stop background goroutine.
+func NewAsyncHandler(real slog.Handler, opts ...AsyncOption) *AsyncHandler {
+	o := &asyncOptions{queueSize: 1024}
+	for _, opt := range opts {
+		opt(o)
+	}
+	q := &asyncQueue{
+		records: make(chan record, max(o.queueSize, 1)),
+		opts:    o,
+		done:    make(chan struct{}),
+	}
+	go q.run()
+	return &AsyncHandler{real: real, queue: q}
+}
+
+// Implementation of slog.Handler interface.
+
+// compile time check that AsyncHandler implements slog.Handler interface.
+var _ slog.Handler = &AsyncHandler{}
+
+func (h *AsyncHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.real.Enabled(ctx, level)
+}
+
+func (h *AsyncHandler) Handle(_ context.Context, r slog.Record) error {
+	// values are resolved, since record is handled later, possibly after they changed.
+	// Also, resolving creates new record, so there is no need to clone it.
+	return h.queue.add(record{Record: resolveRecord(r), handler: h.real})
+}
+
+func (h *AsyncHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &AsyncHandler{real: h.real.WithAttrs(resolveAttrs(attrs)), queue: h.queue}
+}
+
+func (h *AsyncHandler) WithGroup(name string) slog.Handler {
+	if len(name) == 0 {
+		return h
+	}
+	return &AsyncHandler{real: h.real.WithGroup(name), queue: h.queue}
+}
+
+// Flush waits until all records queued before the call are delivered to real handler
+// (or dropped), or until provided context is done.
+func (h *AsyncHandler) Flush(ctx context.Context) error {
+	return h.queue.flush(ctx)
+}
+
+// Close stops accepting new records and waits until queued records are delivered to real
+// handler, or until provided context is done. Records logged after Close fail with ErrClosed.
+func (h *AsyncHandler) Close(ctx context.Context) error {
+	return h.queue.close(ctx)
+}
+
+// Dropped returns number of records dropped because queue was full.
+func (h *AsyncHandler) Dropped() uint64 {
+	return h.queue.dropped.Load()
+}
+
+// asyncQueue holds records waiting for delivery and state of background goroutine.
+type asyncQueue struct {
+	records chan record
+	opts    *asyncOptions
+
+	// closeLock is held for reading while records are added and for writing while queue
+	// is closed, so records are never sent to closed channel
+	closeLock sync.RWMutex
+	closed    bool
+	// done is closed when background goroutine exits
+	done chan struct{}
+
+	// enqueued and processed count records added to the queue and records delivered
+	// (or dropped), flush waits for the latter to catch up with the former
+	enqueued  atomic.Uint64
+	processed atomic.Uint64
+	dropped   atomic.Uint64
+
+	waitersLock sync.Mutex
+	waiters     []flushWaiter
+}
+
+// flushWaiter is flush call waiting for records to be processed.
+type flushWaiter struct {
+	target uint64
+	done   chan struct{}
+}
+
+// add adds record to the queue, according to overflow policy.
+func (q *asyncQueue) add(rec record) error {
+	q.closeLock.RLock()
+	defer q.closeLock.RUnlock()
+	if q.closed {
+		return ErrClosed
+	}
+
+	q.enqueued.Add(1)
+	switch q.opts.overflow {
+	case OverflowDropNewest:
+		select {
+		case q.records <- rec:
+		default:
+			q.drop()
+			return ErrBufferFull
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case q.records <- rec:
+				return nil
+			default:
+			}
+			select {
+			case <-q.records:
+				q.drop()
+			default:
+			}
+		}
+	default:
+		q.records <- rec
+	}
+	return nil
+}
+
+// drop accounts for dropped record.
+func (q *asyncQueue) drop() {
+	q.dropped.Add(1)
+	q.markProcessed()
+}
+
+// run delivers queued records to their handlers, until queue is closed.
+func (q *asyncQueue) run() {
+	defer close(q.done)
+	for rec := range q.records {
+		if err := rec.emit(context.Background(), nil); err != nil && q.opts.onError != nil {
+			q.opts.onError(err)
+		}
+		q.markProcessed()
+	}
+}
+
+// markProcessed accounts for processed record and releases flush calls waiting for it.
+func (q *asyncQueue) markProcessed() {
+	processed := q.processed.Add(1)
+	q.waitersLock.Lock()
+	defer q.waitersLock.Unlock()
+	q.waiters = slices.DeleteFunc(q.waiters, func(w flushWaiter) bool {
+		if w.target <= processed {
+			close(w.done)
+			return true
+		}
+		return false
+	})
+}
+
+// flush waits until all records enqueued so far are processed.
+func (q *asyncQueue) flush(ctx context.Context) error {
+	w := flushWaiter{target: q.enqueued.Load(), done: make(chan struct{})}
+	q.waitersLock.Lock()
+	if q.processed.Load() >= w.target {
+		q.waitersLock.Unlock()
+		return nil
+	}
+	q.waiters = append(q.waiters, w)
+	q.waitersLock.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close stops accepting records and waits for background goroutine to deliver queued ones.
+func (q *asyncQueue) close(ctx context.Context) error {
+	q.closeLock.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.records)
+	}
+	q.closeLock.Unlock()
+
+	select {
+	case <-q.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
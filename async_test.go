@@ -0,0 +1,123 @@
+package slogbuffer_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lockedHandler is [slog.Handler] that handles records under a lock, so records can be
+// read safely while handler is used from background goroutine and handling can be blocked.
+type lockedHandler struct {
+	slog.Handler
+	lock *sync.Mutex
+}
+
+func (h *lockedHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *lockedHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &lockedHandler{Handler: h.Handler.WithAttrs(attrs), lock: h.lock}
+}
+
+func (h *lockedHandler) WithGroup(name string) slog.Handler {
+	return &lockedHandler{Handler: h.Handler.WithGroup(name), lock: h.lock}
+}
+
+func TestAsyncHandler(t *testing.T) {
+	// given
+	rh, reader := getSimplifiedTextHandler()
+	h := slogbuffer.NewAsyncHandler(rh)
+	l := slog.New(h)
+
+	// when
+	l.Info("first msg")
+	l.WithGroup("g1").With("common", "attr").Info("second msg")
+	if err := h.Flush(context.Background()); err != nil {
+		t.Fatalf("flushing: %v", err)
+	}
+
+	// then
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 2)
+	expectMsg(t, lines[0], "first msg")
+	expectMsg(t, lines[1], "second msg")
+	expectAttr(t, lines[1], "g1.common", "attr")
+
+	if err := h.Close(context.Background()); err != nil {
+		t.Fatalf("closing: %v", err)
+	}
+	if err := h.Handle(context.Background(), newRecord(slog.LevelInfo, "after close msg")); !errors.Is(err, slogbuffer.ErrClosed) {
+		t.Fatalf("expected ErrClosed, got: %v", err)
+	}
+}
+
+func TestAsyncHandler_OverflowPolicy(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		policy   slogbuffer.OverflowPolicy
+		expected []string
+	}{
+		{name: "drop newest", policy: slogbuffer.OverflowDropNewest, expected: []string{"msg 0", "msg 1", "msg 2"}},
+		{name: "drop oldest", policy: slogbuffer.OverflowDropOldest, expected: []string{"msg 0", "msg 3", "msg 4"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// given
+			rh, reader := getSimplifiedTextHandler()
+			lock := &sync.Mutex{}
+			h := slogbuffer.NewAsyncHandler(&lockedHandler{Handler: rh, lock: lock},
+				slogbuffer.WithQueueSize(2), slogbuffer.WithOverflowPolicy(tc.policy))
+			l := slog.New(h)
+
+			// when
+			lock.Lock() // blocks delivery
+			l.Info("msg 0")
+			// wait for background goroutine to take first record from the queue
+			time.Sleep(50 * time.Millisecond)
+			for i := 1; i < 5; i++ {
+				l.Info(fmt.Sprintf("msg %d", i))
+			}
+			lock.Unlock()
+			if err := h.Close(context.Background()); err != nil {
+				t.Fatalf("closing: %v", err)
+			}
+
+			// then
+			lines := getLines(t, reader)
+			expectLinesNo(t, lines, len(tc.expected))
+			for i, msg := range tc.expected {
+				expectMsg(t, lines[i], msg)
+			}
+			if h.Dropped() != 2 {
+				t.Fatalf("expected 2 dropped records, got %d", h.Dropped())
+			}
+		})
+	}
+}
+
+func TestAsyncHandler_ErrorHandler(t *testing.T) {
+	// given
+	rh, _ := getSimplifiedTextHandler()
+	var errs []error
+	h := slogbuffer.NewAsyncHandler(newFailingHandler(rh, 1),
+		slogbuffer.WithAsyncErrorHandler(func(err error) { errs = append(errs, err) }))
+
+	// when
+	slog.New(h).Info("msg")
+	if err := h.Close(context.Background()); err != nil {
+		t.Fatalf("closing: %v", err)
+	}
+
+	// then
+	if len(errs) != 1 || !errors.Is(errs[0], errHandlerFailed) {
+		t.Fatalf("expected error of real handler to be reported, got: %v", errs)
+	}
+}
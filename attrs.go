@@ -0,0 +1,37 @@
+package slogbuffer
+
+import (
+	"log/slog"
+)
+
+// resolveAttr resolves value of provided attribute (and values of group members,
+// recursively), so that [slog.LogValuer] values are evaluated at the time of the call.
+func resolveAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		a.Value = slog.GroupValue(resolveAttrs(a.Value.Group())...)
+	}
+	return a
+}
+
+// resolveAttrs returns new slice with all provided attributes resolved.
+func resolveAttrs(attrs []slog.Attr) []slog.Attr {
+	if attrs == nil {
+		return nil
+	}
+	res := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		res[i] = resolveAttr(a)
+	}
+	return res
+}
+
+// resolveRecord returns new record with all attribute values resolved.
+func resolveRecord(r slog.Record) slog.Record {
+	res := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		res.AddAttrs(resolveAttr(a))
+		return true
+	})
+	return res
+}
@@ -0,0 +1,22 @@
+package slogbuffer
+
+import (
+	"context"
+	"log/slog"
+)
+
+// BatchHandler is optionally implemented by real handlers that can handle multiple records
+// at once more efficiently than one by one (e.g. bulk inserts to database or single request to
+// network sink). If real handler implements it, buffered records are delivered to it in batches
+// (of size configured using WithFlushProgress or all at once). Records passed to HandleBatch are
+// self-contained, attributes and groups of the logger are folded into attributes of records.
+// Records logged after real handler is set are still passed to Handle.
+//
+// Options that wrap real handler (WithReplaceAttr, WithCorrelationAttrs and WithCorrelationGroup)
+// hide BatchHandler implementation, so records are delivered one by one when they are used.
+type BatchHandler interface {
+	slog.Handler
+	// HandleBatch handles provided records, in order. If error is returned, all records
+	// of the batch are considered failed.
+	HandleBatch(ctx context.Context, records []slog.Record) error
+}
@@ -0,0 +1,49 @@
+package slogbuffer_test
+
+import (
+	"context"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"testing"
+)
+
+// batchingHandler is [slogbuffer.BatchHandler] that remembers received batches.
+type batchingHandler struct {
+	collectingHandler
+	batches [][]slog.Record
+}
+
+func (h *batchingHandler) HandleBatch(_ context.Context, records []slog.Record) error {
+	h.batches = append(h.batches, records)
+	return nil
+}
+
+func TestBufferLogHandler_BatchHandler(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithFlushProgress(2, nil))
+	l := slog.New(h)
+	l.Info("first msg")
+	l.WithGroup("g1").With("common", "attr").Info("second msg")
+	l.Info("third msg")
+
+	// when
+	real := &batchingHandler{}
+	if err := h.SetRealHandler(context.Background(), real); err != nil {
+		t.Fatalf("setting real handler: %v", err)
+	}
+	l.Info("live msg")
+
+	// then
+	if len(real.batches) != 2 || len(real.batches[0]) != 2 || len(real.batches[1]) != 1 {
+		t.Fatalf("expected batches of 2 and 1 records, got: %v", real.batches)
+	}
+	if real.batches[0][0].Message != "first msg" || real.batches[1][0].Message != "third msg" {
+		t.Fatalf("expected records in order, got: %v", real.batches)
+	}
+	expectRecordAttr(t, real.batches[0][1], "g1", slog.GroupValue(slog.String("common", "attr")))
+
+	// records logged after real handler is set are handled one by one
+	if len(real.records) != 1 || real.records[0].Message != "live msg" {
+		t.Fatalf("expected live record to be handled, got: %v", real.records)
+	}
+}
@@ -0,0 +1,21 @@
+package slogbuffer
+
+// enforceBounds evicts the oldest buffered records while they are older than maximum age or
+// buffered records take more than maximum number of bytes (see WithMaxAge and WithMaxBytes).
+func (h *BufferLogHandler) enforceBounds() {
+	o := h.getOptions()
+	if o.maxAge <= 0 && o.maxBytes <= 0 {
+		return
+	}
+	e, ok := h.buffer.(evicter)
+	if !ok {
+		return
+	}
+	cutoff := h.now().Add(-o.maxAge)
+	e.evictWhile(func(oldest record) bool {
+		if o.maxAge > 0 && !oldest.Time.IsZero() && oldest.Time.Before(cutoff) {
+			return true
+		}
+		return o.maxBytes > 0 && h.stats.bytes.Load() > o.maxBytes
+	})
+}
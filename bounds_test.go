@@ -0,0 +1,108 @@
+package slogbuffer_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBufferLogHandler_WithMaxAge(t *testing.T) {
+	// given
+	h := slogbuffer.NewBoundBufferLogHandler(slog.LevelDebug, 10, slogbuffer.WithMaxAge(time.Minute))
+	ctx := context.Background()
+
+	// when
+	for _, age := range []time.Duration{time.Hour, 2 * time.Minute, 30 * time.Second} {
+		if err := h.Handle(ctx, slog.NewRecord(time.Now().Add(-age), slog.LevelInfo, fmt.Sprintf("age %s", age), 0)); err != nil {
+			t.Fatalf("handling record: %v", err)
+		}
+	}
+	slog.New(h).Info("new")
+
+	// then
+	records := h.Records()
+	if len(records) != 2 || records[0].Message != "age 30s" || records[1].Message != "new" {
+		t.Fatalf("expected only records younger than a minute, got %v", records)
+	}
+	if h.Dropped() != 2 {
+		t.Fatalf("expected 2 dropped records, got %d", h.Dropped())
+	}
+}
+
+func TestBufferLogHandler_WithMaxBytes(t *testing.T) {
+	// given
+	sized := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	slog.New(sized).Info("msg", "no", 0)
+	size := sized.ApproxBytes()
+
+	tests := map[string][]slogbuffer.Option{
+		"memory":     nil,
+		"compressed": {slogbuffer.WithCompression(0)},
+		"sharded":    {slogbuffer.WithShards(3)},
+		"leveled":    {slogbuffer.WithLevelCapacity(slog.LevelDebug, 0), slogbuffer.WithLevelCapacity(slog.LevelWarn, 0)},
+	}
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, append(opts, slogbuffer.WithMaxBytes(3*size))...)
+			l := slog.New(h)
+
+			// when
+			for i := range 10 {
+				l.Info("msg", "no", i)
+			}
+
+			// then
+			records := h.Records()
+			if len(records) != 3 {
+				t.Fatalf("expected 3 records, got %d", len(records))
+			}
+			for i, r := range records {
+				expectRecordAttr(t, r, "no", slog.Int64Value(int64(i+7)))
+			}
+		})
+	}
+}
+
+func TestBufferLogHandler_WithMaxBytes_File(t *testing.T) {
+	// given
+	sized := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	slog.New(sized).Info("msg", "no", 0)
+	h, err := slogbuffer.NewFileBufferLogHandler(filepath.Join(t.TempDir(), "buffer"), 64*1024, slog.LevelDebug,
+		slogbuffer.WithMaxBytes(2*sized.ApproxBytes()))
+	if err != nil {
+		t.Fatalf("creating handler: %v", err)
+	}
+	defer h.Close()
+
+	// when
+	for i := range 5 {
+		slog.New(h).Info("msg", "no", i)
+	}
+
+	// then
+	if h.Len() != 2 {
+		t.Fatalf("expected 2 records, got %d", h.Len())
+	}
+}
+
+func TestBufferLogHandler_CompositeBounds(t *testing.T) {
+	// given
+	h := slogbuffer.NewBoundBufferLogHandler(slog.LevelDebug, 3,
+		slogbuffer.WithMaxAge(time.Hour), slogbuffer.WithMaxBytes(1<<20))
+	l := slog.New(h)
+
+	// when
+	for i := range 5 {
+		l.Info("msg", "no", i)
+	}
+
+	// then
+	// count limit is hit first
+	if h.Len() != 3 || h.Dropped() != 2 {
+		t.Fatalf("expected 3 records and 2 dropped, got %d and %d", h.Len(), h.Dropped())
+	}
+}
@@ -0,0 +1,165 @@
+package slogbuffer
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// CircuitState describes state of circuit breaker around real handler.
+type CircuitState int
+
+const (
+	// CircuitClosed is normal state, records are passed to real handler.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen is state after too many records failed. Real handler is bypassed and
+	// records are buffered until cooldown expires.
+	CircuitOpen
+	// CircuitHalfOpen is state after cooldown expired, while delivery of records that failed
+	// is re-attempted to decide if circuit can be closed again.
+	CircuitHalfOpen
+)
+
+// String returns name of the circuit state.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "Closed"
+	case CircuitOpen:
+		return "Open"
+	case CircuitHalfOpen:
+		return "HalfOpen"
+	default:
+		return "Unknown"
+	}
+}
+
+// CircuitBreakerPolicy controls when circuit breaker around real handler opens and for how long.
+type CircuitBreakerPolicy struct {
+	// FailureRatio is ratio (between 0 and 1) of failed records among the most recent Window
+	// records at which circuit opens. Values outside of that range default to 0.5.
+	FailureRatio float64
+	// Window is number of the most recent records failure ratio is computed over.
+	// Values lower than 1 default to 20.
+	Window int
+	// Cooldown is time circuit stays open before delivery is attempted again.
+	// Values lower than or equal to zero default to 5s.
+	Cooldown time.Duration
+}
+
+// circuitBreaker tracks results of recent records handled by real handler and opens circuit
+// (pauses handler) when too many of them failed.
+type circuitBreaker struct {
+	lock  sync.Mutex
+	state CircuitState
+	// outcomes is ring of results of the most recent records, true for failed ones
+	outcomes []bool
+	next     int
+	failures int
+	// threshold is number of failures within window that opens circuit
+	threshold int
+	cooldown  time.Duration
+}
+
+func newCircuitBreaker(policy CircuitBreakerPolicy) *circuitBreaker {
+	ratio := policy.FailureRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 0.5
+	}
+	window := policy.Window
+	if window < 1 {
+		window = 20
+	}
+	cooldown := policy.Cooldown
+	if cooldown <= 0 {
+		cooldown = 5 * time.Second
+	}
+	return &circuitBreaker{
+		outcomes:  make([]bool, window),
+		threshold: max(int(math.Ceil(ratio*float64(window))), 1),
+		cooldown:  cooldown,
+	}
+}
+
+// record registers result of record handled by real handler and reports if circuit
+// has to be opened because of it.
+func (b *circuitBreaker) record(failed bool) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.state != CircuitClosed {
+		return false
+	}
+	if b.outcomes[b.next] {
+		b.failures--
+	}
+	b.outcomes[b.next] = failed
+	if failed {
+		b.failures++
+	}
+	b.next = (b.next + 1) % len(b.outcomes)
+	if b.failures < b.threshold {
+		return false
+	}
+	b.state = CircuitOpen
+	return true
+}
+
+// setState changes state of circuit. Closing circuit forgets results of previous records.
+func (b *circuitBreaker) setState(state CircuitState) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.state = state
+	if state == CircuitClosed {
+		clear(b.outcomes)
+		b.next, b.failures = 0, 0
+	}
+}
+
+func (b *circuitBreaker) getState() CircuitState {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.state
+}
+
+// CircuitState returns state of circuit breaker around real handler. Handler without
+// circuit breaker (see WithCircuitBreaker) is always in CircuitClosed state.
+func (h *BufferLogHandler) CircuitState() CircuitState {
+	if b := h.root().breaker; b != nil {
+		return b.getState()
+	}
+	return CircuitClosed
+}
+
+// handled registers result of record passed to real handler with circuit breaker and opens
+// circuit, if needed. It should be called on root handler.
+func (h *BufferLogHandler) handled(failed bool) {
+	if h.breaker == nil || !h.breaker.record(failed) || h.isClosed() {
+		return
+	}
+	h.setPaused(true)
+	h.afterFunc(h.breaker.cooldown, h.probe)
+}
+
+// probe re-attempts delivery of records that failed after circuit was opened. Circuit is
+// closed (and buffered records flushed) if it succeeds and opened for another cooldown
+// otherwise, until handler is closed. It should be called on root handler.
+func (h *BufferLogHandler) probe() {
+	if h.isClosed() {
+		// handler might have been closed just as cooldown elapsed
+		return
+	}
+	ctx := context.Background()
+	h.breaker.setState(CircuitHalfOpen)
+	// dead letters are delivered first and delivery stops at first failure, so records
+	// buffered in the meantime are not flushed to real handler that is still failing
+	if h.RetryFlush(ctx, RetryPolicy{}) == nil && h.Resume(ctx) == nil {
+		h.breaker.setState(CircuitClosed)
+		return
+	}
+	h.setPaused(true)
+	h.breaker.setState(CircuitOpen)
+	if !h.isClosed() {
+		h.afterFunc(h.breaker.cooldown, h.probe)
+	}
+}
@@ -0,0 +1,112 @@
+package slogbuffer_test
+
+import (
+	"github.com/delicb/slogbuffer"
+	"github.com/delicb/slogbuffer/slogbuffertest"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBufferLogHandler_WithCircuitBreaker(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithCircuitBreaker(slogbuffer.CircuitBreakerPolicy{
+		FailureRatio: 0.5,
+		Window:       4,
+		Cooldown:     10 * time.Millisecond,
+	}))
+	l := slog.New(h)
+	sink := &flakySink{}
+	setRealHandler(t, h, sink)
+	l.Info("first")
+
+	// when
+	sink.setDown(true)
+	l.Info("second")
+
+	// then
+	// single failure is below threshold
+	if h.CircuitState() != slogbuffer.CircuitClosed || h.IsBuffering() {
+		t.Fatalf("expected circuit to stay closed, got %s", h.CircuitState())
+	}
+
+	// when
+	l.Info("third")
+	l.Info("fourth")
+
+	// then
+	// records are not sent to failing real handler while circuit is open
+	if h.CircuitState() != slogbuffer.CircuitOpen || h.Len() != 1 {
+		t.Fatalf("expected open circuit and 1 buffered record, got %s and %d", h.CircuitState(), h.Len())
+	}
+
+	// when
+	sink.setDown(false)
+
+	// then
+	expected := []string{"first", "second", "third", "fourth"}
+	deadline := time.Now().Add(5 * time.Second)
+	for h.CircuitState() != slogbuffer.CircuitClosed {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected circuit to close after real handler recovered")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got := sink.received(); !slices.Equal(got, expected) {
+		t.Fatalf("expected %v to be delivered, got %v", expected, got)
+	}
+	l.Info("fifth")
+	if got := sink.received(); got[len(got)-1] != "fifth" {
+		t.Fatalf("expected record to be passed to real handler after circuit closed, got %v", got)
+	}
+}
+
+func TestBufferLogHandler_WithCircuitBreaker_Close(t *testing.T) {
+	// given
+	clock := slogbuffertest.NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithClock(clock),
+		slogbuffer.WithCircuitBreaker(slogbuffer.CircuitBreakerPolicy{
+			FailureRatio: 0.5,
+			Window:       2,
+			Cooldown:     time.Second,
+		}))
+	sink := &flakySink{}
+	setRealHandler(t, h, sink)
+	sink.setDown(true)
+	slog.New(h).Info("first")
+	slog.New(h).Info("second")
+	clock.WaitForTimers(1)
+	if h.CircuitState() != slogbuffer.CircuitOpen {
+		t.Fatalf("expected open circuit, got %s", h.CircuitState())
+	}
+
+	// when
+	if err := h.Close(); err != nil {
+		t.Fatalf("closing handler: %v", err)
+	}
+	sink.setDown(false)
+	clock.Advance(time.Minute)
+
+	// then
+	// circuit is not probed after handler was closed
+	time.Sleep(50 * time.Millisecond)
+	if received := sink.received(); len(received) != 0 {
+		t.Fatalf("expected no delivery after handler was closed, got %v", received)
+	}
+	if h.CircuitState() != slogbuffer.CircuitOpen {
+		t.Fatalf("expected circuit to stay open, got %s", h.CircuitState())
+	}
+}
+
+func TestCircuitState_String(t *testing.T) {
+	for state, expected := range map[slogbuffer.CircuitState]string{
+		slogbuffer.CircuitClosed:   "Closed",
+		slogbuffer.CircuitOpen:     "Open",
+		slogbuffer.CircuitHalfOpen: "HalfOpen",
+	} {
+		if state.String() != expected {
+			t.Fatalf("expected %s, got %s", expected, state.String())
+		}
+	}
+}
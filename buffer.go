@@ -1,27 +1,118 @@
 package slogbuffer
 
 import (
+	"context"
 	"iter"
 	"log/slog"
+	"slices"
 	"sync"
 )
 
 type record struct {
 	slog.Record
-	attrs  []slog.Attr
+	// attrs are attributes of the logger that produced record. Attributes added after
+	// group was opened are nested in group attribute with its name (see nest), so attributes
+	// keep their position relative to groups.
+	attrs []slog.Attr
+	// groups are groups of the logger that were open when record was produced.
 	groups []string
+	// handler, when set, is handler that already carries attributes and groups of the
+	// logger, so record is delivered to it directly instead of the handler provided to emit.
+	handler slog.Handler
+}
+
+// emit sends record to provided handler, applying groups and attributes
+// that were set on the logger when record was created.
+func (r record) emit(ctx context.Context, handler slog.Handler) error {
+	if r.handler != nil {
+		return r.handler.Handle(ctx, r.Record)
+	}
+	return compose(handler, r.groups, r.attrs).Handle(ctx, r.Record)
+}
+
+// compose returns provided handler with groups and attributes (nested as by nest) applied,
+// in the same order they were applied to the logger.
+func compose(handler slog.Handler, groups []string, attrs []slog.Attr) slog.Handler {
+	for _, g := range groups {
+		outer, inner := splitGroup(attrs, g)
+		if len(outer) > 0 {
+			handler = handler.WithAttrs(outer)
+		}
+		handler = handler.WithGroup(g)
+		attrs = inner
+	}
+	if len(attrs) > 0 {
+		handler = handler.WithAttrs(attrs)
+	}
+	return handler
+}
+
+// nest returns attributes with added attributes appended to the innermost of provided
+// open groups, represented by group attribute at the end of attributes of enclosing group.
+// Provided attributes are not modified, so they can be shared with other handlers.
+func nest(attrs []slog.Attr, groups []string, added []slog.Attr) []slog.Attr {
+	if len(added) == 0 {
+		return attrs
+	}
+	if len(groups) == 0 {
+		return append(slices.Clip(attrs), added...)
+	}
+	outer, inner := splitGroup(attrs, groups[0])
+	return append(slices.Clip(outer), slog.Attr{
+		Key:   groups[0],
+		Value: slog.GroupValue(nest(inner, groups[1:], added)...),
+	})
+}
+
+// splitGroup splits attributes to ones added before group with provided name was opened
+// and ones added to the group.
+func splitGroup(attrs []slog.Attr, group string) (outer, inner []slog.Attr) {
+	if n := len(attrs); n > 0 && attrs[n-1].Key == group && attrs[n-1].Value.Kind() == slog.KindGroup {
+		return attrs[:n-1], attrs[n-1].Value.Group()
+	}
+	return attrs, nil
+}
+
+// allAttrs returns attributes of the logger and attributes of the record, nested in
+// groups of the logger.
+func (r record) allAttrs() []slog.Attr {
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	return nest(r.attrs, r.groups, attrs)
+}
+
+// materialize returns copy of the record with attributes and groups of the logger
+// folded into attributes of the record.
+func (r record) materialize() slog.Record {
+	res := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	res.AddAttrs(r.allAttrs()...)
+	return res
 }
 
 // buffer is a structure that stores provided values and allows iteration and cleaning entire buffer.
 // If it is bound by maximum number of elements, oldest elements are overwritten when new ones
 // are added. Otherwise, it grows without limit.
 type buffer[T any] struct {
-	// store is actual storage of elements
+	// store is actual storage of elements, used as ring. Its length is number of elements
+	// it can hold before it has to grow (or, for bound buffer, before oldest elements are
+	// overwritten).
 	store []T
 	// flag indicating if storage should be bound to max number of elements or unlimited in size
 	bound bool
-	// for bound use case, this is start index
+	// startIndex is index of the oldest element in store
 	startIndex int
+	// count is number of elements in the buffer
+	count int
+
+	// onAdd and onRemove, when set, are called (while holding the lock) for every element
+	// added to the buffer and every element removed from it (including overwritten ones).
+	// onEvict is additionally called for overwritten elements.
+	onAdd    func(T)
+	onRemove func(T)
+	onEvict  func(T)
 
 	lock sync.Mutex
 }
@@ -30,24 +121,173 @@ type buffer[T any] struct {
 // If buffer is bound and capacity is reached, this will cause the oldest element to be
 // removed to make space for new one.
 func (b *buffer[T]) Add(element T) {
+	if b == nil {
+		return
+	}
 	b.lock.Lock()
 	defer b.lock.Unlock()
 
-	// if not bound of there is still capacity, just append element
-	if !b.bound || cap(b.store) > len(b.store) {
-		b.store = append(b.store, element)
+	if b.onAdd != nil {
+		b.onAdd(element)
+	}
+
+	// if there is still capacity (or storage can grow), just add element after the newest one
+	if b.count == len(b.store) && !b.bound {
+		b.grow()
+	}
+	if b.count < len(b.store) {
+		b.store[(b.startIndex+b.count)%len(b.store)] = element
+		b.count++
 		return
 	}
 
 	// we are at capacity, so overwrite the oldest entry by storing new entry
 	// at current start and move current start to next element, being
 	// careful to wrap if we exceed slice size
+	if b.onRemove != nil {
+		b.onRemove(b.store[b.startIndex])
+	}
+	if b.onEvict != nil {
+		b.onEvict(b.store[b.startIndex])
+	}
 	b.store[b.startIndex] = element
 
-	newStart := (b.startIndex + 1) % cap(b.store)
+	newStart := (b.startIndex + 1) % len(b.store)
 	b.startIndex = newStart
 }
 
+// grow makes storage of unbound buffer larger, keeping elements in order. Caller must hold the lock.
+func (b *buffer[T]) grow() {
+	store := make([]T, max(2*len(b.store), unboundBufferCap))
+	b.copyTo(store, 0, b.count)
+	b.store = store
+	b.startIndex = 0
+}
+
+// resize changes maximum number of elements buffer can hold, evicting the oldest elements
+// if there are more of them. Zero makes buffer unbound.
+func (b *buffer[T]) resize(maxElements int) {
+	if b == nil {
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for maxElements > 0 && b.count > maxElements {
+		b.removeOldest(true)
+	}
+	size := maxElements
+	if size == 0 {
+		size = max(b.count, unboundBufferCap)
+	}
+	store := make([]T, size)
+	b.copyTo(store, 0, b.count)
+	b.store = store
+	b.startIndex = 0
+	b.bound = maxElements > 0
+}
+
+// evictWhile removes the oldest elements as long as provided function returns true for them.
+// Removed elements are reported as evicted.
+func (b *buffer[T]) evictWhile(evict func(oldest T) bool) {
+	if b == nil {
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for b.count > 0 && evict(b.store[b.startIndex]) {
+		b.removeOldest(true)
+	}
+}
+
+// removeIf removes elements for which provided function returns true, keeping order of the
+// remaining ones. Removed elements are not reported as evicted.
+func (b *buffer[T]) removeIf(remove func(el T) bool) {
+	if b == nil {
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	kept := 0
+	for i := range b.count {
+		el := b.store[(b.startIndex+i)%len(b.store)]
+		if remove(el) {
+			if b.onRemove != nil {
+				b.onRemove(el)
+			}
+			continue
+		}
+		// kept elements are moved towards the oldest one, never past element not yet visited
+		b.store[(b.startIndex+kept)%len(b.store)] = el
+		kept++
+	}
+	// removed elements are zeroed, so values they reference can be collected
+	var zero T
+	for i := kept; i < b.count; i++ {
+		b.store[(b.startIndex+i)%len(b.store)] = zero
+	}
+	b.count = kept
+}
+
+// PeekOldest returns the oldest element without removing it. Returns false if buffer is empty.
+func (b *buffer[T]) PeekOldest() (T, bool) {
+	var zero T
+	if b == nil {
+		return zero, false
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.count == 0 {
+		return zero, false
+	}
+	return b.store[b.startIndex], true
+}
+
+// PeekNewest returns the newest element without removing it. Returns false if buffer is empty.
+func (b *buffer[T]) PeekNewest() (T, bool) {
+	var zero T
+	if b == nil {
+		return zero, false
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.count == 0 {
+		return zero, false
+	}
+	return b.store[(b.startIndex+b.count-1)%len(b.store)], true
+}
+
+// PopOldest removes the oldest element and returns it. Returns false if buffer is empty.
+func (b *buffer[T]) PopOldest() (T, bool) {
+	var zero T
+	if b == nil {
+		return zero, false
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.count == 0 {
+		return zero, false
+	}
+	el := b.store[b.startIndex]
+	b.removeOldest(false)
+	return el, true
+}
+
+// removeOldest removes the oldest element, reporting it as evicted if requested. Caller must
+// hold the lock and buffer must not be empty.
+func (b *buffer[T]) removeOldest(evicted bool) {
+	if b.onRemove != nil {
+		b.onRemove(b.store[b.startIndex])
+	}
+	if evicted && b.onEvict != nil {
+		b.onEvict(b.store[b.startIndex])
+	}
+	// removed element is zeroed, so values it references can be collected
+	var zero T
+	b.store[b.startIndex] = zero
+	b.startIndex = (b.startIndex + 1) % len(b.store)
+	b.count--
+}
+
 // iterators implementation
 
 // All is two-value iterator (index and value) over the buffer.
@@ -64,9 +304,8 @@ func (b *buffer[T]) All() iter.Seq2[int, T] {
 
 		// it does not matter if storage is bound or not, this implementation of iteration
 		// works the same
-		maxCap := cap(b.store)
-		for i := range len(b.store) {
-			ix := (b.startIndex + i) % maxCap
+		for i := range b.count {
+			ix := (b.startIndex + i) % len(b.store)
 			if !yield(i, b.store[ix]) {
 				return
 			}
@@ -102,19 +341,122 @@ func (b *buffer[T]) Clear() {
 	}
 	b.lock.Lock()
 	defer b.lock.Unlock()
-	b.store = make([]T, 0, cap(b.store))
+	b.reset()
+}
+
+// Snapshot returns copy of all elements in the buffer in same order they were added.
+func (b *buffer[T]) Snapshot() []T {
+	if b == nil {
+		return nil
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.snapshot()
+}
+
+// Take removes all elements from the buffer and returns them in same order they were added.
+func (b *buffer[T]) Take() []T {
+	if b == nil {
+		return nil
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	res := b.snapshot()
+	b.reset()
+	return res
+}
+
+// reset removes all elements from the buffer. Caller must hold the lock.
+// Storage of unbound buffer is released, so memory used during burst of elements is
+// returned to the runtime once buffer is drained. Storage of bound buffer is limited
+// anyway, so it is reused.
+func (b *buffer[T]) reset() {
+	if b.onRemove != nil {
+		for i := range b.count {
+			b.onRemove(b.store[(b.startIndex+i)%len(b.store)])
+		}
+	}
+	if b.bound {
+		// removed elements are zeroed, so values they reference can be collected
+		clear(b.store)
+	} else {
+		b.store = make([]T, unboundBufferCap)
+	}
+	b.startIndex = 0
+	b.count = 0
+}
+
+// Head returns copy of at most n oldest elements in the buffer, oldest first.
+func (b *buffer[T]) Head(n int) []T {
+	if b == nil {
+		return nil
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.copyRange(0, min(max(n, 0), b.count))
+}
 
+// Tail returns copy of at most n newest elements in the buffer, oldest first.
+func (b *buffer[T]) Tail(n int) []T {
+	if b == nil {
+		return nil
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.copyRange(max(b.count-max(n, 0), 0), b.count)
+}
+
+// snapshot copies elements to new slice. Caller must hold the lock.
+func (b *buffer[T]) snapshot() []T {
+	return b.copyRange(0, b.count)
+}
+
+// copyRange copies elements between from (inclusive) and to (exclusive), counting from
+// the oldest element, to new slice. Caller must hold the lock.
+func (b *buffer[T]) copyRange(from, to int) []T {
+	res := make([]T, to-from)
+	b.copyTo(res, from, to)
+	return res
+}
+
+// copyTo copies elements between from (inclusive) and to (exclusive), counting from the
+// oldest element, to provided slice. Caller must hold the lock.
+func (b *buffer[T]) copyTo(dst []T, from, to int) {
+	for i := from; i < to; i++ {
+		dst[i-from] = b.store[(b.startIndex+i)%len(b.store)]
+	}
 }
 
 // Len returns current number of elements in buffer.
-func (b *buffer[T]) Len() int { return len(b.store) }
+func (b *buffer[T]) Len() int {
+	if b == nil {
+		return 0
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.count
+}
+
+// Cap returns maximum number of elements buffer can hold. Unbound buffer returns 0.
+func (b *buffer[T]) Cap() int {
+	if b == nil {
+		return 0
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if !b.bound {
+		return 0
+	}
+	return len(b.store)
+}
 
 // IsFull returns flag indicating if buffer is full. Unbound buffer is never full.
 func (b *buffer[T]) IsFull() bool {
 	if !b.bound {
 		return false
 	}
-	return len(b.store) == cap(b.store)
+	return b.count == len(b.store)
 }
 
 // newBuffer returns instance of a buffer.
@@ -125,15 +467,17 @@ func (b *buffer[T]) IsFull() bool {
 func newBuffer[T any](maxElements int) *buffer[T] {
 	if maxElements > 0 {
 		return &buffer[T]{
-			store: make([]T, 0, maxElements),
+			store: make([]T, maxElements),
 			bound: true,
 		}
 	}
 
 	// unbound buffer case
 	return &buffer[T]{
-		// cap 16 is arbitrary, just to avoid allocating and copying elements
-		// for small buffers
-		store: make([]T, 0, 16),
+		store: make([]T, unboundBufferCap),
 	}
 }
+
+// unboundBufferCap is initial capacity of unbound buffer. It is arbitrary, just to avoid
+// allocating and copying elements for small buffers.
+const unboundBufferCap = 16
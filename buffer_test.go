@@ -1,6 +1,7 @@
 package slogbuffer
 
 import (
+	"slices"
 	"testing"
 )
 
@@ -92,3 +93,143 @@ func TestBoundBufferOverCapacity(t *testing.T) {
 		t.Fatalf("buffer has length %d, expected 3", b.Len())
 	}
 }
+
+func TestBuffer_Hooks(t *testing.T) {
+	b := newBuffer[int](2)
+	var added, removed []int
+	b.onAdd = func(el int) { added = append(added, el) }
+	b.onRemove = func(el int) { removed = append(removed, el) }
+
+	for i := range 3 {
+		b.Add(i)
+	}
+	b.Take()
+
+	expectSlice(t, added, []int{0, 1, 2})
+	expectSlice(t, removed, []int{0, 1, 2})
+}
+
+func expectSlice[T comparable](t *testing.T, got []T, expect []T) {
+	t.Helper()
+	if !slices.Equal(got, expect) {
+		t.Fatalf("got %v, expected %v", got, expect)
+	}
+}
+
+func TestBuffer_HeadTail(t *testing.T) {
+	b := newBuffer[int](4)
+	for i := range 6 {
+		b.Add(i)
+	}
+
+	expectSlice(t, b.Head(2), []int{2, 3})
+	expectSlice(t, b.Tail(2), []int{4, 5})
+	expectSlice(t, b.Head(10), []int{2, 3, 4, 5})
+	expectSlice(t, b.Tail(10), []int{2, 3, 4, 5})
+	expectSlice(t, b.Tail(0), []int{})
+	expectSlice(t, b.Head(-1), []int{})
+}
+
+func TestBuffer_ReleaseOnClear(t *testing.T) {
+	unbound := newBuffer[int](0)
+	for i := range 1000 {
+		unbound.Add(i)
+	}
+	unbound.Take()
+	if c := cap(unbound.store); c != unboundBufferCap {
+		t.Fatalf("unbound buffer has capacity %d after take, expected %d", c, unboundBufferCap)
+	}
+	unbound.Add(1)
+	expectBufferContent(t, unbound, []int{1})
+
+	bound := newBuffer[*int](3)
+	for i := range 5 {
+		bound.Add(&i)
+	}
+	bound.Clear()
+	if c := cap(bound.store); c != 3 {
+		t.Fatalf("bound buffer has capacity %d after clear, expected 3", c)
+	}
+	for i, el := range bound.store[:cap(bound.store)] {
+		if el != nil {
+			t.Fatalf("element at index %d is still referenced after clear", i)
+		}
+	}
+}
+
+func TestBuffer_EvictWhile(t *testing.T) {
+	for _, b := range []*buffer[int]{newBuffer[int](4), newBuffer[int](0)} {
+		// given
+		var evicted []int
+		b.onEvict = func(el int) { evicted = append(evicted, el) }
+		for i := range 6 {
+			b.Add(i)
+		}
+
+		// when
+		b.evictWhile(func(el int) bool { return el < 4 })
+		b.Add(6)
+		b.Add(7)
+
+		// then
+		if b.Len() != 4 {
+			t.Fatalf("expected 4 elements, got %d", b.Len())
+		}
+		expectBufferContent(t, b, []int{4, 5, 6, 7})
+		if !slices.Equal(evicted, []int{0, 1, 2, 3}) {
+			t.Fatalf("unexpected evicted elements %v", evicted)
+		}
+	}
+}
+
+func TestBuffer_Pop(t *testing.T) {
+	for _, b := range []*buffer[int]{newBuffer[int](4), newBuffer[int](0)} {
+		// given
+		var removed, evicted []int
+		b.onRemove = func(el int) { removed = append(removed, el) }
+		b.onEvict = func(el int) { evicted = append(evicted, el) }
+		for i := range 3 {
+			b.Add(i)
+		}
+
+		// when
+		first, ok := b.PopOldest()
+		b.Add(3)
+
+		// then
+		if !ok || first != 0 {
+			t.Fatalf("expected to pop 0, got %d (%t)", first, ok)
+		}
+		expectBufferContent(t, b, []int{1, 2, 3})
+		if !slices.Equal(removed, []int{0}) || len(evicted) != 0 {
+			t.Fatalf("unexpected removed %v and evicted %v elements", removed, evicted)
+		}
+		for range 3 {
+			b.PopOldest()
+		}
+		if _, ok := b.PopOldest(); ok || b.Len() != 0 {
+			t.Fatalf("expected empty buffer, got %d elements", b.Len())
+		}
+	}
+}
+
+func TestBuffer_Peek(t *testing.T) {
+	for _, b := range []*buffer[int]{newBuffer[int](3), newBuffer[int](0)} {
+		// given
+		if _, ok := b.PeekOldest(); ok {
+			t.Fatalf("expected nothing to peek in empty buffer")
+		}
+		for i := range 4 {
+			b.Add(i)
+		}
+
+		// when
+		oldest, _ := b.PeekOldest()
+		newest, _ := b.PeekNewest()
+
+		// then
+		if b.bound && oldest != 1 || !b.bound && oldest != 0 || newest != 3 {
+			t.Fatalf("unexpected oldest %d and newest %d", oldest, newest)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package slogbuffer
+
+import (
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// CapturedEntry is simple representation of buffered record, convenient for assertions in tests.
+type CapturedEntry struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	// Attrs are attributes of the logger and of the record itself, nested in groups they belong to.
+	// Values are resolved and held as their Go values (e.g. int64 for slog.Int, time.Duration for
+	// slog.Duration), while attributes of groups are held as nested map[string]any.
+	Attrs map[string]any
+	// Groups are groups of the logger that were open when record was produced.
+	Groups []string
+}
+
+// CapturedEntries returns buffered records as CapturedEntry values, in the order they were
+// logged, so tests can assert on logged records without parsing output of some handler.
+// Buffer is not changed.
+func (h *BufferLogHandler) CapturedEntries() []CapturedEntry {
+	records := h.buffer.Snapshot()
+	entries := make([]CapturedEntry, 0, len(records))
+	for _, rec := range records {
+		entries = append(entries, CapturedEntry{
+			Time:    rec.Time,
+			Level:   rec.Level,
+			Message: rec.Message,
+			Attrs:   capturedAttrs(rec.allAttrs()),
+			Groups:  slices.Clone(rec.groups),
+		})
+	}
+	return entries
+}
+
+// capturedAttrs converts attributes to map, recursively converting groups.
+func capturedAttrs(attrs []slog.Attr) map[string]any {
+	res := make(map[string]any, len(attrs))
+	for _, a := range attrs {
+		if a.Equal(slog.Attr{}) {
+			continue
+		}
+		v := a.Value.Resolve()
+		if v.Kind() != slog.KindGroup {
+			res[a.Key] = v.Any()
+			continue
+		}
+		group := capturedAttrs(v.Group())
+		if len(group) == 0 {
+			// empty groups are ignored by handlers
+			continue
+		}
+		if a.Key == "" {
+			// attributes of group with empty key are inlined
+			for k, gv := range group {
+				res[k] = gv
+			}
+			continue
+		}
+		res[a.Key] = group
+	}
+	return res
+}
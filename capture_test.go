@@ -0,0 +1,50 @@
+package slogbuffer_test
+
+import (
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBufferLogHandler_CapturedEntries(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	l := slog.New(h)
+
+	// when
+	l.Debug("starting", "attempt", 1, "timeout", time.Second)
+	l.With("service", "api").WithGroup("req").Info("handled",
+		"status", 200, slog.Group("", slog.Bool("inlined", true)), slog.Group("empty"))
+
+	// then
+	entries := h.CapturedEntries()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	expected := []slogbuffer.CapturedEntry{
+		{
+			Time:    entries[0].Time,
+			Level:   slog.LevelDebug,
+			Message: "starting",
+			Attrs:   map[string]any{"attempt": int64(1), "timeout": time.Second},
+		},
+		{
+			Time:    entries[1].Time,
+			Level:   slog.LevelInfo,
+			Message: "handled",
+			Attrs: map[string]any{
+				"service": "api",
+				"req":     map[string]any{"status": int64(200), "inlined": true},
+			},
+			Groups: []string{"req"},
+		},
+	}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Fatalf("expected entries %+v, got %+v", expected, entries)
+	}
+	if entries[0].Time.IsZero() {
+		t.Fatalf("expected time of entry to be set")
+	}
+}
@@ -0,0 +1,225 @@
+package slogbuffer
+
+import (
+	"sync"
+)
+
+// defaultChunkSize is number of records in single chunk used when chunk size is not provided.
+const defaultChunkSize = 1024
+
+// recordChunk is part of chunkedStorage, holding up to chunk size records.
+type recordChunk struct {
+	records []record
+	next    *recordChunk
+}
+
+// chunkedStorage keeps records in memory in linked list of fixed size chunks. Unlike buffer,
+// which grows by copying all records to storage twice as large, it grows by one chunk at a
+// time, so growth does not cause copy spikes nor temporarily doubles memory, and chunks are
+// released as soon as all records in them are removed.
+type chunkedStorage struct {
+	// maxRecords is maximum number of records, 0 if number of records is not limited
+	maxRecords int
+	chunkSize  int
+
+	// head is chunk with the oldest records, tail is chunk new records are added to
+	head, tail *recordChunk
+	// start is index of the oldest record in head chunk
+	start int
+	// count is number of stored records
+	count int
+
+	// onAdd and onRemove, when set, are called (while holding the lock) for every record
+	// added to the storage and every record removed from it. onEvict is additionally called
+	// for records removed to make space for new ones.
+	onAdd    func(record)
+	onRemove func(record)
+	onEvict  func(record)
+
+	lock sync.Mutex
+}
+
+func newChunkedStorage(maxRecords int, chunkSize int) *chunkedStorage {
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
+	return &chunkedStorage{
+		maxRecords: max(maxRecords, 0),
+		chunkSize:  chunkSize,
+	}
+}
+
+func (s *chunkedStorage) Add(rec record) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.tail == nil || len(s.tail.records) == s.chunkSize {
+		chunk := &recordChunk{records: make([]record, 0, s.chunkSize)}
+		if s.tail == nil {
+			s.head = chunk
+		} else {
+			s.tail.next = chunk
+		}
+		s.tail = chunk
+	}
+	s.tail.records = append(s.tail.records, rec)
+	s.count++
+	if s.onAdd != nil {
+		s.onAdd(rec)
+	}
+	if s.maxRecords > 0 && s.count > s.maxRecords {
+		s.removeOldest(true)
+	}
+	return nil
+}
+
+// removeOldest removes the oldest record, reporting it as evicted if requested. Caller must
+// hold the lock and storage must not be empty.
+func (s *chunkedStorage) removeOldest(evicted bool) {
+	rec := s.head.records[s.start]
+	if s.onRemove != nil {
+		s.onRemove(rec)
+	}
+	if evicted && s.onEvict != nil {
+		s.onEvict(rec)
+	}
+	// removed record is zeroed, so values it references can be collected
+	s.head.records[s.start] = record{}
+	s.start++
+	s.count--
+	if s.start == len(s.head.records) {
+		if s.head == s.tail {
+			s.head, s.tail = nil, nil
+		} else {
+			s.head = s.head.next
+		}
+		s.start = 0
+	}
+}
+
+// records returns records between from (inclusive) and to (exclusive), counting from the
+// oldest one. Caller must hold the lock.
+func (s *chunkedStorage) records(from, to int) []record {
+	res := make([]record, 0, max(to-from, 0))
+	// index of the first record of current chunk
+	start := -s.start
+	for chunk := s.head; chunk != nil && start < to; chunk = chunk.next {
+		end := start + len(chunk.records)
+		if end > from {
+			res = append(res, chunk.records[max(from-start, 0):min(to-start, len(chunk.records))]...)
+		}
+		start = end
+	}
+	return res
+}
+
+func (s *chunkedStorage) Take() []record {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	res := s.records(0, s.count)
+	s.reset()
+	return res
+}
+
+func (s *chunkedStorage) Snapshot() []record {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.records(0, s.count)
+}
+
+func (s *chunkedStorage) Head(n int) []record {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.records(0, min(max(n, 0), s.count))
+}
+
+func (s *chunkedStorage) Tail(n int) []record {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.records(max(s.count-max(n, 0), 0), s.count)
+}
+
+func (s *chunkedStorage) PopOldest() (record, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.count == 0 {
+		return record{}, false
+	}
+	rec := s.head.records[s.start]
+	s.removeOldest(false)
+	return rec, true
+}
+
+func (s *chunkedStorage) Clear() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.reset()
+}
+
+// reset removes all records, releasing all chunks. Caller must hold the lock.
+func (s *chunkedStorage) reset() {
+	if s.onRemove != nil {
+		for _, rec := range s.records(0, s.count) {
+			s.onRemove(rec)
+		}
+	}
+	s.head, s.tail = nil, nil
+	s.start = 0
+	s.count = 0
+}
+
+func (s *chunkedStorage) evictWhile(evict func(oldest record) bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for s.count > 0 && evict(s.head.records[s.start]) {
+		s.removeOldest(true)
+	}
+}
+
+func (s *chunkedStorage) removeIf(remove func(rec record) bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	kept := make([]record, 0, s.count)
+	for _, rec := range s.records(0, s.count) {
+		if !remove(rec) {
+			kept = append(kept, rec)
+		} else if s.onRemove != nil {
+			s.onRemove(rec)
+		}
+	}
+	// remaining records are packed into new chunks, so chunks with no records left are released
+	s.head, s.tail = nil, nil
+	s.start, s.count = 0, len(kept)
+	for chunkStart := 0; chunkStart < len(kept); chunkStart += s.chunkSize {
+		chunk := &recordChunk{records: make([]record, 0, s.chunkSize)}
+		chunk.records = append(chunk.records, kept[chunkStart:min(chunkStart+s.chunkSize, len(kept))]...)
+		if s.tail == nil {
+			s.head = chunk
+		} else {
+			s.tail.next = chunk
+		}
+		s.tail = chunk
+	}
+}
+
+func (s *chunkedStorage) resize(maxRecords int) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.maxRecords = maxRecords
+	for s.maxRecords > 0 && s.count > s.maxRecords {
+		s.removeOldest(true)
+	}
+	return nil
+}
+
+func (s *chunkedStorage) Len() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.count
+}
+
+func (s *chunkedStorage) Cap() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.maxRecords
+}
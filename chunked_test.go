@@ -0,0 +1,81 @@
+package slogbuffer_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/delicb/slogbuffer"
+	"github.com/delicb/slogbuffer/slogbuffertest"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestBufferLogHandler_WithChunkedGrowth(t *testing.T) {
+	for _, maxRecords := range []int{0, 50} {
+		t.Run(fmt.Sprintf("max %d", maxRecords), func(t *testing.T) {
+			// given
+			// small chunks, so records span multiple chunks
+			h := slogbuffer.NewBoundBufferLogHandler(slog.LevelDebug, maxRecords, slogbuffer.WithChunkedGrowth(16))
+			l := slog.New(h).WithGroup("g1").With("common", "attr")
+
+			// when
+			for i := range 120 {
+				l.Info("msg", "no", i)
+			}
+
+			// then
+			expected := 120
+			if maxRecords > 0 {
+				expected = maxRecords
+			}
+			if h.Len() != expected || h.Cap() != maxRecords {
+				t.Fatalf("unexpected len %d and cap %d", h.Len(), h.Cap())
+			}
+			if h.Dropped() != uint64(120-expected) {
+				t.Fatalf("expected %d dropped records, got %d", 120-expected, h.Dropped())
+			}
+			first := 120 - expected
+			head, tail := h.Head(1), h.Tail(20)
+			expectRecordAttr(t, head[0], "g1", slog.GroupValue(slog.String("common", "attr"), slog.Int64("no", int64(first))))
+			expectRecordAttr(t, tail[0], "g1", slog.GroupValue(slog.String("common", "attr"), slog.Int64("no", 100)))
+
+			rh, reader := getSimplifiedTextHandler()
+			setRealHandler(t, h, rh)
+			lines := getLines(t, reader)
+
+			expectLinesNo(t, lines, expected)
+			for i, line := range lines {
+				expectAttr(t, line, "g1.no", fmt.Sprintf("%d", i+first))
+			}
+			if h.Len() != 0 {
+				t.Fatalf("expected no records after flush, got %d", h.Len())
+			}
+		})
+	}
+}
+
+func TestBufferLogHandler_WithChunkedGrowth_MaxAge(t *testing.T) {
+	// given
+	clock := slogbuffertest.NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithChunkedGrowth(4),
+		slogbuffer.WithMaxAge(time.Minute), slogbuffer.WithClock(clock))
+	for i := range 10 {
+		r := slog.NewRecord(clock.Now(), slog.LevelInfo, "msg", 0)
+		r.AddAttrs(slog.Int("no", i))
+		if err := h.Handle(context.Background(), r); err != nil {
+			t.Fatalf("handling record: %v", err)
+		}
+		clock.Advance(10 * time.Second)
+	}
+
+	// when
+	records := h.Records()
+
+	// then
+	// records are evicted when new one is added, so ones older than a minute at the time
+	// the last one was added are gone
+	if len(records) != 7 {
+		t.Fatalf("expected 7 records, got %d", len(records))
+	}
+	expectRecordAttr(t, records[0], "no", slog.IntValue(3))
+}
@@ -0,0 +1,82 @@
+package slogbuffer
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// LogConfig describes real handler of CLI application, usually populated from command line
+// flags (see RegisterFlags).
+type LogConfig struct {
+	// Level is minimal level of records written to output.
+	Level slog.Level
+	// Format is "text" or "json".
+	Format string
+	// Output is "stderr", "stdout" or path of file records are appended to.
+	Output string
+}
+
+// RegisterFlags registers -log-level, -log-format and -log-output flags, which populate config,
+// on provided flag set. Defaults are info level, text format and stderr output.
+func (c *LogConfig) RegisterFlags(fs *flag.FlagSet) {
+	fs.TextVar(&c.Level, "log-level", slog.LevelInfo, "minimal level of log records (debug, info, warn or error)")
+	fs.StringVar(&c.Format, "log-format", "text", "format of log records (text or json)")
+	fs.StringVar(&c.Output, "log-output", "stderr", "output of log records (stderr, stdout or path of file)")
+}
+
+// NewHandler creates handler described by config. Returned closer closes output file, if
+// records are written to one, and does nothing otherwise.
+func (c *LogConfig) NewHandler() (slog.Handler, io.Closer, error) {
+	var (
+		w      io.Writer
+		closer io.Closer = nopCloser{}
+	)
+	switch c.Output {
+	case "", "stderr":
+		w = os.Stderr
+	case "stdout":
+		w = os.Stdout
+	default:
+		f, err := os.OpenFile(c.Output, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("opening log output: %w", err)
+		}
+		w, closer = f, f
+	}
+
+	opts := &slog.HandlerOptions{Level: c.Level}
+	switch c.Format {
+	case "", "text":
+		return slog.NewTextHandler(w, opts), closer, nil
+	case "json":
+		return slog.NewJSONHandler(w, opts), closer, nil
+	default:
+		_ = closer.Close()
+		return nil, nil, fmt.Errorf("unknown log format %q", c.Format)
+	}
+}
+
+// nopCloser is io.Closer that does nothing.
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+// Bootstrap wires common pattern of CLI applications: it sets default logger to one that buffers
+// records of all levels (see InstallDefault) and returns start function that, once config is
+// populated (e.g. after flag.Parse), creates real handler described by config and flushes buffered
+// records to it. Records below configured level are dropped during flush. Returned closer should be
+// closed before application exits.
+func Bootstrap(config *LogConfig, opts ...Option) (start func(ctx context.Context) (io.Closer, error)) {
+	promote := InstallDefault(slog.LevelDebug, append([]Option{WithFlushLevelCheck(true)}, opts...)...)
+	return func(ctx context.Context) (io.Closer, error) {
+		real, closer, err := config.NewHandler()
+		if err != nil {
+			return nil, err
+		}
+		return closer, promote(ctx, real)
+	}
+}
@@ -0,0 +1,73 @@
+package slogbuffer_test
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBootstrap(t *testing.T) {
+	// given
+	original := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(original) })
+	output := filepath.Join(t.TempDir(), "app.log")
+
+	var config slogbuffer.LogConfig
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	config.RegisterFlags(fs)
+	start := slogbuffer.Bootstrap(&config)
+
+	// when
+	// records are logged before flags are parsed
+	slog.Info("info msg")
+	slog.Warn("warn msg", "attr", "value")
+	if err := fs.Parse([]string{"-log-level=warn", "-log-format=json", "-log-output=" + output}); err != nil {
+		t.Fatalf("parsing flags: %v", err)
+	}
+	closer, err := start(context.Background())
+	if err != nil {
+		t.Fatalf("starting logging: %v", err)
+	}
+	slog.Error("error msg")
+	if err := closer.Close(); err != nil {
+		t.Fatalf("closing output: %v", err)
+	}
+
+	// then
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("reading output: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", data)
+	}
+	for i, expected := range []string{"warn msg", "error msg"} {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(lines[i]), &entry); err != nil {
+			t.Fatalf("decoding %q: %v", lines[i], err)
+		}
+		if entry["msg"] != expected {
+			t.Fatalf("expected message %q, got %v", expected, entry["msg"])
+		}
+	}
+}
+
+func TestLogConfig_NewHandler_UnknownFormat(t *testing.T) {
+	// given
+	config := slogbuffer.LogConfig{Format: "xml"}
+
+	// when
+	_, _, err := config.NewHandler()
+
+	// then
+	if err == nil {
+		t.Fatalf("expected error for unknown format")
+	}
+}
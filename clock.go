@@ -0,0 +1,42 @@
+package slogbuffer
+
+import (
+	"time"
+)
+
+// Clock provides current time and timers to time-dependent features of BufferLogHandler (age
+// limit, buffer delay and replay time attributes, retries, automatic re-buffering and circuit
+// breaker), so they can be tested deterministically, without sleeping (see WithClock).
+// Implementations must be safe for concurrent use.
+type Clock interface {
+	// Now returns current time.
+	Now() time.Time
+	// After returns channel that receives current time once provided duration elapses.
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock is Clock that uses time package.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// now returns current time according to clock of the handler.
+func (h *BufferLogHandler) now() time.Time {
+	return h.getOptions().clock.Now()
+}
+
+// afterFunc calls provided function in its own goroutine once provided duration elapses
+// according to clock of the handler, unless handler is closed before that.
+func (h *BufferLogHandler) afterFunc(d time.Duration, f func()) {
+	after := h.getOptions().clock.After(d)
+	closed := h.root().closed
+	go func() {
+		select {
+		case <-after:
+			f()
+		case <-closed:
+		}
+	}()
+}
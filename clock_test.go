@@ -0,0 +1,37 @@
+package slogbuffer_test
+
+import (
+	"context"
+	"github.com/delicb/slogbuffer"
+	"github.com/delicb/slogbuffer/slogbuffertest"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestBufferLogHandler_WithClock(t *testing.T) {
+	// given
+	clock := slogbuffertest.NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug,
+		slogbuffer.WithClock(clock), slogbuffer.WithMaxAge(time.Minute), slogbuffer.WithBufferDelay("delay"))
+	// records are stamped with time of the clock, instead of time of logging
+	log := func(msg string) {
+		if err := h.Handle(context.Background(), slog.NewRecord(clock.Now(), slog.LevelInfo, msg, 0)); err != nil {
+			t.Fatalf("handling record: %v", err)
+		}
+	}
+
+	// when
+	log("old")
+	clock.Advance(2 * time.Minute)
+	log("new")
+	clock.Advance(30 * time.Second)
+
+	// then
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandler(t, h, rh)
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 1)
+	expectMsg(t, lines[0], "new")
+	expectAttr(t, lines[0], "delay", "30s")
+}
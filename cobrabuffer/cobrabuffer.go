@@ -0,0 +1,61 @@
+// Package cobrabuffer wires [slogbuffer.Bootstrap] into CLI applications built using Cobra.
+//
+// It is a separate module, so slogbuffer itself does not depend on Cobra.
+package cobrabuffer
+
+import (
+	"flag"
+	"github.com/delicb/slogbuffer"
+	"github.com/spf13/cobra"
+	"io"
+)
+
+// Bootstrap sets default logger to one that buffers records (see slogbuffer.Bootstrap), registers
+// --log-level, --log-format and --log-output persistent flags on provided (usually root) command
+// and chains persistent pre-run hook that, once flags are parsed, creates real handler from them
+// and flushes buffered records to it. Output file, if used, is closed by persistent post-run hook.
+//
+// Cobra runs only the nearest persistent hooks, so subcommands that define their own should call
+// hooks of the root command, or cobra.EnableTraverseRunHooks should be set.
+func Bootstrap(cmd *cobra.Command, opts ...slogbuffer.Option) {
+	var config slogbuffer.LogConfig
+	fs := flag.NewFlagSet(cmd.Name(), flag.ContinueOnError)
+	config.RegisterFlags(fs)
+	cmd.PersistentFlags().AddGoFlagSet(fs)
+
+	start := slogbuffer.Bootstrap(&config, opts...)
+	var closer io.Closer
+
+	preRunE, preRun := cmd.PersistentPreRunE, cmd.PersistentPreRun
+	cmd.PersistentPreRun = nil
+	cmd.PersistentPreRunE = func(c *cobra.Command, args []string) error {
+		var err error
+		if closer, err = start(c.Context()); err != nil {
+			return err
+		}
+		if preRunE != nil {
+			return preRunE(c, args)
+		}
+		if preRun != nil {
+			preRun(c, args)
+		}
+		return nil
+	}
+
+	postRunE, postRun := cmd.PersistentPostRunE, cmd.PersistentPostRun
+	cmd.PersistentPostRun = nil
+	cmd.PersistentPostRunE = func(c *cobra.Command, args []string) error {
+		var err error
+		if postRunE != nil {
+			err = postRunE(c, args)
+		} else if postRun != nil {
+			postRun(c, args)
+		}
+		if closer != nil {
+			if closeErr := closer.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		return err
+	}
+}
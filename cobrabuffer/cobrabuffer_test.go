@@ -0,0 +1,57 @@
+package cobrabuffer_test
+
+import (
+	"github.com/delicb/slogbuffer/cobrabuffer"
+	"github.com/spf13/cobra"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBootstrap(t *testing.T) {
+	// given
+	original := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(original) })
+	output := filepath.Join(t.TempDir(), "app.log")
+
+	var preRun bool
+	root := &cobra.Command{
+		Use: "app",
+		PersistentPreRun: func(*cobra.Command, []string) {
+			preRun = true
+		},
+		Run: func(*cobra.Command, []string) {
+			slog.Debug("ignored msg")
+			slog.Warn("run msg")
+		},
+	}
+	cobrabuffer.Bootstrap(root)
+
+	// when
+	// records are logged before flags are parsed
+	slog.Info("startup msg")
+	root.SetArgs([]string{"--log-level", "info", "--log-output", output})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("executing command: %v", err)
+	}
+
+	// then
+	if !preRun {
+		t.Fatalf("expected existing persistent pre-run hook to be called")
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("reading output: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", data)
+	}
+	for i, expected := range []string{"msg=\"startup msg\"", "msg=\"run msg\""} {
+		if !strings.Contains(lines[i], expected) {
+			t.Fatalf("expected %s in %q", expected, lines[i])
+		}
+	}
+}
@@ -0,0 +1,13 @@
+module github.com/delicb/slogbuffer/cobrabuffer
+
+go 1.23.1
+
+require (
+	github.com/delicb/slogbuffer v0.0.0-20261016134606-73c960bce6bf
+	github.com/spf13/cobra v1.8.1
+)
+
+require (
+	github.com/inconshreveable/mousetrap v1.1.0 // indirect
+	github.com/spf13/pflag v1.0.5 // indirect
+)
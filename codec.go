@@ -0,0 +1,494 @@
+package slogbuffer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Binary format written by Encode starts with header (magic, version, maximum number of
+// records of the buffer), followed by records. Each record is prefixed by its length, so
+// incomplete record at the end of the stream can be detected.
+const (
+	codecMagic   = "SLOGBUF"
+	codecVersion = 2
+	// codecVersionFlatAttrs is version in which all attributes of the logger belonged to
+	// all of its groups, regardless of order in which they were added.
+	codecVersionFlatAttrs = 1
+
+	// kindRegistered marks value serialized using codec from RegisterValueCodec. It is followed
+	// by name of the codec, serialized value and textual representation of the value, used
+	// when codec is not registered in decoding process.
+	kindRegistered = 0x80
+
+	// maxDecodedRecords is the largest maximum number of records accepted from the header.
+	// Bound buffer allocates space for all of its records upfront, so corrupted header must
+	// not be able to request arbitrarily large allocation.
+	maxDecodedRecords = 1 << 20
+)
+
+// ErrInvalidFormat is returned when decoding data that was not produced by Encode.
+var ErrInvalidFormat = errors.New("slogbuffer: invalid format")
+
+// Encode writes all buffered records to provided writer in compact binary format,
+// including attributes and groups of the logger that produced them, so the buffer can be
+// persisted and reconstructed using Decode (potentially in another process).
+// Buffer is not changed.
+func (h *BufferLogHandler) Encode(w io.Writer) error {
+	if err := writeHeader(w, h.buffer.Cap()); err != nil {
+		return err
+	}
+	for _, rec := range h.buffer.Snapshot() {
+		if err := writeRecord(w, rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Decode reads records written by Encode and returns new handler that has them buffered.
+// Handler is bound to the same number of records as the handler that encoded them. Data
+// of handlers bound to more than 1048576 records is rejected with ErrInvalidFormat.
+func Decode(r io.Reader, leveler slog.Leveler, opts ...Option) (*BufferLogHandler, error) {
+	br := bufio.NewReader(r)
+	maxRecords, version, err := readHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	if maxRecords > maxDecodedRecords {
+		return nil, fmt.Errorf("%w: maximum number of records %d too large", ErrInvalidFormat, maxRecords)
+	}
+
+	h := NewBoundBufferLogHandler(leveler, maxRecords, opts...)
+	for {
+		rec, err := readRecord(br)
+		if errors.Is(err, io.EOF) {
+			return h, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := h.buffer.Add(upgradeRecord(rec, version)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func writeHeader(w io.Writer, maxRecords int) error {
+	var enc encoder
+	enc.buf.WriteString(codecMagic)
+	enc.uvarint(codecVersion)
+	enc.uvarint(uint64(maxRecords))
+	_, err := w.Write(enc.buf.Bytes())
+	return err
+}
+
+// readHeader reads header written by writeHeader and returns maximum number of records
+// and version of the format.
+func readHeader(r *bufio.Reader) (int, uint64, error) {
+	magic := make([]byte, len(codecMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != codecMagic {
+		return 0, 0, ErrInvalidFormat
+	}
+	version, err := binary.ReadUvarint(r)
+	if err != nil || (version != codecVersion && version != codecVersionFlatAttrs) {
+		return 0, 0, fmt.Errorf("%w: unsupported version %d", ErrInvalidFormat, version)
+	}
+	maxRecords, err := binary.ReadUvarint(r)
+	if err != nil {
+		return 0, 0, ErrInvalidFormat
+	}
+	if maxRecords > math.MaxInt32 {
+		return 0, 0, fmt.Errorf("%w: maximum number of records %d too large", ErrInvalidFormat, maxRecords)
+	}
+	return int(maxRecords), version, nil
+}
+
+// upgradeRecord converts record read from data of provided version to current representation.
+func upgradeRecord(rec record, version uint64) record {
+	if version == codecVersionFlatAttrs {
+		rec.attrs = nest(nil, rec.groups, rec.attrs)
+	}
+	return rec
+}
+
+// writeRecord writes single length-prefixed record.
+func writeRecord(w io.Writer, rec record) error {
+	enc := getEncoder()
+	defer putEncoder(enc)
+	enc.frame(rec)
+	_, err := w.Write(enc.buf.Bytes())
+	return err
+}
+
+// readRecord reads single length-prefixed record. It returns [io.EOF] if there are no
+// more records and [io.ErrUnexpectedEOF] if last record is incomplete.
+func readRecord(r *bufio.Reader) (record, error) {
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return record{}, err
+	}
+	if size > math.MaxInt32 {
+		return record{}, fmt.Errorf("%w: record size %d too large", ErrInvalidFormat, size)
+	}
+	// payload grows as it is read, so corrupted size of truncated record is not allocated
+	var payload bytes.Buffer
+	if _, err := io.CopyN(&payload, r, int64(size)); err != nil {
+		return record{}, io.ErrUnexpectedEOF
+	}
+	dec := decoder{r: bytes.NewReader(payload.Bytes())}
+	rec := dec.record()
+	if dec.err != nil {
+		return record{}, fmt.Errorf("%w: %w", ErrInvalidFormat, dec.err)
+	}
+	return rec, nil
+}
+
+// encoder appends binary representation of values to the buffer.
+type encoder struct {
+	buf bytes.Buffer
+}
+
+// maxPooledEncoderSize is maximum size of buffer of encoder returned to the pool, so
+// single huge record does not keep large buffer alive.
+const maxPooledEncoderSize = 64 << 10
+
+// encoderPool holds encoders reused for encoding records, so buffers are not allocated
+// for every encoded record.
+var encoderPool = sync.Pool{New: func() any { return new(encoder) }}
+
+// getEncoder returns empty encoder from the pool. It should be returned using putEncoder
+// once encoded data is no longer used.
+func getEncoder() *encoder {
+	e := encoderPool.Get().(*encoder)
+	e.buf.Reset()
+	return e
+}
+
+// putEncoder returns encoder to the pool.
+func putEncoder(e *encoder) {
+	if e.buf.Cap() <= maxPooledEncoderSize {
+		encoderPool.Put(e)
+	}
+}
+
+func (e *encoder) uvarint(v uint64) {
+	var data [binary.MaxVarintLen64]byte
+	e.buf.Write(binary.AppendUvarint(data[:0], v))
+}
+
+func (e *encoder) varint(v int64) {
+	var data [binary.MaxVarintLen64]byte
+	e.buf.Write(binary.AppendVarint(data[:0], v))
+}
+
+func (e *encoder) string(s string) {
+	e.uvarint(uint64(len(s)))
+	e.buf.WriteString(s)
+}
+
+func (e *encoder) time(t time.Time) {
+	data, err := t.MarshalBinary()
+	if err != nil {
+		// only possible for unusual time zone offsets, fall back to UTC
+		data, _ = t.UTC().MarshalBinary()
+	}
+	e.uvarint(uint64(len(data)))
+	e.buf.Write(data)
+}
+
+func (e *encoder) record(rec record) {
+	e.time(rec.Time)
+	e.varint(int64(rec.Level))
+	e.string(rec.Message)
+	e.uvarint(uint64(len(rec.groups)))
+	for _, g := range rec.groups {
+		e.string(g)
+	}
+	e.attrs(rec.attrs)
+	n := 0
+	rec.Attrs(func(a slog.Attr) bool {
+		if !a.Equal(slog.Attr{}) {
+			n++
+		}
+		return true
+	})
+	e.uvarint(uint64(n))
+	rec.Attrs(func(a slog.Attr) bool {
+		if !a.Equal(slog.Attr{}) {
+			e.attr(a)
+		}
+		return true
+	})
+}
+
+// frame appends record prefixed by its length.
+func (e *encoder) frame(rec record) {
+	payload := getEncoder()
+	defer putEncoder(payload)
+	payload.record(rec)
+	e.uvarint(uint64(payload.buf.Len()))
+	e.buf.Write(payload.buf.Bytes())
+}
+
+// attrs appends provided attributes. Empty attributes are skipped, since handlers ignore them
+// anyway and their nil value could not be reconstructed.
+func (e *encoder) attrs(attrs []slog.Attr) {
+	n := 0
+	for _, a := range attrs {
+		if !a.Equal(slog.Attr{}) {
+			n++
+		}
+	}
+	e.uvarint(uint64(n))
+	for _, a := range attrs {
+		if !a.Equal(slog.Attr{}) {
+			e.attr(a)
+		}
+	}
+}
+
+func (e *encoder) attr(a slog.Attr) {
+	e.string(a.Key)
+	e.value(a.Value.Resolve())
+}
+
+func (e *encoder) value(v slog.Value) {
+	if v.Kind() == slog.KindAny {
+		if name, data, ok := encodeValue(v.Any()); ok {
+			e.buf.WriteByte(kindRegistered)
+			e.string(name)
+			e.uvarint(uint64(len(data)))
+			e.buf.Write(data)
+			e.string(valueText(v.Any()))
+			return
+		}
+	}
+
+	e.buf.WriteByte(byte(v.Kind()))
+	switch v.Kind() {
+	case slog.KindString:
+		e.string(v.String())
+	case slog.KindInt64:
+		e.varint(v.Int64())
+	case slog.KindUint64:
+		e.uvarint(v.Uint64())
+	case slog.KindFloat64:
+		e.uvarint(math.Float64bits(v.Float64()))
+	case slog.KindBool:
+		if v.Bool() {
+			e.buf.WriteByte(1)
+		} else {
+			e.buf.WriteByte(0)
+		}
+	case slog.KindDuration:
+		e.varint(int64(v.Duration()))
+	case slog.KindTime:
+		e.time(v.Time())
+	case slog.KindGroup:
+		e.attrs(v.Group())
+	default:
+		// arbitrary values without registered codec can not be reconstructed,
+		// so they are stored as text
+		e.string(valueText(v.Any()))
+	}
+}
+
+// decoder reads binary representation of values. First error is remembered and all
+// subsequent reads return zero values.
+type decoder struct {
+	r   *bytes.Reader
+	err error
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, err := binary.ReadUvarint(d.r)
+	d.err = err
+	return v
+}
+
+func (d *decoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, err := binary.ReadVarint(d.r)
+	d.err = err
+	return v
+}
+
+func (d *decoder) byte() byte {
+	if d.err != nil {
+		return 0
+	}
+	b, err := d.r.ReadByte()
+	d.err = err
+	return b
+}
+
+func (d *decoder) bytes() []byte {
+	size := d.uvarint()
+	if d.err != nil {
+		return nil
+	}
+	if size > uint64(d.r.Len()) {
+		d.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	data := make([]byte, size)
+	_, d.err = io.ReadFull(d.r, data)
+	return data
+}
+
+func (d *decoder) string() string {
+	return string(d.bytes())
+}
+
+func (d *decoder) time() time.Time {
+	var t time.Time
+	data := d.bytes()
+	if d.err == nil {
+		d.err = t.UnmarshalBinary(data)
+	}
+	return t
+}
+
+// count reads number of elements that follow, guarding against corrupted input
+// that would cause huge allocations.
+func (d *decoder) count() int {
+	n := d.uvarint()
+	if n > uint64(d.r.Len()) {
+		if d.err == nil {
+			d.err = io.ErrUnexpectedEOF
+		}
+		return 0
+	}
+	return int(n)
+}
+
+func (d *decoder) record() record {
+	t := d.time()
+	level := slog.Level(d.varint())
+	msg := d.string()
+	var groups []string
+	if n := d.count(); n > 0 {
+		groups = make([]string, 0, n)
+		for range n {
+			groups = append(groups, d.string())
+		}
+	}
+	attrs := d.attrs()
+	r := slog.NewRecord(t, level, msg, 0)
+	r.AddAttrs(d.attrs()...)
+	return record{
+		Record: r,
+		attrs:  attrs,
+		groups: groups,
+	}
+}
+
+func (d *decoder) attrs() []slog.Attr {
+	n := d.count()
+	if n == 0 {
+		return nil
+	}
+	attrs := make([]slog.Attr, 0, n)
+	for range n {
+		key := d.string()
+		attrs = append(attrs, slog.Attr{Key: key, Value: d.value()})
+	}
+	return attrs
+}
+
+func (d *decoder) value() slog.Value {
+	kind := d.byte()
+	if kind == kindRegistered {
+		name := d.string()
+		data := d.bytes()
+		fallback := d.string()
+		if d.err != nil {
+			return slog.Value{}
+		}
+		return slog.AnyValue(decodeValue(name, data, fallback))
+	}
+
+	switch kind := slog.Kind(kind); kind {
+	case slog.KindString:
+		return slog.StringValue(d.string())
+	case slog.KindInt64:
+		return slog.Int64Value(d.varint())
+	case slog.KindUint64:
+		return slog.Uint64Value(d.uvarint())
+	case slog.KindFloat64:
+		return slog.Float64Value(math.Float64frombits(d.uvarint()))
+	case slog.KindBool:
+		return slog.BoolValue(d.byte() == 1)
+	case slog.KindDuration:
+		return slog.DurationValue(time.Duration(d.varint()))
+	case slog.KindTime:
+		return slog.TimeValue(d.time())
+	case slog.KindGroup:
+		return slog.GroupValue(d.attrs()...)
+	case slog.KindAny, slog.KindLogValuer:
+		return slog.StringValue(d.string())
+	default:
+		if d.err == nil {
+			d.err = fmt.Errorf("unknown value kind %d", kind)
+		}
+		return slog.Value{}
+	}
+}
+
+// NewFromFile returns handler with records loaded from file previously written by
+// SaveToFile (or Encode), so records captured before crash or restart are replayed once
+// real handler is set in the new process. File is not removed after loading.
+// If file does not exist, returned error satisfies errors.Is(err, fs.ErrNotExist).
+func NewFromFile(path string, leveler slog.Leveler, opts ...Option) (*BufferLogHandler, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h, err := Decode(f, leveler, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("loading records from %s: %w", path, err)
+	}
+	return h, nil
+}
+
+// SaveToFile persists all buffered records to file (see Encode), replacing its content.
+// File is written to temporary location first and then renamed, so existing file is
+// never left partially written. Buffer is not changed.
+func (h *BufferLogHandler) SaveToFile(path string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	w := bufio.NewWriter(f)
+	if err := h.Encode(w); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
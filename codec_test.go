@@ -0,0 +1,143 @@
+package slogbuffer_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"github.com/delicb/slogbuffer"
+	"io"
+	"io/fs"
+	"log/slog"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBufferLogHandler_EncodeDecode(t *testing.T) {
+	// given
+	h := slogbuffer.NewBoundBufferLogHandler(slog.LevelDebug, 10)
+	l := slog.New(h)
+
+	l.Info("info msg",
+		slog.String("string", "value"),
+		slog.Int("int", -42),
+		slog.Uint64("uint", 42),
+		slog.Float64("float", 4.2),
+		slog.Bool("bool", true),
+		slog.Duration("duration", time.Second),
+		slog.Time("time", time.Date(2024, 9, 15, 10, 0, 0, 0, time.UTC)),
+		slog.Any("error", errors.New("some error")),
+	)
+	l.WithGroup("g1").With("common", "attr").Warn("warn msg", slog.Group("g2", "foo", "bar"))
+	l.With("outer", "attr").WithGroup("g1").With("inner", "attr").Error("error msg")
+
+	// when
+	encoded := new(bytes.Buffer)
+	if err := h.Encode(encoded); err != nil {
+		t.Fatalf("encoding: %v", err)
+	}
+	decoded, err := slogbuffer.Decode(encoded, slog.LevelDebug)
+	if err != nil {
+		t.Fatalf("decoding: %v", err)
+	}
+
+	// then
+	if decoded.Len() != 3 || decoded.Cap() != 10 {
+		t.Fatalf("unexpected decoded buffer len %d and cap %d", decoded.Len(), decoded.Cap())
+	}
+
+	expected, actual := new(bytes.Buffer), new(bytes.Buffer)
+	if err := h.WriteJSON(expected); err != nil {
+		t.Fatalf("writing json: %v", err)
+	}
+	if err := decoded.WriteJSON(actual); err != nil {
+		t.Fatalf("writing json: %v", err)
+	}
+	if expected.String() != actual.String() {
+		t.Fatalf("decoded buffer differs, got\n%s\nexpected\n%s", actual, expected)
+	}
+}
+
+func TestDecode_InvalidInput(t *testing.T) {
+	_, err := slogbuffer.Decode(strings.NewReader("definitely not a buffer"), slog.LevelDebug)
+	if !errors.Is(err, slogbuffer.ErrInvalidFormat) {
+		t.Fatalf("expected ErrInvalidFormat, got %v", err)
+	}
+}
+
+func TestDecode_TruncatedInput(t *testing.T) {
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	slog.New(h).Info("info msg", "foo", "bar")
+
+	encoded := new(bytes.Buffer)
+	if err := h.Encode(encoded); err != nil {
+		t.Fatalf("encoding: %v", err)
+	}
+
+	truncated := encoded.Bytes()[:encoded.Len()-2]
+	_, err := slogbuffer.Decode(bytes.NewReader(truncated), slog.LevelDebug)
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
+
+func TestDecode_CorruptedHeader(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		data []byte
+	}{
+		{name: "truncated", data: []byte("SLOGBUF\x02")},
+		{name: "huge max records", data: binary.AppendUvarint([]byte("SLOGBUF\x02"), math.MaxUint64)},
+		{name: "huge record size", data: binary.AppendUvarint([]byte("SLOGBUF\x02\x0a"), math.MaxUint64)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// when
+			_, err := slogbuffer.Decode(bytes.NewReader(tc.data), slog.LevelDebug)
+
+			// then
+			if !errors.Is(err, slogbuffer.ErrInvalidFormat) {
+				t.Fatalf("expected ErrInvalidFormat, got %v", err)
+			}
+		})
+	}
+}
+
+func TestNewFromFile(t *testing.T) {
+	// given
+	path := filepath.Join(t.TempDir(), "buffer.bin")
+
+	h := slogbuffer.NewBoundBufferLogHandler(slog.LevelDebug, 5)
+	slog.New(h).With("common", "attr").Info("before restart")
+	if err := h.SaveToFile(path); err != nil {
+		t.Fatalf("saving to file: %v", err)
+	}
+
+	// when
+	restored, err := slogbuffer.NewFromFile(path, slog.LevelInfo)
+	if err != nil {
+		t.Fatalf("loading from file: %v", err)
+	}
+	slog.New(restored).Info("after restart")
+
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandler(t, restored, rh)
+	lines := getLines(t, reader)
+
+	// then
+	if restored.Cap() != 5 {
+		t.Fatalf("expected restored buffer to have cap 5, got %d", restored.Cap())
+	}
+	expectLinesNo(t, lines, 2)
+	expectMsg(t, lines[0], "before restart")
+	expectAttr(t, lines[0], "common", "attr")
+	expectMsg(t, lines[1], "after restart")
+}
+
+func TestNewFromFile_Missing(t *testing.T) {
+	_, err := slogbuffer.NewFromFile(filepath.Join(t.TempDir(), "missing.bin"), slog.LevelInfo)
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected fs.ErrNotExist, got %v", err)
+	}
+}
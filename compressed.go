@@ -0,0 +1,327 @@
+package slogbuffer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"io"
+	"sync"
+)
+
+// defaultCompressionBlockSize is size of uncompressed block used when block size is not provided.
+const defaultCompressionBlockSize = 64 * 1024
+
+// flateWriterPool holds compressors reused for compressing blocks, since they are expensive
+// to allocate.
+var flateWriterPool sync.Pool
+
+// compressedBlock is group of encoded records. Blocks are compressed once they reach
+// configured size, only the newest block is kept uncompressed, while records are added to it.
+type compressedBlock struct {
+	data       []byte
+	count      int
+	compressed bool
+}
+
+// compressedStorage keeps records in memory encoded the same way as by Encode and
+// compressed in blocks, trading CPU time for smaller memory footprint.
+type compressedStorage struct {
+	// maxRecords is maximum number of records, 0 if number of records is not limited
+	maxRecords int
+	blockSize  int
+
+	// blocks holds all records, oldest block first
+	blocks []*compressedBlock
+	// skip is number of records at the start of the oldest compressed block that were
+	// evicted, records of uncompressed block are trimmed from it right away
+	skip int
+	// count is number of stored records
+	count int
+	// evicting caches decoded records of the oldest compressed block while records are
+	// being evicted from it, so it does not have to be decompressed for every eviction
+	evicting []record
+
+	// onAdd and onRemove, when set, are called (while holding the lock) for every record
+	// added to the storage and every record removed from it. onEvict is additionally called
+	// for records removed to make space for new ones.
+	onAdd    func(record)
+	onRemove func(record)
+	onEvict  func(record)
+
+	lock sync.Mutex
+}
+
+func newCompressedStorage(maxRecords int, blockSize int) *compressedStorage {
+	if blockSize <= 0 {
+		blockSize = defaultCompressionBlockSize
+	}
+	return &compressedStorage{
+		maxRecords: max(maxRecords, 0),
+		blockSize:  blockSize,
+	}
+}
+
+// decode returns all records of the block. Caller must hold the lock.
+func (s *compressedStorage) decode(block *compressedBlock) []record {
+	var r io.Reader = bytes.NewReader(block.data)
+	if block.compressed {
+		r = flate.NewReader(r)
+	}
+	br := bufio.NewReader(r)
+	records := make([]record, 0, block.count)
+	for {
+		rec, err := readRecord(br)
+		if err != nil {
+			// only possible error is end of data, since blocks are produced by this storage
+			return records
+		}
+		records = append(records, rec)
+	}
+}
+
+// compress compresses the newest block. Caller must hold the lock.
+func (s *compressedStorage) compress(block *compressedBlock) error {
+	var buf bytes.Buffer
+	w, ok := flateWriterPool.Get().(*flate.Writer)
+	if ok {
+		w.Reset(&buf)
+	} else {
+		var err error
+		if w, err = flate.NewWriter(&buf, flate.DefaultCompression); err != nil {
+			return err
+		}
+	}
+	defer flateWriterPool.Put(w)
+
+	if _, err := w.Write(block.data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	block.data = buf.Bytes()
+	block.compressed = true
+	return nil
+}
+
+func (s *compressedStorage) Add(rec record) error {
+	enc := getEncoder()
+	defer putEncoder(enc)
+	enc.frame(rec)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.blocks) == 0 || s.blocks[len(s.blocks)-1].compressed {
+		s.blocks = append(s.blocks, &compressedBlock{})
+	}
+	block := s.blocks[len(s.blocks)-1]
+	block.data = append(block.data, enc.buf.Bytes()...)
+	block.count++
+	s.count++
+	if s.onAdd != nil {
+		s.onAdd(rec)
+	}
+
+	if len(block.data) >= s.blockSize {
+		if err := s.compress(block); err != nil {
+			return err
+		}
+	}
+	if s.maxRecords > 0 && s.count > s.maxRecords {
+		s.removeOldest(true)
+	}
+	return nil
+}
+
+// oldest returns the oldest record, decoding only what is needed to get it. Caller must hold
+// the lock and storage must not be empty. It returns false if the record can not be decoded.
+func (s *compressedStorage) oldest() (record, bool) {
+	block := s.blocks[0]
+	if !block.compressed {
+		// newest block is still growing, so its records are decoded one by one
+		return decodeFrame(block.data)
+	}
+	if s.evicting == nil {
+		s.evicting = s.decode(block)
+	}
+	if s.skip < len(s.evicting) {
+		return s.evicting[s.skip], true
+	}
+	return record{}, false
+}
+
+// removeOldest removes the oldest record, reporting it as evicted if requested. Caller must
+// hold the lock and storage must not be empty.
+func (s *compressedStorage) removeOldest(evicted bool) {
+	if s.onRemove != nil || (evicted && s.onEvict != nil) {
+		if rec, ok := s.oldest(); ok {
+			if s.onRemove != nil {
+				s.onRemove(rec)
+			}
+			if evicted && s.onEvict != nil {
+				s.onEvict(rec)
+			}
+		}
+	}
+	s.count--
+
+	oldest := s.blocks[0]
+	if !oldest.compressed {
+		// record is trimmed from the block right away, so bound buffer smaller than a block
+		// does not decode whole block on every eviction, nor keep evicted records around
+		oldest.data = oldest.data[min(frameSize(oldest.data), len(oldest.data)):]
+		oldest.count--
+		if oldest.count == 0 {
+			s.blocks = s.blocks[1:]
+		}
+		return
+	}
+	s.skip++
+	if s.skip >= oldest.count {
+		s.blocks = s.blocks[1:]
+		s.skip = 0
+		s.evicting = nil
+	}
+}
+
+func (s *compressedStorage) evictWhile(evict func(oldest record) bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for s.count > 0 {
+		if rec, ok := s.oldest(); ok && !evict(rec) {
+			return
+		}
+		s.removeOldest(true)
+	}
+}
+
+func (s *compressedStorage) PopOldest() (record, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for s.count > 0 {
+		rec, ok := s.oldest()
+		s.removeOldest(false)
+		if ok {
+			return rec, true
+		}
+	}
+	return record{}, false
+}
+
+// frameSize returns size of the first record frame in provided data, including its length
+// prefix.
+func frameSize(data []byte) int {
+	size, n := binary.Uvarint(data)
+	if n <= 0 || size > uint64(len(data)-n) {
+		return len(data)
+	}
+	return n + int(size)
+}
+
+// decodeFrame decodes the first record frame in provided data.
+func decodeFrame(data []byte) (record, bool) {
+	size, n := binary.Uvarint(data)
+	if n <= 0 || size > uint64(len(data)-n) {
+		return record{}, false
+	}
+	dec := decoder{r: bytes.NewReader(data[n : n+int(size)])}
+	rec := dec.record()
+	return rec, dec.err == nil
+}
+
+// records returns records between from (inclusive) and to (exclusive), counting from the
+// oldest one, decoding only blocks that contain them. Caller must hold the lock.
+func (s *compressedStorage) records(from, to int) []record {
+	res := make([]record, 0, max(to-from, 0))
+	// index of the first record of current block
+	start := -s.skip
+	for _, block := range s.blocks {
+		end := start + block.count
+		if end > from && start < to {
+			decoded := s.decode(block)
+			res = append(res, decoded[max(from-start, 0):min(to-start, len(decoded))]...)
+		}
+		start = end
+	}
+	return res
+}
+
+func (s *compressedStorage) Take() []record {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	res := s.records(0, s.count)
+	s.reset()
+	return res
+}
+
+func (s *compressedStorage) Snapshot() []record {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.records(0, s.count)
+}
+
+func (s *compressedStorage) Head(n int) []record {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.records(0, min(max(n, 0), s.count))
+}
+
+func (s *compressedStorage) Tail(n int) []record {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.records(max(s.count-max(n, 0), 0), s.count)
+}
+
+func (s *compressedStorage) Clear() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.reset()
+}
+
+// reset removes all records. Caller must hold the lock.
+func (s *compressedStorage) reset() {
+	if s.onRemove != nil {
+		for _, rec := range s.records(0, s.count) {
+			s.onRemove(rec)
+		}
+	}
+	s.blocks = nil
+	s.skip = 0
+	s.count = 0
+	s.evicting = nil
+}
+
+// approxBytes returns size of encoded (and mostly compressed) records.
+func (s *compressedStorage) approxBytes() int64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var size int64
+	for _, block := range s.blocks {
+		size += int64(cap(block.data))
+	}
+	return size
+}
+
+func (s *compressedStorage) Len() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.count
+}
+
+func (s *compressedStorage) Cap() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.maxRecords
+}
+
+func (s *compressedStorage) resize(maxRecords int) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.maxRecords = maxRecords
+	for s.maxRecords > 0 && s.count > s.maxRecords {
+		s.removeOldest(true)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package slogbuffer_test
+
+import (
+	"fmt"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"testing"
+)
+
+func TestBufferLogHandler_WithCompression(t *testing.T) {
+	// given
+	// small blocks, so records span multiple compressed blocks
+	h := slogbuffer.NewBoundBufferLogHandler(slog.LevelDebug, 50, slogbuffer.WithCompression(256))
+	l := slog.New(h).WithGroup("g1").With("common", "attr")
+
+	// when
+	for i := range 120 {
+		l.Info("msg", "no", i)
+	}
+
+	// then
+	if h.Len() != 50 || h.Cap() != 50 {
+		t.Fatalf("unexpected len %d and cap %d", h.Len(), h.Cap())
+	}
+	if !h.HasLevel(slog.LevelInfo) {
+		t.Fatalf("expected records at info level")
+	}
+	head, tail := h.Head(1), h.Tail(1)
+	expectRecordAttr(t, head[0], "g1", slog.GroupValue(slog.String("common", "attr"), slog.Int64("no", 70)))
+	expectRecordAttr(t, tail[0], "g1", slog.GroupValue(slog.String("common", "attr"), slog.Int64("no", 119)))
+
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandler(t, h, rh)
+	lines := getLines(t, reader)
+
+	expectLinesNo(t, lines, 50)
+	for i, line := range lines {
+		expectAttr(t, line, "g1.common", "attr")
+		expectAttr(t, line, "g1.no", fmt.Sprintf("%d", i+70))
+	}
+	if h.HasLevel(slog.LevelDebug) {
+		t.Fatalf("expected no records after flush")
+	}
+}
+
+func TestBufferLogHandler_WithCompression_ApproxBytes(t *testing.T) {
+	// given
+	plain := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	compressed := slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithCompression(1024))
+
+	// when
+	for _, h := range []*slogbuffer.BufferLogHandler{plain, compressed} {
+		l := slog.New(h)
+		for i := range 1000 {
+			l.Info("repetitive message that compresses well", "no", i)
+		}
+	}
+
+	// then
+	if compressed.ApproxBytes() <= 0 || compressed.ApproxBytes() >= plain.ApproxBytes() {
+		t.Fatalf("expected compressed records to take less than %d bytes, got %d",
+			plain.ApproxBytes(), compressed.ApproxBytes())
+	}
+}
+
+func TestBufferLogHandler_WithCompression_BoundSmallerThanBlock(t *testing.T) {
+	// given
+	h := slogbuffer.NewBoundBufferLogHandler(slog.LevelDebug, 10, slogbuffer.WithCompression(64*1024))
+	l := slog.New(h)
+
+	// when
+	for i := range 10000 {
+		l.Info("msg", "no", i)
+	}
+
+	// then
+	// evicted records do not stay in the uncompressed block
+	if h.ApproxBytes() > 4096 {
+		t.Fatalf("expected evicted records to be released, buffer takes %d bytes", h.ApproxBytes())
+	}
+	records := h.Records()
+	if len(records) != 10 {
+		t.Fatalf("expected 10 records, got %d", len(records))
+	}
+	for i, r := range records {
+		r.Attrs(func(a slog.Attr) bool {
+			if a.Key == "no" && a.Value.Int64() != int64(9990+i) {
+				t.Fatalf("expected record %d, got %d", 9990+i, a.Value.Int64())
+			}
+			return true
+		})
+	}
+}
+
+func BenchmarkBufferLogHandler_WithCompression_BoundSmallerThanBlock(b *testing.B) {
+	h := slogbuffer.NewBoundBufferLogHandler(slog.LevelDebug, 100, slogbuffer.WithCompression(64*1024))
+	l := slog.New(h)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		l.Info("benchmark msg", "key", "value")
+	}
+}
@@ -0,0 +1,120 @@
+package slogbuffer
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+)
+
+// contextKey is key under which BufferLogHandler is stored in context.
+type contextKey struct{}
+
+// NewContext returns copy of provided context that carries provided buffer handler.
+// Records logged using ContextHandler with returned context are routed to that handler.
+func NewContext(ctx context.Context, h *BufferLogHandler) context.Context {
+	return context.WithValue(ctx, contextKey{}, h)
+}
+
+// FromContext returns buffer handler stored in provided context, or nil if there is none.
+func FromContext(ctx context.Context) *BufferLogHandler {
+	h, _ := ctx.Value(contextKey{}).(*BufferLogHandler)
+	return h
+}
+
+// LoggerFromContext returns logger that uses buffer handler stored in provided context,
+// or default logger if context does not carry one.
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	if h := FromContext(ctx); h != nil {
+		return slog.New(h)
+	}
+	return slog.Default()
+}
+
+// ContextHandler is [slog.Handler] that routes records to buffer handler carried by context
+// (see NewContext) and to fallback handler if context does not carry one. This enables scoped
+// log capture (e.g. per request), where records of each scope are buffered and flushed (or
+// discarded) independently, while application uses single logger.
+type ContextHandler struct {
+	// fallback handles records when context does not carry buffer handler, with attributes
+	// and groups of this handler already applied
+	fallback slog.Handler
+	// segments are attributes and groups of this handler, in order they were added,
+	// applied to buffer handler from context when record is handled
+	segments []segment
+}
+
+// segment is either group or attributes added to ContextHandler.
+type segment struct {
+	group string
+	attrs []slog.Attr
+}
+
+// NewContextHandler creates handler that routes records to buffer handler from context,
+// or to provided fallback handler.
+func NewContextHandler(fallback slog.Handler) *ContextHandler {
+	return &ContextHandler{fallback: fallback}
+}
+
+// addContextAttrs returns copy of provided record with attributes returned by extractors
+// for provided context added to it.
+func addContextAttrs(ctx context.Context, r slog.Record, extractors []func(context.Context) []slog.Attr) slog.Record {
+	var attrs []slog.Attr
+	for _, extract := range extractors {
+		attrs = append(attrs, extract(ctx)...)
+	}
+	if len(attrs) == 0 {
+		return r
+	}
+	// record shares attributes with its copies, so it has to be cloned before adding to it
+	r = r.Clone()
+	r.AddAttrs(attrs...)
+	return r
+}
+
+// Implementation of slog.Handler interface.
+
+// compile time check that ContextHandler implements slog.Handler interface.
+var _ slog.Handler = &ContextHandler{}
+
+func (h *ContextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if bh := FromContext(ctx); bh != nil {
+		return bh.Enabled(ctx, level)
+	}
+	return h.fallback.Enabled(ctx, level)
+}
+
+func (h *ContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	bh := FromContext(ctx)
+	if bh == nil {
+		return h.fallback.Handle(ctx, r)
+	}
+	var target slog.Handler = bh
+	for _, s := range h.segments {
+		if s.attrs != nil {
+			target = target.WithAttrs(s.attrs)
+		} else {
+			target = target.WithGroup(s.group)
+		}
+	}
+	return target.Handle(ctx, r)
+}
+
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return &ContextHandler{
+		fallback: h.fallback.WithAttrs(attrs),
+		segments: append(slices.Clip(h.segments), segment{attrs: attrs}),
+	}
+}
+
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	if len(name) == 0 {
+		return h
+	}
+	return &ContextHandler{
+		fallback: h.fallback.WithGroup(name),
+		segments: append(slices.Clip(h.segments), segment{group: name}),
+	}
+}
@@ -0,0 +1,78 @@
+package slogbuffer_test
+
+import (
+	"context"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestContextHandler(t *testing.T) {
+	// given
+	fallback, fallbackReader := getSimplifiedTextHandler()
+	l := slog.New(slogbuffer.NewContextHandler(fallback)).WithGroup("g1").With("common", "attr")
+
+	first := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	second := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	firstCtx := slogbuffer.NewContext(context.Background(), first)
+	secondCtx := slogbuffer.NewContext(context.Background(), second)
+	if slogbuffer.FromContext(firstCtx) != first {
+		t.Fatalf("expected handler to be returned from context")
+	}
+
+	// when
+	l.InfoContext(firstCtx, "first msg")
+	l.DebugContext(secondCtx, "second msg")
+	l.InfoContext(context.Background(), "fallback msg")
+
+	// then
+	fallbackLines := getLines(t, fallbackReader)
+	expectLinesNo(t, fallbackLines, 1)
+	expectMsg(t, fallbackLines[0], "fallback msg")
+	expectAttr(t, fallbackLines[0], "g1.common", "attr")
+
+	// each buffer is flushed independently
+	second.Discard()
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandler(t, first, rh)
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 1)
+	expectMsg(t, lines[0], "first msg")
+	expectAttr(t, lines[0], "g1.common", "attr")
+}
+
+type requestIDKey struct{}
+
+func TestBufferLogHandler_WithContextAttrs(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithContextAttrs(
+		func(ctx context.Context) []slog.Attr {
+			if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+				return []slog.Attr{slog.String("request_id", id)}
+			}
+			return nil
+		},
+	))
+	l := slog.New(h).WithGroup("g1")
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "req-1")
+
+	// when
+	l.InfoContext(ctx, "buffered msg")
+	l.InfoContext(context.Background(), "no request msg")
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandler(t, h, rh)
+	l.InfoContext(ctx, "direct msg")
+
+	// then
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 3)
+	expectMsg(t, lines[0], "buffered msg")
+	expectAttr(t, lines[0], "g1.request_id", "req-1")
+	expectMsg(t, lines[1], "no request msg")
+	if strings.Contains(lines[1], "request_id") {
+		t.Fatalf("expected no request ID in %q", lines[1])
+	}
+	expectMsg(t, lines[2], "direct msg")
+	expectAttr(t, lines[2], "g1.request_id", "req-1")
+}
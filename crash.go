@@ -0,0 +1,37 @@
+package slogbuffer
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+)
+
+// SetCrashOutput makes buffered records part of crash report of the process, so fatal panics
+// come with full log context even if real handler was never set. File at provided path is opened
+// (and created, if needed) for appending and set as additional crash output using
+// [debug.SetCrashOutput], so runtime writes report of fatal error to it, besides stderr.
+//
+// Since no code runs after fatal error, returned function has to be deferred (at the top of main
+// function and goroutines that might panic). When panic propagates through it, records buffered by
+// provided handler are written to the file (in logfmt style, see WriteText) and panic continues,
+// so runtime appends its report after them. Fatal errors that are not panics (e.g. concurrent map
+// writes) produce report without buffered records.
+func SetCrashOutput(path string, h *BufferLogHandler) (func(), error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("opening crash output %s: %w", path, err)
+	}
+	if err := debug.SetCrashOutput(f, debug.CrashOptions{}); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		p := recover()
+		if p == nil {
+			return
+		}
+		_, _ = fmt.Fprintf(f, "buffered log records:\n")
+		_ = h.WriteText(f)
+		panic(p)
+	}, nil
+}
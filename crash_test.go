@@ -0,0 +1,46 @@
+package slogbuffer_test
+
+import (
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetCrashOutput(t *testing.T) {
+	if path := os.Getenv("SLOGBUFFER_CRASH_OUTPUT"); path != "" {
+		// running in child process that crashes
+		h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+		crashed, err := slogbuffer.SetCrashOutput(path, h)
+		if err != nil {
+			t.Fatalf("setting crash output: %v", err)
+		}
+		defer crashed()
+		slog.New(h).Info("before crash", "key", "value")
+		panic("boom")
+	}
+
+	// given
+	path := filepath.Join(t.TempDir(), "crash.log")
+	cmd := exec.Command(os.Args[0], "-test.run=^TestSetCrashOutput$")
+	cmd.Env = append(os.Environ(), "SLOGBUFFER_CRASH_OUTPUT="+path)
+
+	// when
+	if err := cmd.Run(); err == nil {
+		t.Fatalf("expected child process to crash")
+	}
+
+	// then
+	report, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading crash output: %v", err)
+	}
+	recordsAt := strings.Index(string(report), `msg="before crash" key=value`)
+	panicAt := strings.Index(string(report), "panic: boom")
+	if recordsAt < 0 || panicAt < recordsAt {
+		t.Fatalf("expected buffered records followed by crash report, got:\n%s", report)
+	}
+}
@@ -0,0 +1,29 @@
+package slogbuffer
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// DeadLetters returns copies of records that real handler failed to handle, either during
+// flush or after real handler was set, in the order they were logged. Attributes and
+// groups of the logger that produced a record are folded into attributes of the record.
+// Records are not removed, use RetryFlush or ExportDeadLetters for that.
+func (h *BufferLogHandler) DeadLetters() []slog.Record {
+	return materializeAll(h.deadLetters.Snapshot())
+}
+
+// ExportDeadLetters sends records that real handler failed to handle to provided
+// handler (e.g. one writing to file for later inspection). Exported records are removed,
+// records that provided handler fails to handle are kept.
+func (h *BufferLogHandler) ExportDeadLetters(ctx context.Context, handler slog.Handler) error {
+	var exportErr error
+	for _, rec := range h.deadLetters.Take() {
+		if err := rec.emit(ctx, handler); err != nil {
+			exportErr = errors.Join(exportErr, err)
+			h.deadLetters.Add(rec)
+		}
+	}
+	return exportErr
+}
@@ -0,0 +1,148 @@
+package slogbuffer_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"testing"
+)
+
+func TestBufferLogHandler_DeadLetters(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	l := slog.New(h)
+	withGroup := l.WithGroup("g1").With("common", "attr")
+
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandler(t, h, newFailingHandler(rh, 1))
+
+	// when
+	err := withGroup.Handler().Handle(context.Background(), newRecord(slog.LevelError, "failed msg", "foo", "bar"))
+	l.Info("delivered msg")
+
+	// then
+	if !errors.Is(err, errHandlerFailed) {
+		t.Fatalf("expected handler error, got %v", err)
+	}
+	expectLinesNo(t, getLines(t, reader), 1)
+
+	deadLetters := h.DeadLetters()
+	if len(deadLetters) != 1 {
+		t.Fatalf("expected 1 dead letter, got %d", len(deadLetters))
+	}
+	if deadLetters[0].Message != "failed msg" {
+		t.Fatalf("unexpected dead letter message %q", deadLetters[0].Message)
+	}
+	expectRecordAttr(t, deadLetters[0], "g1", slog.GroupValue(slog.String("common", "attr"), slog.String("foo", "bar")))
+
+	// dead letters are re-delivered on retry
+	if err := h.RetryFlush(context.Background(), slogbuffer.RetryPolicy{}); err != nil {
+		t.Fatalf("retrying flush: %v", err)
+	}
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 1)
+	expectMsg(t, lines[0], "failed msg")
+	expectAttr(t, lines[0], "g1.common", "attr")
+	expectAttr(t, lines[0], "g1.foo", "bar")
+
+	if len(h.DeadLetters()) != 0 {
+		t.Fatalf("expected dead letters to be delivered")
+	}
+}
+
+func TestBufferLogHandler_ExportDeadLetters(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	l := slog.New(h)
+	l.With("common", "attr").Info("info msg")
+
+	rh, _ := getSimplifiedTextHandler()
+	if err := h.SetRealHandler(context.Background(), newFailingHandler(rh, 1)); err == nil {
+		t.Fatalf("expected error from flush")
+	}
+
+	// when
+	exportHandler, reader := getSimplifiedTextHandler()
+	if err := h.ExportDeadLetters(context.Background(), exportHandler); err != nil {
+		t.Fatalf("exporting dead letters: %v", err)
+	}
+
+	// then
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 1)
+	expectMsg(t, lines[0], "info msg")
+	expectAttr(t, lines[0], "common", "attr")
+
+	if len(h.DeadLetters()) != 0 {
+		t.Fatalf("expected dead letters to be removed after export")
+	}
+}
+
+func TestBufferLogHandler_PartialFlushFailure(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	l := slog.New(h)
+	for i := range 5 {
+		l.Info("msg", "no", i)
+	}
+
+	// when
+	// real handler fails the first two records and delivers the rest
+	rh, reader := getSimplifiedTextHandler()
+	err := h.SetRealHandler(context.Background(), newFailingHandler(rh, 2))
+
+	// then
+	var flushErr *slogbuffer.FlushError
+	if !errors.As(err, &flushErr) || flushErr.Failed != 2 {
+		t.Fatalf("expected flush error with 2 failed records, got %v", err)
+	}
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 3)
+	for i, line := range lines {
+		expectAttr(t, line, "no", fmt.Sprintf("%d", i+2))
+	}
+
+	// only failed records are kept and can be delivered to alternate target
+	deadLetters := h.DeadLetters()
+	if len(deadLetters) != 2 {
+		t.Fatalf("expected 2 dead letters, got %d", len(deadLetters))
+	}
+	alternate, alternateReader := getSimplifiedTextHandler()
+	if err := h.ExportDeadLetters(context.Background(), alternate); err != nil {
+		t.Fatalf("exporting dead letters: %v", err)
+	}
+	lines = getLines(t, alternateReader)
+	expectLinesNo(t, lines, 2)
+	for i, line := range lines {
+		expectAttr(t, line, "no", fmt.Sprintf("%d", i))
+	}
+	expectLinesNo(t, getLines(t, reader), 0)
+}
+
+func TestBufferLogHandler_DeadLettersEvicted(t *testing.T) {
+	// given
+	h := slogbuffer.NewBoundBufferLogHandler(slog.LevelDebug, 2)
+	l := slog.New(h)
+	rh, _ := getSimplifiedTextHandler()
+	setRealHandler(t, h, newFailingHandler(rh, 3))
+
+	// when
+	for i := range 3 {
+		l.Info("failed msg", "no", i)
+	}
+
+	// then
+	// the oldest dead letter is evicted to make space for newer one and counted as dropped
+	if h.Dropped() != 1 {
+		t.Fatalf("expected 1 dropped record, got %d", h.Dropped())
+	}
+	deadLetters := h.DeadLetters()
+	if len(deadLetters) != 2 {
+		t.Fatalf("expected 2 dead letters, got %d", len(deadLetters))
+	}
+	for i, r := range deadLetters {
+		expectRecordAttr(t, r, "no", slog.IntValue(i+1))
+	}
+}
@@ -0,0 +1,58 @@
+package slogbuffer
+
+import (
+	"log/slog"
+	"slices"
+)
+
+// DedupPolicy controls which of attributes with the same key is kept when duplicates are removed.
+type DedupPolicy int
+
+const (
+	// DedupLastWins keeps value of attribute that was added last, e.g. attribute of the record
+	// over attribute of the logger.
+	DedupLastWins DedupPolicy = iota
+	// DedupFirstWins keeps value of attribute that was added first.
+	DedupFirstWins
+)
+
+// dedupAttrs returns new slice with attributes with duplicate keys removed, according to
+// provided policy. Kept attribute takes position of the first one with the same key. Groups
+// with the same key are merged and members of groups are de-duplicated recursively.
+func dedupAttrs(attrs []slog.Attr, policy DedupPolicy) []slog.Attr {
+	res := make([]slog.Attr, 0, len(attrs))
+	index := make(map[string]int, len(attrs))
+	for _, a := range inlineGroups(attrs) {
+		i, seen := index[a.Key]
+		switch {
+		case !seen:
+			index[a.Key] = len(res)
+			res = append(res, a)
+		case res[i].Value.Kind() == slog.KindGroup && a.Value.Kind() == slog.KindGroup:
+			res[i].Value = slog.GroupValue(append(slices.Clip(res[i].Value.Group()), a.Value.Group()...)...)
+		case policy == DedupLastWins:
+			res[i] = a
+		}
+	}
+	for i, a := range res {
+		if a.Value.Kind() == slog.KindGroup {
+			res[i].Value = slog.GroupValue(dedupAttrs(a.Value.Group(), policy)...)
+		}
+	}
+	return res
+}
+
+// inlineGroups returns attributes with members of groups with empty key (which slog handlers
+// inline into enclosing group) in place of those groups. Values are resolved.
+func inlineGroups(attrs []slog.Attr) []slog.Attr {
+	res := make([]slog.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		a.Value = a.Value.Resolve()
+		if a.Value.Kind() == slog.KindGroup && len(a.Key) == 0 {
+			res = append(res, inlineGroups(a.Value.Group())...)
+			continue
+		}
+		res = append(res, a)
+	}
+	return res
+}
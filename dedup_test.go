@@ -0,0 +1,44 @@
+package slogbuffer_test
+
+import (
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestBufferLogHandler_WithDedup(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		policy   slogbuffer.DedupPolicy
+		expected string
+	}{
+		{name: "last wins", policy: slogbuffer.DedupLastWins, expected: "record"},
+		{name: "first wins", policy: slogbuffer.DedupFirstWins, expected: "logger"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// given
+			h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithDedup(tc.policy))
+			l := slog.New(h).With("key", "logger", slog.Group("g1", "a", "1")).WithGroup("g1").With("key", "logger")
+
+			// when
+			l.Info("buffered msg", "key", "record", "b", "2")
+			rh, reader := getSimplifiedTextHandler()
+			setRealHandler(t, h, rh)
+			l.Info("direct msg", "key", "record", "b", "2")
+
+			// then
+			lines := getLines(t, reader)
+			expectLinesNo(t, lines, 2)
+			for _, line := range lines {
+				expectAttr(t, line, "key", "logger")
+				expectAttr(t, line, "g1.a", "1")
+				expectAttr(t, line, "g1.b", "2")
+				expectAttr(t, line, "g1.key", tc.expected)
+				if n := strings.Count(line, "g1.key="); n != 1 {
+					t.Fatalf("expected g1.key once, found %d times in %q", n, line)
+				}
+			}
+		})
+	}
+}
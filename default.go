@@ -0,0 +1,21 @@
+package slogbuffer
+
+import (
+	"context"
+	"log/slog"
+)
+
+// InstallDefault sets default logger (see [slog.SetDefault]) to a logger that buffers records
+// at or above provided level, which covers common pattern of buffering everything logged using
+// global logger until application is configured (e.g. flags are parsed).
+//
+// Returned promote function flushes buffered records to real handler (see SetRealHandler).
+// Default logger keeps using buffering handler, which becomes simple wrapper of real handler,
+// so options applied to real handler (e.g. failover or circuit breaker) keep working and, if
+// promotion fails (e.g. health check of real handler fails), records stay buffered and promote
+// can be called again.
+func InstallDefault(leveler slog.Leveler, opts ...Option) (promote func(ctx context.Context, real slog.Handler) error) {
+	h := NewBufferLogHandler(leveler, opts...)
+	slog.SetDefault(slog.New(h))
+	return h.SetRealHandler
+}
@@ -0,0 +1,76 @@
+package slogbuffer_test
+
+import (
+	"context"
+	"errors"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"testing"
+)
+
+func TestInstallDefault(t *testing.T) {
+	// given
+	original := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(original) })
+	promote := slogbuffer.InstallDefault(slog.LevelDebug)
+	logger := slog.Default()
+
+	// when
+	slog.Debug("debug msg")
+	slog.Info("info msg")
+
+	rh, reader := getSimplifiedTextHandler()
+	if err := promote(context.Background(), rh); err != nil {
+		t.Fatalf("promoting default logger: %v", err)
+	}
+	slog.Info("after promote msg")
+	logger.Info("old logger msg")
+
+	// then
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 4)
+	expectMsg(t, lines[0], "debug msg")
+	expectMsg(t, lines[1], "info msg")
+	expectMsg(t, lines[2], "after promote msg")
+	expectMsg(t, lines[3], "old logger msg")
+}
+
+func TestInstallDefault_PromoteFailed(t *testing.T) {
+	// given
+	original := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(original) })
+	healthy := false
+	promote := slogbuffer.InstallDefault(slog.LevelDebug, slogbuffer.WithHealthCheck(func(context.Context) error {
+		if !healthy {
+			return errors.New("sink not ready")
+		}
+		return nil
+	}))
+	installed := slog.Default()
+	slog.Info("before promote msg")
+	rh, reader := getSimplifiedTextHandler()
+
+	// when
+	err := promote(context.Background(), rh)
+
+	// then
+	if !errors.Is(err, slogbuffer.ErrHealthCheckFailed) {
+		t.Fatalf("expected health check error, got: %v", err)
+	}
+	if slog.Default() != installed {
+		t.Fatalf("expected default logger to be kept when promotion failed")
+	}
+	slog.Info("after failed promote msg")
+
+	// when
+	healthy = true
+	if err := promote(context.Background(), rh); err != nil {
+		t.Fatalf("promoting default logger: %v", err)
+	}
+
+	// then
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 2)
+	expectMsg(t, lines[0], "before promote msg")
+	expectMsg(t, lines[1], "after failed promote msg")
+}
@@ -0,0 +1,78 @@
+package slogbuffer
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// DiscardIf removes buffered records for which provided function returns true, keeping the
+// rest in order. Attributes and groups of the logger are folded into attributes of records
+// passed to the function. Error is possible only for storages that keep records outside of memory.
+func (h *BufferLogHandler) DiscardIf(discard func(r slog.Record) bool) error {
+	return h.removeRecords(func(rec record) bool {
+		return discard(rec.materialize())
+	})
+}
+
+// DiscardOlderThan removes buffered records logged more than provided duration ago, according
+// to clock of the handler (see WithClock).
+func (h *BufferLogHandler) DiscardOlderThan(d time.Duration) error {
+	cutoff := h.now().Add(-d)
+	return h.removeRecords(func(rec record) bool {
+		return rec.Time.Before(cutoff)
+	})
+}
+
+// DiscardBelow removes buffered records below provided level. Unlike RaiseLevel, records
+// below provided level logged afterward are still buffered.
+func (h *BufferLogHandler) DiscardBelow(level slog.Level) error {
+	return h.removeRecords(func(rec record) bool {
+		return rec.Level < level
+	})
+}
+
+// RaiseLevel makes handler ignore records below provided level from now on, regardless of
+// level handler was created with and level of real handler, and removes already buffered
+// records below it, e.g. when quiet mode is requested by configuration that is read after
+// logging started. Level can only be raised, lower level than the one already set is ignored.
+// Error is possible only for storages that keep records outside of memory.
+func (h *BufferLogHandler) RaiseLevel(level slog.Level) error {
+	root := h.root()
+	for {
+		current := root.raisedLevel.Load()
+		if current != nil && *current >= level {
+			level = *current
+			break
+		}
+		if root.raisedLevel.CompareAndSwap(current, &level) {
+			break
+		}
+	}
+	return h.removeRecords(func(rec record) bool {
+		return rec.Level < level
+	})
+}
+
+// belowRaisedLevel reports if provided level is below level set using RaiseLevel.
+func (h *BufferLogHandler) belowRaisedLevel(level slog.Level) bool {
+	raised := h.root().raisedLevel.Load()
+	return raised != nil && level < *raised
+}
+
+// removeRecords removes buffered records for which provided function returns true, keeping
+// order of remaining ones. Storages that can not remove records in place are emptied and
+// remaining records are added back, so records logged concurrently might end up before them.
+func (h *BufferLogHandler) removeRecords(remove func(rec record) bool) error {
+	if r, ok := h.buffer.(remover); ok {
+		r.removeIf(remove)
+		return nil
+	}
+	var err error
+	for _, rec := range h.buffer.Take() {
+		if !remove(rec) {
+			err = errors.Join(err, h.buffer.Add(rec))
+		}
+	}
+	return err
+}
@@ -0,0 +1,135 @@
+package slogbuffer_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestBufferLogHandler_RaiseLevel(t *testing.T) {
+	levels := []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn}
+	for name, build := range storageHandlers {
+		t.Run(name, func(t *testing.T) {
+			// given
+			h := build(t)
+			l := slog.New(h).WithGroup("g").With("common", "attr")
+			for i := range 12 {
+				l.Log(context.Background(), levels[i%3], fmt.Sprintf("msg %d", i), "no", i)
+			}
+
+			// when
+			if err := h.RaiseLevel(slog.LevelInfo); err != nil {
+				t.Fatalf("raising level: %v", err)
+			}
+			// level can not be lowered
+			if err := h.RaiseLevel(slog.LevelDebug); err != nil {
+				t.Fatalf("raising level: %v", err)
+			}
+			l.Debug("ignored msg")
+			l.Info("late msg", "no", 12)
+
+			// then
+			if h.Len() != 9 {
+				t.Fatalf("expected 9 records, got %d", h.Len())
+			}
+			if h.Enabled(context.Background(), slog.LevelDebug) {
+				t.Fatalf("expected debug level to be disabled")
+			}
+
+			rh, reader := getSimplifiedTextHandler()
+			setRealHandler(t, h, rh)
+			l.Debug("ignored direct msg")
+
+			lines := getLines(t, reader)
+			expectLinesNo(t, lines, 9)
+			expected := []int{1, 2, 4, 5, 7, 8, 10, 11, 12}
+			for i, line := range lines {
+				expectAttr(t, line, "g.no", fmt.Sprintf("%d", expected[i]))
+				expectAttr(t, line, "g.common", "attr")
+			}
+		})
+	}
+}
+
+func TestBufferLogHandler_RaiseLevel_Handle(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	if err := h.RaiseLevel(slog.LevelWarn); err != nil {
+		t.Fatalf("raising level: %v", err)
+	}
+
+	// when
+	// Handle is called directly, without checking Enabled first
+	for _, level := range []slog.Level{slog.LevelInfo, slog.LevelWarn} {
+		if err := h.Handle(context.Background(), slog.NewRecord(time.Now(), level, level.String()+" msg", 0)); err != nil {
+			t.Fatalf("handling record: %v", err)
+		}
+	}
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandler(t, h, rh)
+	if err := h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "direct msg", 0)); err != nil {
+		t.Fatalf("handling record: %v", err)
+	}
+
+	// then
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 1)
+	expectMsg(t, lines[0], "WARN msg")
+}
+
+func TestBufferLogHandler_DiscardIf(t *testing.T) {
+	for name, build := range storageHandlers {
+		t.Run(name, func(t *testing.T) {
+			// given
+			h := build(t)
+			now := time.Now()
+			levels := []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn}
+			handler := h.WithGroup("g").WithAttrs([]slog.Attr{slog.String("common", "attr")})
+			for i := range 12 {
+				// every record is a minute younger than the previous one
+				r := slog.NewRecord(now.Add(time.Duration(i-12)*time.Minute), levels[i%3], fmt.Sprintf("msg %d", i), 0)
+				r.AddAttrs(slog.Int("no", i), slog.Bool("secret", i == 7))
+				if err := handler.Handle(context.Background(), r); err != nil {
+					t.Fatalf("handling record: %v", err)
+				}
+			}
+
+			// when
+			if err := h.DiscardOlderThan(630 * time.Second); err != nil {
+				t.Fatalf("discarding records: %v", err)
+			}
+			if err := h.DiscardBelow(slog.LevelInfo); err != nil {
+				t.Fatalf("discarding records: %v", err)
+			}
+			if err := h.DiscardIf(func(r slog.Record) bool {
+				secret := false
+				r.Attrs(func(a slog.Attr) bool {
+					if a.Key == "g" {
+						for _, member := range a.Value.Group() {
+							secret = secret || (member.Key == "secret" && member.Value.Bool())
+						}
+					}
+					return true
+				})
+				return secret
+			}); err != nil {
+				t.Fatalf("discarding records: %v", err)
+			}
+			// records below discarded level are still buffered
+			slog.New(h).Debug("late msg", "no", 12)
+
+			// then
+			rh, reader := getSimplifiedTextHandler()
+			setRealHandler(t, h, rh)
+			lines := getLines(t, reader)
+			expected := []int{2, 4, 5, 8, 10, 11, 12}
+			expectLinesNo(t, lines, len(expected))
+			for i, line := range lines {
+				expectAttr(t, line, "no", fmt.Sprintf("%d", expected[i]))
+			}
+		})
+	}
+}
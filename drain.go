@@ -0,0 +1,43 @@
+package slogbuffer
+
+import (
+	"iter"
+	"log/slog"
+)
+
+// Drain returns iterator that removes buffered records one by one, oldest first, and yields
+// them, so records can be pumped into custom pipeline incrementally instead of being flushed
+// at once. Like with Records, attributes and groups of the logger are folded into attributes
+// of yielded records. If eviction summary is enabled (see WithEvictionSummary), summary of
+// evicted records is yielded first.
+//
+// Records logged concurrently are yielded as well, iteration ends once buffer is empty.
+// Records that were not yielded when iteration is stopped stay buffered.
+func (h *BufferLogHandler) Drain() iter.Seq[slog.Record] {
+	return func(yield func(slog.Record) bool) {
+		h.enforceBounds()
+		if h.stats != nil && h.stats.summary != nil {
+			if summary, ok := h.stats.summary.take(); ok && !yield(summary.materialize()) {
+				return
+			}
+		}
+		for {
+			rec, ok := h.PopOldest()
+			if !ok || !yield(rec) {
+				return
+			}
+		}
+	}
+}
+
+// PopOldest removes the oldest buffered record and returns it, which allows consumers to
+// implement their own trickle flush. Returns false if buffer is empty. Like with Records,
+// attributes and groups of the logger are folded into attributes of the record. Unlike
+// Drain, it never returns summary of evicted records.
+func (h *BufferLogHandler) PopOldest() (slog.Record, bool) {
+	rec, ok := h.buffer.PopOldest()
+	if !ok {
+		return slog.Record{}, false
+	}
+	return rec.materialize(), true
+}
@@ -0,0 +1,127 @@
+package slogbuffer_test
+
+import (
+	"fmt"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"path/filepath"
+	"testing"
+)
+
+func TestBufferLogHandler_Drain(t *testing.T) {
+	handlers := map[string]func(t *testing.T) *slogbuffer.BufferLogHandler{
+		"memory": func(t *testing.T) *slogbuffer.BufferLogHandler {
+			return slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+		},
+		"compressed": func(t *testing.T) *slogbuffer.BufferLogHandler {
+			return slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithCompression(128))
+		},
+		"chunked": func(t *testing.T) *slogbuffer.BufferLogHandler {
+			return slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithChunkedGrowth(3))
+		},
+		"sharded": func(t *testing.T) *slogbuffer.BufferLogHandler {
+			return slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithShards(3))
+		},
+		"leveled": func(t *testing.T) *slogbuffer.BufferLogHandler {
+			return slogbuffer.NewBoundBufferLogHandler(slog.LevelDebug, 100,
+				slogbuffer.WithLevelCapacity(slog.LevelWarn, 10), slogbuffer.WithPin(slogbuffer.PinAttr("no", 3)))
+		},
+		"file": func(t *testing.T) *slogbuffer.BufferLogHandler {
+			h, err := slogbuffer.NewFileBufferLogHandler(filepath.Join(t.TempDir(), "buffer"), 64*1024, slog.LevelDebug)
+			if err != nil {
+				t.Fatalf("creating handler: %v", err)
+			}
+			t.Cleanup(func() { _ = h.Close() })
+			return h
+		},
+		"wal": func(t *testing.T) *slogbuffer.BufferLogHandler {
+			h, err := slogbuffer.NewWALBufferLogHandler(filepath.Join(t.TempDir(), "wal"), 0, slog.LevelDebug)
+			if err != nil {
+				t.Fatalf("creating handler: %v", err)
+			}
+			t.Cleanup(func() { _ = h.Close() })
+			return h
+		},
+	}
+
+	for name, build := range handlers {
+		t.Run(name, func(t *testing.T) {
+			// given
+			h := build(t)
+			l := slog.New(h).WithGroup("g").With("common", "attr")
+			for i := range 10 {
+				l.Info(fmt.Sprintf("msg %d", i), "no", i)
+			}
+
+			// when
+			var drained []slog.Record
+			for rec := range h.Drain() {
+				drained = append(drained, rec)
+				if len(drained) == 4 {
+					break
+				}
+			}
+
+			// then
+			if h.Len() != 6 {
+				t.Fatalf("expected 6 records to stay buffered, got %d", h.Len())
+			}
+			for rec := range h.Drain() {
+				drained = append(drained, rec)
+			}
+			if h.Len() != 0 || len(drained) != 10 {
+				t.Fatalf("expected all 10 records to be drained, got %d (%d still buffered)", len(drained), h.Len())
+			}
+			for i, rec := range drained {
+				if rec.Message != fmt.Sprintf("msg %d", i) {
+					t.Fatalf("expected record %d, got %q", i, rec.Message)
+				}
+				expectRecordAttr(t, rec, "g", slog.GroupValue(slog.String("common", "attr"), slog.Int("no", i)))
+			}
+		})
+	}
+}
+
+func TestBufferLogHandler_Drain_ConcurrentProducer(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	l := slog.New(h)
+	l.Info("first")
+
+	// when
+	var messages []string
+	for rec := range h.Drain() {
+		messages = append(messages, rec.Message)
+		if rec.Message == "first" {
+			l.Info("second")
+		}
+	}
+
+	// then
+	if len(messages) != 2 || messages[1] != "second" {
+		t.Fatalf("expected records logged while draining to be yielded, got %v", messages)
+	}
+}
+
+func TestBufferLogHandler_Drain_EvictionSummary(t *testing.T) {
+	// given
+	h := slogbuffer.NewBoundBufferLogHandler(slog.LevelDebug, 2, slogbuffer.WithEvictionSummary(""))
+	l := slog.New(h)
+	for i := range 5 {
+		l.Info("msg", "no", i)
+	}
+
+	// when
+	records := h.Records()
+	var drained []slog.Record
+	for rec := range h.Drain() {
+		drained = append(drained, rec)
+	}
+
+	// then
+	if len(records) != 2 || len(drained) != 3 {
+		t.Fatalf("expected summary and 2 records, got %d", len(drained))
+	}
+	expectRecordAttr(t, drained[0], "evicted", slog.IntValue(3))
+	expectRecordAttr(t, drained[2], "no", slog.IntValue(4))
+}
@@ -0,0 +1,41 @@
+package slogbuffer
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrAlreadyBound is returned when real handler is set on handler that already has one
+// (see SetRealHandler).
+var ErrAlreadyBound = errors.New("slogbuffer: real handler already set")
+
+// ErrBufferFull is returned when record is dropped because there is no space for it, e.g. by
+// AsyncHandler with OverflowDropNewest policy.
+var ErrBufferFull = errors.New("slogbuffer: buffer full")
+
+// FlushError is returned when real handler fails to handle some of flushed records. Those
+// records are sent to failover handler or kept for RetryFlush (see DeadLetters). Errors
+// returned by real handler can be matched using errors.Is and errors.As.
+type FlushError struct {
+	// Failed is number of records real handler failed to handle.
+	Failed int
+	// Err is combination of errors returned by real handler.
+	Err error
+}
+
+func (e *FlushError) Error() string {
+	return fmt.Sprintf("slogbuffer: failed to flush %d records: %v", e.Failed, e.Err)
+}
+
+func (e *FlushError) Unwrap() error {
+	return e.Err
+}
+
+// newFlushError returns FlushError for provided number of failed records and combined errors
+// of real handler, or nil if there were no failures.
+func newFlushError(failed int, err error) error {
+	if failed == 0 && err == nil {
+		return nil
+	}
+	return &FlushError{Failed: failed, Err: err}
+}
@@ -0,0 +1,87 @@
+package slogbuffer_test
+
+import (
+	"context"
+	"errors"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"sync"
+	"testing"
+)
+
+func TestFlushError(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	l := slog.New(h)
+	for i := range 5 {
+		l.Info("msg", "no", i)
+	}
+
+	// when
+	rh, _ := getSimplifiedTextHandler()
+	err := h.SetRealHandler(context.Background(), newFailingHandler(rh, 2))
+
+	// then
+	var flushErr *slogbuffer.FlushError
+	if !errors.As(err, &flushErr) {
+		t.Fatalf("expected FlushError, got %v", err)
+	}
+	if flushErr.Failed != 2 {
+		t.Fatalf("expected 2 failed records, got %d", flushErr.Failed)
+	}
+	if !errors.Is(err, errHandlerFailed) {
+		t.Fatalf("expected error of real handler to be matched, got %v", err)
+	}
+	if len(h.DeadLetters()) != 2 {
+		t.Fatalf("expected 2 dead letters, got %d", len(h.DeadLetters()))
+	}
+}
+
+func TestErrAlreadyBound(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	first, firstReader := getSimplifiedTextHandler()
+	setRealHandler(t, h, first)
+	other := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	slog.New(other).Info("msg")
+
+	// when
+	second, secondReader := getSimplifiedTextHandler()
+	setErr := h.SetRealHandler(context.Background(), second)
+	mergeErr := slogbuffer.FlushMerged(context.Background(), second, other, h)
+	slog.New(h).Info("msg")
+
+	// then
+	if !errors.Is(setErr, slogbuffer.ErrAlreadyBound) || !errors.Is(mergeErr, slogbuffer.ErrAlreadyBound) {
+		t.Fatalf("expected ErrAlreadyBound, got %v and %v", setErr, mergeErr)
+	}
+	expectLinesNo(t, getLines(t, firstReader), 1)
+	expectLinesNo(t, getLines(t, secondReader), 0)
+	if other.Len() != 1 {
+		t.Fatalf("expected records of other handler to stay buffered, got %d", other.Len())
+	}
+}
+
+func TestErrBufferFull(t *testing.T) {
+	// given
+	rh, _ := getSimplifiedTextHandler()
+	lock := &sync.Mutex{}
+	h := slogbuffer.NewAsyncHandler(&lockedHandler{Handler: rh, lock: lock},
+		slogbuffer.WithQueueSize(1), slogbuffer.WithOverflowPolicy(slogbuffer.OverflowDropNewest))
+	defer h.Close(context.Background())
+
+	// when
+	lock.Lock() // blocks delivery
+	var err error
+	for range 3 {
+		// first record might be taken from the queue by background goroutine, but there
+		// is no space for the last one either way
+		err = h.Handle(context.Background(), newRecord(slog.LevelInfo, "msg"))
+	}
+	lock.Unlock()
+
+	// then
+	if !errors.Is(err, slogbuffer.ErrBufferFull) {
+		t.Fatalf("expected ErrBufferFull, got %v", err)
+	}
+}
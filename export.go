@@ -0,0 +1,169 @@
+package slogbuffer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// DumpTo writes all buffered records to provided writer, formatted by temporary handler
+// created using provided build function (e.g. one creating [slog.TextHandler] or
+// [slog.JSONHandler]). Buffer is not changed, so it is useful for crash reports or bug
+// bundles while handler keeps buffering.
+func (h *BufferLogHandler) DumpTo(w io.Writer, build func(io.Writer) slog.Handler) error {
+	handler := build(w)
+	var dumpErr error
+	for _, rec := range h.buffer.Snapshot() {
+		// handler that record would be sent to is not relevant for dump
+		rec.handler = nil
+		dumpErr = errors.Join(dumpErr, rec.emit(context.Background(), handler))
+	}
+	return dumpErr
+}
+
+// jsonRecord is representation of buffered record used for JSON export.
+type jsonRecord struct {
+	Time    time.Time  `json:"time"`
+	Level   slog.Level `json:"level"`
+	Message string     `json:"msg"`
+	// Groups are groups of the logger that were open when record was produced. Attributes
+	// of the record belong to them.
+	Groups []string `json:"groups,omitempty"`
+	// Attrs are attributes of the logger followed by attributes of the record itself,
+	// nested in groups they belong to.
+	Attrs jsonAttrs `json:"attrs,omitempty"`
+}
+
+func newJSONRecord(rec record) jsonRecord {
+	return jsonRecord{
+		Time:    rec.Time,
+		Level:   rec.Level,
+		Message: rec.Message,
+		Groups:  rec.groups,
+		Attrs:   rec.allAttrs(),
+	}
+}
+
+// jsonAttrs are encoded as JSON object, preserving order of attributes.
+type jsonAttrs []slog.Attr
+
+func (a jsonAttrs) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	first := true
+	for _, attr := range a {
+		attr.Value = attr.Value.Resolve()
+		if attr.Equal(slog.Attr{}) {
+			continue
+		}
+		key, err := json.Marshal(attr.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(jsonValue(attr.Value))
+		if err != nil {
+			return nil, err
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// jsonValue returns value that is encoded to JSON the same way [slog.JSONHandler] would encode it.
+func jsonValue(v slog.Value) any {
+	switch v.Kind() {
+	case slog.KindGroup:
+		return jsonAttrs(v.Group())
+	case slog.KindDuration:
+		return int64(v.Duration())
+	case slog.KindAny:
+		a := v.Any()
+		if _, data, ok := encodeValue(a); ok {
+			if json.Valid(data) {
+				return json.RawMessage(data)
+			}
+			return string(data)
+		}
+		if err, ok := a.(error); ok {
+			if _, isMarshaler := a.(json.Marshaler); !isMarshaler {
+				return err.Error()
+			}
+		}
+		if _, err := json.Marshal(a); err != nil {
+			return valueText(a)
+		}
+		return a
+	default:
+		return v.Any()
+	}
+}
+
+// WriteJSON writes all buffered records to provided writer as newline delimited JSON, one
+// object per record with time, level, message, groups and attributes of the record. Unlike
+// DumpTo, format does not depend on any handler, so it is suitable for consumption by
+// tools outside of the process. Buffer is not changed.
+func (h *BufferLogHandler) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	for _, rec := range h.buffer.Snapshot() {
+		if err := encoder.Encode(newJSONRecord(rec)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteText writes all buffered records to provided writer in logfmt style, as produced
+// by [slog.TextHandler]. Buffer is not changed.
+func (h *BufferLogHandler) WriteText(w io.Writer) error {
+	return h.DumpTo(w, func(w io.Writer) slog.Handler {
+		return slog.NewTextHandler(w, nil)
+	})
+}
+
+// compile time check that BufferLogHandler implements io.WriterTo interface.
+var _ io.WriterTo = &BufferLogHandler{}
+
+// WriteTo writes all buffered records to provided writer as newline delimited JSON (see
+// WriteJSON) and returns number of bytes written, so buffer can be streamed using io.Copy
+// into HTTP responses, archive files etc. Buffer is not changed.
+func (h *BufferLogHandler) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	err := h.WriteJSON(cw)
+	return cw.n, err
+}
+
+// countingWriter counts bytes written to underlying writer.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// compile time check that BufferLogHandler implements fmt.Stringer interface.
+var _ fmt.Stringer = &BufferLogHandler{}
+
+// String returns all buffered records in logfmt style (see WriteText), which is useful
+// for debugging, e.g. printing buffered records in test failure message.
+func (h *BufferLogHandler) String() string {
+	var buf bytes.Buffer
+	_ = h.WriteText(&buf)
+	return buf.String()
+}
@@ -0,0 +1,143 @@
+package slogbuffer_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/delicb/slogbuffer"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+func TestBufferLogHandler_DumpTo(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	l := slog.New(h)
+
+	l.Info("info msg", "foo", "bar")
+	l.WithGroup("g1").With("common", "attr").Warn("warn msg")
+
+	// when
+	out := new(bytes.Buffer)
+	err := h.DumpTo(out, func(w io.Writer) slog.Handler {
+		return slog.NewJSONHandler(w, nil)
+	})
+	if err != nil {
+		t.Fatalf("dumping buffer: %v", err)
+	}
+	lines := getLines(t, out)
+
+	// then
+	expectLinesNo(t, lines, 2)
+	expectContains(t, lines[0], `"msg":"info msg"`)
+	expectContains(t, lines[0], `"foo":"bar"`)
+	expectContains(t, lines[1], `"msg":"warn msg"`)
+	expectContains(t, lines[1], `"g1":{"common":"attr"}`)
+
+	// buffer is not changed by dump
+	if h.Len() != 2 {
+		t.Fatalf("expected 2 buffered records, got %d", h.Len())
+	}
+}
+
+func TestBufferLogHandler_WriteJSON(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	l := slog.New(h)
+
+	l.Info("info msg", "foo", "bar", "err", errors.New("some error"))
+	l.WithGroup("g1").With("common", "attr").Warn("warn msg", slog.Group("g2", "no", 42))
+
+	// when
+	out := new(bytes.Buffer)
+	if err := h.WriteJSON(out); err != nil {
+		t.Fatalf("writing json: %v", err)
+	}
+	lines := getLines(t, out)
+
+	// then
+	expectLinesNo(t, lines, 2)
+
+	var first map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("decoding line %s: %v", lines[0], err)
+	}
+	if first["msg"] != "info msg" || first["level"] != "INFO" {
+		t.Fatalf("unexpected record %s", lines[0])
+	}
+	if _, ok := first["time"]; !ok {
+		t.Fatalf("time missing, line is %s", lines[0])
+	}
+	if _, ok := first["groups"]; ok {
+		t.Fatalf("unexpected groups, line is %s", lines[0])
+	}
+	expectContains(t, lines[0], `"attrs":{"foo":"bar","err":"some error"}`)
+
+	expectContains(t, lines[1], `"level":"WARN"`)
+	expectContains(t, lines[1], `"msg":"warn msg"`)
+	expectContains(t, lines[1], `"groups":["g1"]`)
+	expectContains(t, lines[1], `"attrs":{"g1":{"common":"attr","g2":{"no":42}}}`)
+}
+
+func TestBufferLogHandler_WriteText(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	l := slog.New(h)
+
+	l.Debug("debug msg", "foo", "bar")
+	l.WithGroup("g1").Warn("warn msg", "foo", "bar")
+
+	// when
+	out := new(bytes.Buffer)
+	if err := h.WriteText(out); err != nil {
+		t.Fatalf("writing text: %v", err)
+	}
+	text := out.String()
+	lines := getLines(t, out)
+
+	// then
+	expectLinesNo(t, lines, 2)
+	expectLevel(t, lines[0], slog.LevelDebug)
+	expectMsg(t, lines[0], "debug msg")
+	expectAttr(t, lines[0], "foo", "bar")
+	expectLevel(t, lines[1], slog.LevelWarn)
+	expectMsg(t, lines[1], "warn msg")
+	expectAttr(t, lines[1], "g1.foo", "bar")
+
+	if h.String() != text {
+		t.Fatalf("String returned %q, expected %q", h.String(), text)
+	}
+}
+
+func TestBufferLogHandler_WriteTo(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	l := slog.New(h)
+
+	l.Info("info msg", "foo", "bar")
+	l.Warn("warn msg", "no", 42)
+
+	expected := new(bytes.Buffer)
+	if err := h.WriteJSON(expected); err != nil {
+		t.Fatalf("writing json: %v", err)
+	}
+
+	// when
+	out := new(bytes.Buffer)
+	n, err := h.WriteTo(out)
+
+	// then
+	if err != nil {
+		t.Fatalf("writing buffer: %v", err)
+	}
+	if n != int64(out.Len()) {
+		t.Fatalf("reported %d bytes, %d were written", n, out.Len())
+	}
+	if out.String() != expected.String() {
+		t.Fatalf("expected %q, got %q", expected.String(), out.String())
+	}
+	if h.Len() != 2 {
+		t.Fatalf("expected buffer to be unchanged, got %d records", h.Len())
+	}
+}
@@ -0,0 +1,42 @@
+package slogbuffer
+
+import (
+	"expvar"
+	"sync"
+	"sync/atomic"
+)
+
+// expvarHandlers maps names published using WithExpvar to handlers whose statistics they
+// report. expvar does not allow replacing published variables, so handler is replaced instead.
+var (
+	expvarLock     sync.Mutex
+	expvarHandlers = map[string]*atomic.Pointer[BufferLogHandler]{}
+)
+
+// publishExpvar publishes statistics of provided handler using expvar under provided name.
+func publishExpvar(name string, h *BufferLogHandler) {
+	expvarLock.Lock()
+	defer expvarLock.Unlock()
+
+	if published, ok := expvarHandlers[name]; ok {
+		published.Store(h)
+		return
+	}
+	published := new(atomic.Pointer[BufferLogHandler])
+	published.Store(h)
+	expvarHandlers[name] = published
+	expvar.Publish(name, expvar.Func(func() any {
+		return published.Load().expvarStats()
+	}))
+}
+
+// expvarStats returns statistics of the handler, as published using expvar.
+func (h *BufferLogHandler) expvarStats() map[string]any {
+	return map[string]any{
+		"len":     h.Len(),
+		"cap":     h.Cap(),
+		"dropped": h.Dropped(),
+		"bytes":   h.ApproxBytes(),
+		"state":   h.State().String(),
+	}
+}
@@ -0,0 +1,54 @@
+package slogbuffer_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"github.com/delicb/slogbuffer"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+func TestWithExpvar(t *testing.T) {
+	// given
+	h := slogbuffer.NewBoundBufferLogHandler(slog.LevelDebug, 2, slogbuffer.WithExpvar("slogbuffer_test"))
+	l := slog.New(h)
+
+	// when
+	for range 3 {
+		l.Info("info msg")
+	}
+
+	// then
+	stats := expvarStats(t, "slogbuffer_test")
+	if stats["len"] != 2.0 || stats["cap"] != 2.0 || stats["dropped"] != 1.0 || stats["state"] != "Buffering" {
+		t.Fatalf("unexpected statistics %v", stats)
+	}
+	if bytes, ok := stats["bytes"].(float64); !ok || bytes <= 0 {
+		t.Fatalf("unexpected size of records %v", stats["bytes"])
+	}
+
+	setRealHandler(t, h, slog.NewTextHandler(io.Discard, nil))
+	if stats := expvarStats(t, "slogbuffer_test"); stats["len"] != 0.0 || stats["state"] != "Flushed" {
+		t.Fatalf("unexpected statistics after flush %v", stats)
+	}
+
+	// handler created with the same name replaces published one
+	slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithExpvar("slogbuffer_test"))
+	if stats := expvarStats(t, "slogbuffer_test"); stats["cap"] != 0.0 || stats["state"] != "Buffering" {
+		t.Fatalf("unexpected statistics of replaced handler %v", stats)
+	}
+}
+
+func expvarStats(t *testing.T, name string) map[string]any {
+	t.Helper()
+	v := expvar.Get(name)
+	if v == nil {
+		t.Fatalf("variable %s not published", name)
+	}
+	var stats map[string]any
+	if err := json.Unmarshal([]byte(v.String()), &stats); err != nil {
+		t.Fatalf("decoding statistics %s: %v", v.String(), err)
+	}
+	return stats
+}
@@ -0,0 +1,369 @@
+package slogbuffer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"unsafe"
+)
+
+// Storage file starts with fixed size header, followed by data region that is used as
+// ring buffer of records. Each record is stored as 4 byte length followed by record
+// encoded the same way as by Encode. Zero length (or lack of space for the length at the
+// end of data region) means that next record is at the start of data region.
+const (
+	fileStorageMagic = "SLOGRING"
+	// header contains magic, capacity of data region, offset of the oldest record,
+	// offset where next record will be written and number of records
+	fileHeaderSize = 64
+	// entryPrefixSize is size of length prefix of each record
+	entryPrefixSize = 4
+)
+
+// ErrRecordTooLarge is returned when record does not fit into file storage at all.
+var ErrRecordTooLarge = errors.New("slogbuffer: record too large for storage")
+
+// fileEntry describes position of a record in data region of storage file.
+type fileEntry struct {
+	offset int64
+	// size of entry, including length prefix
+	size int64
+}
+
+// fileStorage keeps records in pre-allocated file, used as ring buffer bound by size in
+// bytes. Only small index of record positions is kept in memory, so large buffers do
+// not pressure the heap and records can be recovered from the file after process dies.
+type fileStorage struct {
+	file *os.File
+	// capacity is size of data region of the file
+	capacity int64
+	// entries is index of stored records, oldest first
+	entries []fileEntry
+	// tail is offset in data region where next record will be written
+	tail int64
+
+	// onAdd and onRemove, when set, are called (while holding the lock) for every record
+	// added to the storage and every record removed from it. onEvict is additionally called
+	// for records removed to make space for new ones.
+	onAdd    func(record)
+	onRemove func(record)
+	onEvict  func(record)
+
+	lock sync.Mutex
+}
+
+// openFileStorage opens storage file at provided path, creating and pre-allocating it to
+// hold maxBytes of records if it does not exist. Records from existing file are recovered
+// and capacity of existing file is kept.
+func openFileStorage(path string, maxBytes int64, onAdd, onRemove, onEvict func(record)) (*fileStorage, error) {
+	if maxBytes <= entryPrefixSize {
+		return nil, fmt.Errorf("slogbuffer: file storage size %d too small", maxBytes)
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	s := &fileStorage{
+		file:     file,
+		capacity: maxBytes,
+		onAdd:    onAdd,
+		onRemove: onRemove,
+		onEvict:  onEvict,
+	}
+
+	info, err := file.Stat()
+	if err == nil && info.Size() == 0 {
+		err = s.init()
+	} else if err == nil {
+		err = s.recover()
+	}
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("opening storage file %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// init pre-allocates new storage file.
+func (s *fileStorage) init() error {
+	if err := s.file.Truncate(fileHeaderSize + s.capacity); err != nil {
+		return err
+	}
+	return s.writeHeader()
+}
+
+// recover reads header and rebuilds index of records from existing storage file.
+func (s *fileStorage) recover() error {
+	header := make([]byte, fileHeaderSize)
+	if _, err := s.file.ReadAt(header, 0); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidFormat, err)
+	}
+	if string(header[:len(fileStorageMagic)]) != fileStorageMagic {
+		return ErrInvalidFormat
+	}
+	s.capacity = int64(binary.LittleEndian.Uint64(header[8:]))
+	pos := int64(binary.LittleEndian.Uint64(header[16:]))
+	s.tail = int64(binary.LittleEndian.Uint64(header[24:]))
+	count := binary.LittleEndian.Uint64(header[32:])
+
+	prefix := make([]byte, entryPrefixSize)
+	for range count {
+		size, err := s.readPrefix(pos, prefix)
+		if err == nil && size == 0 {
+			pos = 0
+			size, err = s.readPrefix(pos, prefix)
+		}
+		if err != nil {
+			return err
+		}
+		if size == 0 || pos+entryPrefixSize+size > s.capacity {
+			return fmt.Errorf("%w: corrupted record at offset %d", ErrInvalidFormat, pos)
+		}
+		entry := fileEntry{offset: pos, size: entryPrefixSize + size}
+		s.entries = append(s.entries, entry)
+		if s.onAdd != nil {
+			if rec, err := s.read(entry); err == nil {
+				s.onAdd(rec)
+			}
+		}
+		pos += entry.size
+	}
+	return nil
+}
+
+// readPrefix reads length of record at provided position. Zero is returned if there
+// is no space for the length, meaning that record is at the start of data region.
+func (s *fileStorage) readPrefix(pos int64, prefix []byte) (int64, error) {
+	if pos+entryPrefixSize > s.capacity {
+		return 0, nil
+	}
+	if _, err := s.file.ReadAt(prefix, fileHeaderSize+pos); err != nil {
+		return 0, err
+	}
+	return int64(binary.LittleEndian.Uint32(prefix)), nil
+}
+
+// writeHeader persists current position of records. Caller must hold the lock.
+func (s *fileStorage) writeHeader() error {
+	header := make([]byte, fileHeaderSize)
+	copy(header, fileStorageMagic)
+	head := s.tail
+	if len(s.entries) > 0 {
+		head = s.entries[0].offset
+	}
+	binary.LittleEndian.PutUint64(header[8:], uint64(s.capacity))
+	binary.LittleEndian.PutUint64(header[16:], uint64(head))
+	binary.LittleEndian.PutUint64(header[24:], uint64(s.tail))
+	binary.LittleEndian.PutUint64(header[32:], uint64(len(s.entries)))
+	_, err := s.file.WriteAt(header, 0)
+	return err
+}
+
+// read reads and decodes record described by entry. Caller must hold the lock.
+func (s *fileStorage) read(entry fileEntry) (record, error) {
+	payload := make([]byte, entry.size-entryPrefixSize)
+	if _, err := s.file.ReadAt(payload, fileHeaderSize+entry.offset+entryPrefixSize); err != nil {
+		return record{}, err
+	}
+	dec := decoder{r: bytes.NewReader(payload)}
+	rec := dec.record()
+	return rec, dec.err
+}
+
+// readAll reads records described by provided entries. Records that can not be read
+// are skipped. Caller must hold the lock.
+func (s *fileStorage) readAll(entries []fileEntry) []record {
+	res := make([]record, 0, len(entries))
+	for _, entry := range entries {
+		if rec, err := s.read(entry); err == nil {
+			res = append(res, rec)
+		}
+	}
+	return res
+}
+
+// removeOldest removes the oldest record, reporting it as evicted if requested. Caller must
+// hold the lock and storage must not be empty.
+func (s *fileStorage) removeOldest(evicted bool) {
+	if s.onRemove != nil || (evicted && s.onEvict != nil) {
+		if rec, err := s.read(s.entries[0]); err == nil {
+			if s.onRemove != nil {
+				s.onRemove(rec)
+			}
+			if evicted && s.onEvict != nil {
+				s.onEvict(rec)
+			}
+		}
+	}
+	s.entries = s.entries[1:]
+}
+
+func (s *fileStorage) evictWhile(evict func(oldest record) bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for len(s.entries) > 0 {
+		// records that can not be read are evicted without asking
+		if rec, err := s.read(s.entries[0]); err == nil && !evict(rec) {
+			return
+		}
+		s.removeOldest(true)
+	}
+}
+
+func (s *fileStorage) Add(rec record) error {
+	payload := getEncoder()
+	defer putEncoder(payload)
+	payload.record(rec)
+	size := int64(entryPrefixSize + payload.buf.Len())
+	if size > s.capacity {
+		return ErrRecordTooLarge
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// find position for new record, evicting the oldest records until there is enough space
+	var pos int64
+	for {
+		if len(s.entries) == 0 {
+			s.tail = 0
+		}
+		var oldest *fileEntry
+		if len(s.entries) > 0 {
+			oldest = &s.entries[0]
+		}
+		// records are wrapped if the oldest one is stored after position for the next one
+		wrapped := oldest != nil && oldest.offset >= s.tail
+
+		if s.tail+size <= s.capacity {
+			if !wrapped || oldest.offset >= s.tail+size {
+				pos = s.tail
+				break
+			}
+		} else if !wrapped && (oldest == nil || oldest.offset >= size) {
+			// mark rest of data region as unused, so reader continues from the start
+			if s.capacity-s.tail >= entryPrefixSize {
+				if _, err := s.file.WriteAt(make([]byte, entryPrefixSize), fileHeaderSize+s.tail); err != nil {
+					return err
+				}
+			}
+			pos = 0
+			break
+		}
+		s.removeOldest(true)
+	}
+
+	data := make([]byte, entryPrefixSize, size)
+	binary.LittleEndian.PutUint32(data, uint32(payload.buf.Len()))
+	data = append(data, payload.buf.Bytes()...)
+	if _, err := s.file.WriteAt(data, fileHeaderSize+pos); err != nil {
+		return err
+	}
+
+	s.entries = append(s.entries, fileEntry{offset: pos, size: size})
+	s.tail = pos + size
+	if s.onAdd != nil {
+		s.onAdd(rec)
+	}
+	return s.writeHeader()
+}
+
+func (s *fileStorage) Take() []record {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	res := s.readAll(s.entries)
+	s.reset()
+	return res
+}
+
+func (s *fileStorage) Snapshot() []record {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.readAll(s.entries)
+}
+
+func (s *fileStorage) Head(n int) []record {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.readAll(s.entries[:min(max(n, 0), len(s.entries))])
+}
+
+func (s *fileStorage) Tail(n int) []record {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.readAll(s.entries[max(len(s.entries)-max(n, 0), 0):])
+}
+
+func (s *fileStorage) PopOldest() (record, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for len(s.entries) > 0 {
+		// records that can not be read are skipped
+		rec, err := s.read(s.entries[0])
+		s.removeOldest(false)
+		if err == nil {
+			_ = s.writeHeader()
+			return rec, true
+		}
+	}
+	_ = s.writeHeader()
+	return record{}, false
+}
+
+func (s *fileStorage) Clear() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.reset()
+}
+
+// reset removes all records. Caller must hold the lock.
+func (s *fileStorage) reset() {
+	for len(s.entries) > 0 {
+		s.removeOldest(false)
+	}
+	s.entries = nil
+	s.tail = 0
+	_ = s.writeHeader()
+}
+
+func (s *fileStorage) Len() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.entries)
+}
+
+// approxBytes returns size of index of records, since records themselves are kept in file.
+func (s *fileStorage) approxBytes() int64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return int64(cap(s.entries)) * int64(unsafe.Sizeof(fileEntry{}))
+}
+
+func (s *fileStorage) Cap() int {
+	return 0
+}
+
+// Close closes storage file. Records stay in the file and are recovered when it is opened again.
+func (s *fileStorage) Close() error {
+	return s.file.Close()
+}
+
+// NewFileBufferLogHandler returns log handler that keeps buffered records in file at provided
+// path instead of in memory. File is pre-allocated to hold maxBytes of records and used as
+// ring buffer, evicting the oldest records when it is full. Since only small index of records
+// is kept in memory, this is suitable for very large buffers. File content survives the
+// process, so if file at provided path already exists (e.g. after process was killed), its
+// records are recovered and its capacity is kept. Close should be called to release the file.
+func NewFileBufferLogHandler(path string, maxBytes int64, leveler slog.Leveler, opts ...Option) (*BufferLogHandler, error) {
+	h := NewBufferLogHandler(leveler, opts...)
+	s, err := openFileStorage(path, maxBytes, h.stats.add, h.stats.remove, h.stats.evict)
+	if err != nil {
+		return nil, err
+	}
+	h.buffer = s
+	return h, nil
+}
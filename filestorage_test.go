@@ -0,0 +1,115 @@
+package slogbuffer_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newFileHandler(t *testing.T, path string, maxBytes int64) *slogbuffer.BufferLogHandler {
+	t.Helper()
+	h, err := slogbuffer.NewFileBufferLogHandler(path, maxBytes, slog.LevelDebug)
+	if err != nil {
+		t.Fatalf("creating file handler: %v", err)
+	}
+	t.Cleanup(func() { _ = h.Close() })
+	return h
+}
+
+func TestFileBufferLogHandler(t *testing.T) {
+	// given
+	h := newFileHandler(t, filepath.Join(t.TempDir(), "buffer.ring"), 1<<20)
+	l := slog.New(h)
+
+	l.Info("info msg", "foo", "bar")
+	l.WithGroup("g1").With("common", "attr").Warn("warn msg")
+
+	// when
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandler(t, h, rh)
+	lines := getLines(t, reader)
+
+	// then
+	expectLinesNo(t, lines, 2)
+	expectMsg(t, lines[0], "info msg")
+	expectAttr(t, lines[0], "foo", "bar")
+	expectMsg(t, lines[1], "warn msg")
+	expectAttr(t, lines[1], "g1.common", "attr")
+	if h.Len() != 0 {
+		t.Fatalf("expected empty buffer after flush, got %d records", h.Len())
+	}
+}
+
+func TestFileBufferLogHandler_Eviction(t *testing.T) {
+	// given
+	h := newFileHandler(t, filepath.Join(t.TempDir(), "buffer.ring"), 1024)
+	l := slog.New(h)
+
+	// when
+	// records of different sizes, so they wrap around at different positions
+	for i := range 200 {
+		l.Info("msg", "no", i, "padding", strings.Repeat("x", i%50))
+	}
+
+	// then
+	records := h.Records()
+	if len(records) == 0 || len(records) >= 200 {
+		t.Fatalf("expected oldest records to be evicted, got %d records", len(records))
+	}
+	for i, r := range records {
+		expectRecordAttr(t, r, "no", slog.Int64Value(int64(200-len(records)+i)))
+	}
+}
+
+func TestFileBufferLogHandler_Recover(t *testing.T) {
+	// given
+	path := filepath.Join(t.TempDir(), "buffer.ring")
+	h, err := slogbuffer.NewFileBufferLogHandler(path, 1024, slog.LevelDebug)
+	if err != nil {
+		t.Fatalf("creating file handler: %v", err)
+	}
+	for i := range 50 {
+		slog.New(h).Info(fmt.Sprintf("msg %d", i))
+	}
+	expected := h.Len()
+	if err := h.Close(); err != nil {
+		t.Fatalf("closing handler: %v", err)
+	}
+
+	// when
+	recovered := newFileHandler(t, path, 1<<20)
+
+	// then
+	if recovered.Len() != expected {
+		t.Fatalf("expected %d recovered records, got %d", expected, recovered.Len())
+	}
+	if !recovered.HasLevel(slog.LevelInfo) {
+		t.Fatalf("expected recovered records to be tracked")
+	}
+	tail := recovered.Tail(1)
+	if tail[0].Message != "msg 49" {
+		t.Fatalf("unexpected newest record %q", tail[0].Message)
+	}
+
+	// capacity of existing file is kept, so records keep being evicted
+	for i := range 50 {
+		slog.New(recovered).Info(fmt.Sprintf("msg %d", i+50))
+	}
+	if recovered.Len() > expected+1 {
+		t.Fatalf("expected capacity of existing file to be kept, got %d records", recovered.Len())
+	}
+}
+
+func TestFileBufferLogHandler_RecordTooLarge(t *testing.T) {
+	h := newFileHandler(t, filepath.Join(t.TempDir(), "buffer.ring"), 64)
+
+	err := h.Handle(context.Background(), newRecord(slog.LevelInfo, "msg", "payload", strings.Repeat("x", 100)))
+	if !errors.Is(err, slogbuffer.ErrRecordTooLarge) {
+		t.Fatalf("expected ErrRecordTooLarge, got %v", err)
+	}
+}
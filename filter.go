@@ -0,0 +1,55 @@
+package slogbuffer
+
+import (
+	"log/slog"
+	"regexp"
+	"slices"
+)
+
+// FilterRule decides if record should be dropped instead of being buffered. Groups are names
+// of groups opened on the logger (using [slog.Logger.WithGroup]) that logged the record.
+// Attributes of the logger are folded into attributes of the record, nested in those groups.
+type FilterRule func(groups []string, r slog.Record) bool
+
+// FilterMessage returns rule that drops records with message matching provided regular expression.
+func FilterMessage(pattern *regexp.Regexp) FilterRule {
+	return func(_ []string, r slog.Record) bool {
+		return pattern.MatchString(r.Message)
+	}
+}
+
+// FilterAttr returns rule that drops records with attribute with provided key and value, e.g.
+// FilterAttr("component", "thirdparty"). Only top level attributes are considered (including
+// attributes of the logger added before any group was opened) and value has to be comparable
+// (e.g. string, number or bool).
+func FilterAttr(key string, value any) FilterRule {
+	expected := slog.AnyValue(value)
+	return func(_ []string, r slog.Record) bool {
+		found := false
+		r.Attrs(func(a slog.Attr) bool {
+			found = a.Key == key && a.Value.Resolve().Equal(expected)
+			return !found
+		})
+		return found
+	}
+}
+
+// FilterGroup returns rule that drops records logged using logger whose groups start with
+// provided ones, e.g. FilterGroup("http") drops records of loggers created using
+// WithGroup("http"), including ones with additional groups opened.
+func FilterGroup(groups ...string) FilterRule {
+	return func(recordGroups []string, _ slog.Record) bool {
+		return len(recordGroups) >= len(groups) && slices.Equal(recordGroups[:len(groups)], groups)
+	}
+}
+
+// filtered reports if any of provided rules drops provided record.
+func filtered(rec record, rules []FilterRule) bool {
+	r := rec.materialize()
+	for _, rule := range rules {
+		if rule(rec.groups, r) {
+			return true
+		}
+	}
+	return false
+}
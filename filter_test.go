@@ -0,0 +1,45 @@
+package slogbuffer_test
+
+import (
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"regexp"
+	"testing"
+)
+
+func TestBufferLogHandler_WithFilter(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithFilter(
+		slogbuffer.FilterMessage(regexp.MustCompile(`^heartbeat`)),
+		slogbuffer.FilterAttr("component", "thirdparty"),
+		slogbuffer.FilterGroup("noisy", "lib"),
+	))
+	l := slog.New(h)
+
+	// when
+	l.Info("heartbeat 1")
+	l.With("component", "thirdparty").Info("thirdparty msg")
+	l.Info("own attr msg", "component", "thirdparty")
+	l.WithGroup("noisy").WithGroup("lib").WithGroup("sub").Info("noisy lib msg")
+	l.WithGroup("noisy").Info("noisy msg")
+	l.With("component", "app").Info("app msg")
+
+	// then
+	if h.Len() != 2 {
+		t.Fatalf("expected 2 buffered records, got %d", h.Len())
+	}
+	if h.Dropped() != 0 {
+		t.Fatalf("expected no records reported as dropped, got %d", h.Dropped())
+	}
+
+	// records passed directly to real handler are not filtered
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandler(t, h, rh)
+	l.Info("heartbeat 2")
+
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 3)
+	expectMsg(t, lines[0], "noisy msg")
+	expectMsg(t, lines[1], "app msg")
+	expectMsg(t, lines[2], "heartbeat 2")
+}
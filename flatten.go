@@ -0,0 +1,26 @@
+package slogbuffer
+
+import (
+	"log/slog"
+)
+
+// flattenAttrs returns new slice with members of groups in place of groups, with keys prefixed
+// by provided prefix and keys of groups they belong to, joined using provided separator.
+func flattenAttrs(prefix string, attrs []slog.Attr, separator string) []slog.Attr {
+	res := make([]slog.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		a.Value = a.Value.Resolve()
+		if a.Value.Kind() != slog.KindGroup {
+			a.Key = prefix + a.Key
+			res = append(res, a)
+			continue
+		}
+		memberPrefix := prefix
+		if len(a.Key) > 0 {
+			// members of group with empty key are inlined into enclosing group
+			memberPrefix = prefix + a.Key + separator
+		}
+		res = append(res, flattenAttrs(memberPrefix, a.Value.Group(), separator)...)
+	}
+	return res
+}
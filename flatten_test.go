@@ -0,0 +1,38 @@
+package slogbuffer_test
+
+import (
+	"context"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"testing"
+)
+
+func TestBufferLogHandler_WithFlattenGroups(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithFlattenGroups(""))
+	l := slog.New(h).With("common", "attr").WithGroup("g1").With("a", "1").WithGroup("g2")
+
+	// when
+	l.Info("buffered msg", "key", "value", slog.Group("g3", "b", "2"), slog.Group("", "inline", "3"))
+	rh := &collectingHandler{}
+	if err := h.SetRealHandler(context.Background(), rh); err != nil {
+		t.Fatalf("setting real handler: %v", err)
+	}
+	l.Info("direct msg", "key", "value", slog.Group("g3", "b", "2"), slog.Group("", "inline", "3"))
+
+	// then
+	// real handler ignores groups, so all attributes have to be in the record
+	if len(rh.records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(rh.records))
+	}
+	for _, r := range rh.records {
+		expectRecordAttr(t, r, "common", slog.StringValue("attr"))
+		expectRecordAttr(t, r, "g1.a", slog.StringValue("1"))
+		expectRecordAttr(t, r, "g1.g2.key", slog.StringValue("value"))
+		expectRecordAttr(t, r, "g1.g2.g3.b", slog.StringValue("2"))
+		expectRecordAttr(t, r, "g1.g2.inline", slog.StringValue("3"))
+		if r.NumAttrs() != 5 {
+			t.Fatalf("expected 5 attributes, got %d", r.NumAttrs())
+		}
+	}
+}
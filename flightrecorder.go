@@ -0,0 +1,273 @@
+package slogbuffer
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// FlightRecorderHandler is [slog.Handler] that forwards records to real handler immediately,
+// but also keeps the latest records in memory, so they can be dumped on demand (e.g. on error,
+// on signal or via debug endpoint). This is the inverse of BufferLogHandler, which holds
+// records until real handler is known.
+//
+// Recorder has its own level, independent of level of real handler, so it can keep e.g. debug
+// records that real handler discards, to provide context when something goes wrong.
+type FlightRecorderHandler struct {
+	// leveler is minimal level of records that are kept
+	leveler slog.Leveler
+	// real is handler to which records are forwarded, with attributes and groups
+	// of this handler already applied
+	real slog.Handler
+
+	// buffer holds latest records, it is shared with all derived handlers
+	buffer *buffer[record]
+
+	// attrs and groups are kept with every recorded record, since buffer is shared
+	attrs  []slog.Attr
+	groups []string
+
+	// opts holds optional configuration provided when handler was created.
+	opts *flightRecorderOptions
+	// window tracks records captured after incident was triggered, it is shared with
+	// all derived handlers
+	window *captureWindow
+}
+
+// FlightRecorderOption configures optional behaviour of FlightRecorderHandler.
+type FlightRecorderOption func(*flightRecorderOptions)
+
+// flightRecorderOptions holds optional configuration of FlightRecorderHandler.
+type flightRecorderOptions struct {
+	// triggerLeveler is minimal level of records that trigger incident dump, nil if disabled
+	triggerLeveler slog.Leveler
+	// incident receives dumped records
+	incident slog.Handler
+	// contextRecords is maximum number of preceding records dumped with triggering one
+	contextRecords int
+	// postRecords and postDuration limit window of records dumped after triggering one
+	postRecords  int
+	postDuration time.Duration
+	// clock provides current time to post trigger window
+	clock Clock
+}
+
+// WithIncidentTrigger configures recorder to automatically dump context of every record at or
+// above trigger level to incident handler: up to contextRecords latest recorded records of
+// lower level, followed by the triggering record itself. This way each error comes with its
+// debug context, even though real handler does not log debug records.
+func WithIncidentTrigger(trigger slog.Leveler, incident slog.Handler, contextRecords int) FlightRecorderOption {
+	return func(o *flightRecorderOptions) {
+		o.triggerLeveler = trigger
+		o.incident = incident
+		o.contextRecords = contextRecords
+	}
+}
+
+// WithPostTriggerWindow extends incident dumps (see WithIncidentTrigger) with records that
+// follow the triggering one: up to postRecords records or records within postDuration after
+// the trigger, whichever limit is reached first (zero value means no limit of that kind).
+// This gives pre and post window of records around each incident. Records at or above trigger
+// level within the window only extend it, their context is already part of the incident.
+func WithPostTriggerWindow(postRecords int, postDuration time.Duration) FlightRecorderOption {
+	return func(o *flightRecorderOptions) {
+		o.postRecords = postRecords
+		o.postDuration = postDuration
+	}
+}
+
+// WithFlightRecorderClock sets clock used to limit duration of post trigger window (see
+// WithPostTriggerWindow) instead of system clock, so it can be tested without sleeping.
+func WithFlightRecorderClock(clock Clock) FlightRecorderOption {
+	return func(o *flightRecorderOptions) {
+		if clock != nil {
+			o.clock = clock
+		}
+	}
+}
+
+// captureWindow tracks window of records dumped after incident trigger.
+type captureWindow struct {
+	clock Clock
+	// remaining is number of records that can still be captured, negative if not limited
+	remaining int
+	// until is time until which records are captured, zero if not limited
+	until time.Time
+	open  bool
+	lock  sync.Mutex
+}
+
+// start opens capture window according to provided options. It reports if window was
+// already open.
+func (w *captureWindow) start(o *flightRecorderOptions) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	wasOpen := w.isOpen()
+	w.open = o.postRecords > 0 || o.postDuration > 0
+	w.remaining = -1
+	if o.postRecords > 0 {
+		w.remaining = o.postRecords
+	}
+	w.until = time.Time{}
+	if o.postDuration > 0 {
+		w.until = w.clock.Now().Add(o.postDuration)
+	}
+	return wasOpen
+}
+
+// capture reports if record should be captured, counting it against the window limit.
+func (w *captureWindow) capture() bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if !w.isOpen() {
+		return false
+	}
+	if w.remaining > 0 {
+		w.remaining--
+	}
+	return true
+}
+
+// isOpen reports if window is still open. Caller must hold the lock.
+func (w *captureWindow) isOpen() bool {
+	if !w.open {
+		return false
+	}
+	if w.remaining == 0 || (!w.until.IsZero() && w.clock.Now().After(w.until)) {
+		w.open = false
+	}
+	return w.open
+}
+
+// NewFlightRecorderHandler returns handler that forwards records to provided real handler and
+// keeps the latest maxRecords records at or above provided level.
+func NewFlightRecorderHandler(real slog.Handler, leveler slog.Leveler, maxRecords int, opts ...FlightRecorderOption) *FlightRecorderHandler {
+	o := &flightRecorderOptions{clock: systemClock{}}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &FlightRecorderHandler{
+		leveler: leveler,
+		real:    real,
+		buffer:  newBuffer[record](max(maxRecords, 1)),
+		opts:    o,
+		window:  &captureWindow{clock: o.clock},
+	}
+}
+
+// compile time check that FlightRecorderHandler implements slog.Handler interface.
+var _ slog.Handler = &FlightRecorderHandler{}
+
+func (h *FlightRecorderHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.records(level) || h.real.Enabled(ctx, level)
+}
+
+func (h *FlightRecorderHandler) Handle(ctx context.Context, r slog.Record) error {
+	rec := record{
+		// resolving creates new record, so it is safe to keep it
+		Record: resolveRecord(r),
+		attrs:  h.attrs,
+		groups: h.groups,
+	}
+
+	var handleErr error
+	if h.triggers(r.Level) {
+		if h.window.start(h.opts) {
+			// context of this record was already dumped as part of previous incident
+			handleErr = rec.emit(ctx, h.opts.incident)
+		} else {
+			handleErr = h.dumpIncident(ctx, rec)
+		}
+	} else if h.records(r.Level) && h.window.capture() {
+		handleErr = rec.emit(ctx, h.opts.incident)
+	}
+	if h.records(r.Level) {
+		h.buffer.Add(rec)
+	}
+	if h.real.Enabled(ctx, r.Level) {
+		handleErr = errors.Join(handleErr, h.real.Handle(ctx, r))
+	}
+	return handleErr
+}
+
+func (h *FlightRecorderHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := h.clone()
+	c.real = h.real.WithAttrs(attrs)
+	c.attrs = nest(h.attrs, h.groups, resolveAttrs(attrs))
+	return c
+}
+
+func (h *FlightRecorderHandler) WithGroup(name string) slog.Handler {
+	if len(name) == 0 {
+		return h
+	}
+	c := h.clone()
+	c.real = h.real.WithGroup(name)
+	c.groups = append(slices.Clip(h.groups), name)
+	return c
+}
+
+// Records returns copies of recorded records, oldest first, with attributes and groups
+// of the logger folded into attributes of the record.
+func (h *FlightRecorderHandler) Records() []slog.Record {
+	return materializeAll(h.buffer.Snapshot())
+}
+
+// Dump sends all recorded records to provided handler (e.g. one writing to incident file),
+// oldest first. Recorded records are kept.
+func (h *FlightRecorderHandler) Dump(ctx context.Context, handler slog.Handler) error {
+	var dumpErr error
+	for _, rec := range h.buffer.Snapshot() {
+		dumpErr = errors.Join(dumpErr, rec.emit(ctx, handler))
+	}
+	return dumpErr
+}
+
+// Clear removes all recorded records.
+func (h *FlightRecorderHandler) Clear() {
+	h.buffer.Clear()
+}
+
+// dumpIncident sends context of triggering record, followed by the record itself, to
+// incident handler.
+func (h *FlightRecorderHandler) dumpIncident(ctx context.Context, trigger record) error {
+	var preceding []record
+	for _, rec := range h.buffer.Snapshot() {
+		if !h.triggers(rec.Level) {
+			preceding = append(preceding, rec)
+		}
+	}
+	preceding = preceding[max(len(preceding)-h.opts.contextRecords, 0):]
+
+	var dumpErr error
+	for _, rec := range append(preceding, trigger) {
+		dumpErr = errors.Join(dumpErr, rec.emit(ctx, h.opts.incident))
+	}
+	return dumpErr
+}
+
+// triggers reports if records of provided level trigger incident dump.
+func (h *FlightRecorderHandler) triggers(level slog.Level) bool {
+	return h.opts.triggerLeveler != nil && level >= h.opts.triggerLeveler.Level()
+}
+
+// records reports if records of provided level are kept.
+func (h *FlightRecorderHandler) records(level slog.Level) bool {
+	return level >= h.leveler.Level()
+}
+
+// clone creates a copy of current handler, sharing the buffer.
+func (h *FlightRecorderHandler) clone() *FlightRecorderHandler {
+	return &FlightRecorderHandler{
+		leveler: h.leveler,
+		real:    h.real,
+		buffer:  h.buffer,
+		attrs:   h.attrs,
+		groups:  h.groups,
+		opts:    h.opts,
+		window:  h.window,
+	}
+}
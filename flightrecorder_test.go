@@ -0,0 +1,135 @@
+package slogbuffer_test
+
+import (
+	"context"
+	"github.com/delicb/slogbuffer"
+	"github.com/delicb/slogbuffer/slogbuffertest"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestFlightRecorderHandler(t *testing.T) {
+	// given
+	rh, reader := getSimplifiedTextHandler() // info level
+	h := slogbuffer.NewFlightRecorderHandler(rh, slog.LevelDebug, 3)
+	l := slog.New(h)
+
+	// when
+	l.Debug("first debug msg")
+	l.Debug("second debug msg")
+	l.WithGroup("g1").With("common", "attr").Debug("third debug msg")
+	l.Info("info msg")
+
+	// then
+	// records are forwarded immediately, depending on level of real handler
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 1)
+	expectMsg(t, lines[0], "info msg")
+
+	// latest records are kept, regardless of level of real handler
+	incident, incidentReader := getSimplifiedTextHandler()
+	if err := h.Dump(context.Background(), incident); err != nil {
+		t.Fatalf("dumping records: %v", err)
+	}
+	dumped := getLines(t, incidentReader)
+	expectLinesNo(t, dumped, 3)
+	expectMsg(t, dumped[0], "second debug msg")
+	expectMsg(t, dumped[1], "third debug msg")
+	expectAttr(t, dumped[1], "g1.common", "attr")
+	expectMsg(t, dumped[2], "info msg")
+
+	if len(h.Records()) != 3 {
+		t.Fatalf("expected records to be kept after dump")
+	}
+	h.Clear()
+	if len(h.Records()) != 0 {
+		t.Fatalf("expected no records after clear")
+	}
+}
+
+func TestFlightRecorderHandler_WithIncidentTrigger(t *testing.T) {
+	// given
+	rh, reader := getSimplifiedTextHandler() // info level
+	incident, incidentReader := getSimplifiedTextHandler()
+	h := slogbuffer.NewFlightRecorderHandler(rh, slog.LevelDebug, 10,
+		slogbuffer.WithIncidentTrigger(slog.LevelError, incident, 2),
+	)
+	l := slog.New(h)
+
+	// when
+	l.Debug("first debug msg")
+	l.Error("first error")
+	l.Debug("second debug msg")
+	l.Info("info msg")
+	l.With("common", "attr").Error("second error")
+
+	// then
+	expectLinesNo(t, getLines(t, reader), 3)
+
+	lines := getLines(t, incidentReader)
+	expectLinesNo(t, lines, 5)
+	// first incident
+	expectMsg(t, lines[0], "first debug msg")
+	expectMsg(t, lines[1], "first error")
+	// second incident, context limited to 2 records, without previous error
+	expectMsg(t, lines[2], "second debug msg")
+	expectMsg(t, lines[3], "info msg")
+	expectMsg(t, lines[4], "second error")
+	expectAttr(t, lines[4], "common", "attr")
+}
+
+func TestFlightRecorderHandler_WithPostTriggerWindow(t *testing.T) {
+	// given
+	rh, _ := getSimplifiedTextHandler()
+	incident, incidentReader := getSimplifiedTextHandler()
+	h := slogbuffer.NewFlightRecorderHandler(rh, slog.LevelDebug, 10,
+		slogbuffer.WithIncidentTrigger(slog.LevelError, incident, 1),
+		slogbuffer.WithPostTriggerWindow(2, 0),
+	)
+	l := slog.New(h)
+
+	// when
+	l.Debug("before msg")
+	l.Error("first error")
+	l.Debug("first after msg")
+	l.Error("second error") // extends window
+	l.Debug("second after msg")
+	l.Debug("third after msg")
+	l.Debug("outside of window msg")
+
+	// then
+	lines := getLines(t, incidentReader)
+	expectLinesNo(t, lines, 6)
+	expectMsg(t, lines[0], "before msg")
+	expectMsg(t, lines[1], "first error")
+	expectMsg(t, lines[2], "first after msg")
+	expectMsg(t, lines[3], "second error")
+	expectMsg(t, lines[4], "second after msg")
+	expectMsg(t, lines[5], "third after msg")
+}
+
+func TestFlightRecorderHandler_WithPostTriggerWindow_Duration(t *testing.T) {
+	// given
+	rh, _ := getSimplifiedTextHandler()
+	incident, incidentReader := getSimplifiedTextHandler()
+	clock := slogbuffertest.NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	h := slogbuffer.NewFlightRecorderHandler(rh, slog.LevelDebug, 10,
+		slogbuffer.WithIncidentTrigger(slog.LevelError, incident, 0),
+		slogbuffer.WithPostTriggerWindow(0, 50*time.Millisecond),
+		slogbuffer.WithFlightRecorderClock(clock),
+	)
+	l := slog.New(h)
+
+	// when
+	l.Error("error")
+	l.Debug("within window msg")
+	clock.Advance(100 * time.Millisecond)
+	l.Debug("outside of window msg")
+
+	// then
+	lines := getLines(t, incidentReader)
+	expectLinesNo(t, lines, 2)
+	expectMsg(t, lines[0], "error")
+	expectMsg(t, lines[1], "within window msg")
+}
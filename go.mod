@@ -1,5 +1,3 @@
 module github.com/delicb/slogbuffer
 
 go 1.23.1
-
-require go.uber.org/multierr v1.11.0 // indirect
@@ -0,0 +1,110 @@
+// Package grpcbuffer provides gRPC server interceptors that buffer records of each RPC
+// using [slogbuffer.BufferLogHandler] and flush them to real handler only if RPC failed,
+// mirroring [slogbuffer.Middleware] for gRPC services.
+//
+// It is a separate module, so slogbuffer itself does not depend on gRPC.
+package grpcbuffer
+
+import (
+	"context"
+	"github.com/delicb/slogbuffer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log/slog"
+)
+
+// Option configures optional behaviour of interceptors.
+type Option func(*options)
+
+// options holds optional configuration of interceptors.
+type options struct {
+	// shouldFlush reports if records of RPC that finished with provided code should be flushed
+	shouldFlush func(code codes.Code) bool
+	// maxRecords is maximum number of records buffered per RPC, 0 if unbound
+	maxRecords int
+}
+
+// newOptions returns options with all provided Option values applied.
+func newOptions(opts []Option) *options {
+	o := &options{
+		shouldFlush: func(code codes.Code) bool { return code != codes.OK },
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithFlushCodes makes interceptors flush records only of RPCs that finished with one of
+// provided status codes. By default, records of all RPCs with non-OK status are flushed.
+func WithFlushCodes(flushCodes ...codes.Code) Option {
+	return func(o *options) {
+		o.shouldFlush = func(code codes.Code) bool {
+			for _, c := range flushCodes {
+				if c == code {
+					return true
+				}
+			}
+			return false
+		}
+	}
+}
+
+// WithBufferSize limits number of records buffered per RPC. Default is unbound.
+func WithBufferSize(maxRecords int) Option {
+	return func(o *options) {
+		o.maxRecords = maxRecords
+	}
+}
+
+// UnaryServerInterceptor returns interceptor that buffers records of each unary RPC and
+// flushes them to real handler only if RPC returned non-OK status (or handler panicked).
+// Buffer handler of the RPC is stored in context (see [slogbuffer.NewContext]), so RPC
+// handlers can use [slogbuffer.LoggerFromContext] to log records to it.
+func UnaryServerInterceptor(real slog.Handler, leveler slog.Leveler, opts ...Option) grpc.UnaryServerInterceptor {
+	o := newOptions(opts)
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		h := slogbuffer.NewBoundBufferLogHandler(leveler, o.maxRecords)
+		defer finish(ctx, h, real, o, &err)
+		return handler(slogbuffer.NewContext(ctx, h), req)
+	}
+}
+
+// StreamServerInterceptor returns interceptor that buffers records of each streaming RPC
+// and flushes them to real handler only if RPC returned non-OK status (or handler panicked).
+// Buffer handler is available from context of the stream, like with UnaryServerInterceptor.
+func StreamServerInterceptor(real slog.Handler, leveler slog.Leveler, opts ...Option) grpc.StreamServerInterceptor {
+	o := newOptions(opts)
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		h := slogbuffer.NewBoundBufferLogHandler(leveler, o.maxRecords)
+		defer finish(ss.Context(), h, real, o, &err)
+		return handler(srv, &serverStream{ServerStream: ss, ctx: slogbuffer.NewContext(ss.Context(), h)})
+	}
+}
+
+// finish flushes or discards records of finished RPC. It must be deferred, so panics of
+// RPC handlers are detected and propagated.
+func finish(ctx context.Context, h *slogbuffer.BufferLogHandler, real slog.Handler, o *options, err *error) {
+	p := recover()
+	if p != nil || o.shouldFlush(status.Code(*err)) {
+		// records that real handler fails to handle are kept as dead letters,
+		// RPC error is more relevant to the caller than flush error
+		_ = h.SetRealHandler(ctx, real)
+	} else {
+		h.Discard()
+	}
+	if p != nil {
+		panic(p)
+	}
+}
+
+// serverStream is [grpc.ServerStream] with context that carries buffer handler.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,84 @@
+package grpcbuffer_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/delicb/slogbuffer"
+	"github.com/delicb/slogbuffer/grpcbuffer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func getTextHandler() (slog.Handler, *bytes.Buffer) {
+	writer := new(bytes.Buffer)
+	return slog.NewTextHandler(writer, nil), writer
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		opts    []grpcbuffer.Option
+		err     error
+		flushed bool
+	}{
+		{name: "ok", err: nil, flushed: false},
+		{name: "failed", err: status.Error(codes.Internal, "failed"), flushed: true},
+		{name: "failed not configured code", opts: []grpcbuffer.Option{grpcbuffer.WithFlushCodes(codes.Internal)},
+			err: status.Error(codes.NotFound, "not found"), flushed: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// given
+			rh, out := getTextHandler()
+			interceptor := grpcbuffer.UnaryServerInterceptor(rh, slog.LevelDebug, tc.opts...)
+
+			// when
+			_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{},
+				func(ctx context.Context, req any) (any, error) {
+					slogbuffer.LoggerFromContext(ctx).Debug("handling rpc")
+					return nil, tc.err
+				})
+
+			// then
+			if err != tc.err {
+				t.Fatalf("expected error of handler, got: %v", err)
+			}
+			if strings.Contains(out.String(), "handling rpc") != tc.flushed {
+				t.Fatalf("expected records flushed: %v, got output: %q", tc.flushed, out.String())
+			}
+		})
+	}
+}
+
+// testStream is minimal [grpc.ServerStream] used in tests.
+type testStream struct {
+	grpc.ServerStream
+}
+
+func (s testStream) Context() context.Context {
+	return context.Background()
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	// given
+	rh, out := getTextHandler()
+	interceptor := grpcbuffer.StreamServerInterceptor(rh, slog.LevelDebug)
+
+	// when
+	err := interceptor(nil, testStream{}, &grpc.StreamServerInfo{},
+		func(srv any, stream grpc.ServerStream) error {
+			slogbuffer.LoggerFromContext(stream.Context()).Debug("handling stream")
+			return status.Error(codes.Unavailable, "unavailable")
+		})
+
+	// then
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected error of handler, got: %v", err)
+	}
+	if !strings.Contains(out.String(), "handling stream") {
+		t.Fatalf("expected records of failed stream to be flushed, got: %q", out.String())
+	}
+}
@@ -14,9 +14,13 @@ package slogbuffer
 
 import (
 	"context"
-	"go.uber.org/multierr"
+	"errors"
+	"io"
 	"log/slog"
 	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // BufferLogHandler is [pkg/log/slog.Handler] that buffers records in memory until real log handler
@@ -25,39 +29,143 @@ import (
 type BufferLogHandler struct {
 	// leveler is minimal level that this handler will consider storing
 	leveler slog.Leveler
-	// real is handler to which all calls will be sent to and where memory buffer of records.
-	// will be drained, when provided
-	real slog.Handler
+	// mode holds real handler (set using SetRealHandler) and pause state. It is replaced
+	// atomically, so logging calls read it without locking. Only value on root handler is
+	// relevant, derived handlers use value of their root.
+	mode atomic.Pointer[handlerMode]
 
 	// buffer is place where records are stored.
-	buffer *buffer[record]
+	buffer storage
+	// deadLetters holds records that real handler failed to handle, either during
+	// flush or after it was set, so delivery can be re-attempted later.
+	deadLetters *buffer[record]
+	// stats tracks levels and size of buffered records.
+	stats *bufferStats
+	// sampler decides which records are buffered, nil if sampling is disabled.
+	sampler *sampler
 
 	// attrs serve as part of implementation of [slog.Handler.WithAttrs].
+	// attrs and groups are never modified, only replaced when handler is derived, so they
+	// are shared (without copying) with derived handlers and buffered records.
 	attrs []slog.Attr
 	// groups serve as part of implementation of [slog.Handler.WithGroup],
 	groups []string
 
-	// parent is reference to handler from which this logger was created
-	parent *BufferLogHandler
+	// origin is reference to root handler, from which this handler was (directly or
+	// indirectly) derived, nil for root handler itself. Shared state (e.g. mode) is kept
+	// on root handler, so it is reachable in constant time regardless of derivation depth.
+	origin *BufferLogHandler
+
+	// composed caches real handler with attributes and groups of this handler applied, so
+	// it is not composed for every record. It is valid only for mode it was composed in.
+	composed atomic.Pointer[composedHandler]
+
+	// recovering is set while background goroutine re-attempts delivery to failing real
+	// handler (see WithAutoRebuffer). Only value on root handler is relevant.
+	recovering atomic.Bool
+	// closed is closed by Close, which stops background recovery (see WithAutoRebuffer).
+	// It is set only on root handler.
+	closed    chan struct{}
+	closeOnce sync.Once
+	// breaker opens circuit around real handler when too many records fail, nil if
+	// circuit breaker is disabled. It is set only on root handler.
+	breaker *circuitBreaker
+	// raisedLevel is level below which records are ignored, set using RaiseLevel, nil if
+	// level was not raised. Only value on root handler is relevant.
+	raisedLevel atomic.Pointer[slog.Level]
+	// aboveWatermark is set once high watermark callback was called and cleared once buffer
+	// is below watermark again (see WithHighWatermark). Only value on root handler is relevant.
+	aboveWatermark atomic.Bool
+
+	// opts holds optional configuration provided when handler was created.
+	opts *options
+}
+
+// handlerMode is immutable description of mode in which handler operates.
+type handlerMode struct {
+	// real is handler to which all calls will be sent to and where memory buffer of records
+	// will be drained, nil until real handler is provided
+	real slog.Handler
+	// paused is set when handler buffers records even though real handler is set
+	paused bool
+}
+
+// composedHandler is real handler of some mode with attributes and groups applied.
+type composedHandler struct {
+	mode    *handlerMode
+	handler slog.Handler
 }
 
+// bufferingMode is mode of handler before real handler is set.
+var bufferingMode = &handlerMode{}
+
 // NewBufferLogHandler returns unbound instance of log handler that stores log records
 // until such time when SetRealHandler is called, at which point messages get flushed
 // and all subsequent calls are just proxy calls to real handler.
-func NewBufferLogHandler(leveler slog.Leveler) *BufferLogHandler {
-	return NewBoundBufferLogHandler(leveler, 0)
+func NewBufferLogHandler(leveler slog.Leveler, opts ...Option) *BufferLogHandler {
+	return NewBoundBufferLogHandler(leveler, 0, opts...)
 }
 
 // NewBoundBufferLogHandler creates instance of log handler that stores log records with
 // upper limit on number of records, thus providing some level of memory consumption control.
-func NewBoundBufferLogHandler(leveler slog.Leveler, maxRecords int) *BufferLogHandler {
-	return &BufferLogHandler{
-		leveler: leveler,
-		real:    nil,
-		buffer:  newBuffer[record](maxRecords),
-		attrs:   nil,
-		groups:  nil,
+func NewBoundBufferLogHandler(leveler slog.Leveler, maxRecords int, opts ...Option) *BufferLogHandler {
+	o := newOptions(opts)
+	stats := newBufferStats(o)
+
+	var store storage
+	if o.compress {
+		compressed := newCompressedStorage(maxRecords, o.compressionBlockSize)
+		compressed.onAdd = stats.add
+		compressed.onRemove = stats.remove
+		compressed.onEvict = stats.evict
+		store = compressed
+	} else if len(o.levelCapacities) > 0 || len(o.pin) > 0 {
+		capacities := o.levelCapacities
+		if len(capacities) == 0 {
+			// single band holding records of all levels
+			capacities = map[slog.Level]int{slog.LevelDebug: maxRecords}
+		}
+		store = newLeveledStorage(capacities, o.pin, stats.add, stats.remove, stats.evict)
+	} else if o.shards > 1 {
+		store = newShardedStorage(maxRecords, o.shards, stats.add, stats.remove, stats.evict)
+	} else if o.chunkSize > 0 {
+		chunked := newChunkedStorage(maxRecords, o.chunkSize)
+		chunked.onAdd = stats.add
+		chunked.onRemove = stats.remove
+		chunked.onEvict = stats.evict
+		store = chunked
+	} else {
+		buf := newBuffer[record](maxRecords)
+		buf.onAdd = stats.add
+		buf.onRemove = stats.remove
+		buf.onEvict = stats.evict
+		store = memoryStorage{buf}
+	}
+	deadLetters := newBuffer[record](maxRecords)
+	deadLetters.onEvict = func(r record) {
+		// eviction happens while adding record, context of the call is not available
+		stats.drop(context.Background(), r)
+	}
+
+	h := &BufferLogHandler{
+		leveler:     leveler,
+		buffer:      store,
+		deadLetters: deadLetters,
+		stats:       stats,
+		sampler:     newSampler(o.samplingRates),
+		breaker:     o.newCircuitBreaker(),
+		closed:      make(chan struct{}),
+		attrs:       nil,
+		groups:      nil,
+		opts:        o,
+	}
+	if o.expvarName != "" {
+		publishExpvar(o.expvarName, h)
 	}
+	if o.watchdogAfter > 0 {
+		h.startWatchdog(o.watchdogAfter)
+	}
+	return h
 }
 
 // Implementation of slog.Handler interface.
@@ -66,48 +174,111 @@ func NewBoundBufferLogHandler(leveler slog.Leveler, maxRecords int) *BufferLogHa
 var _ slog.Handler = &BufferLogHandler{}
 
 func (h *BufferLogHandler) Enabled(ctx context.Context, level slog.Level) bool {
-	rHandler := h.getRealHandler()
-	if rHandler == nil {
-		return level >= h.leveler.Level()
+	if h.belowRaisedLevel(level) {
+		return false
+	}
+	mode := h.root().loadMode()
+	if mode.real == nil {
+		return level >= h.leveler.Level() || h.isEmergency(ctx, level)
 	}
-	return rHandler.Enabled(ctx, level)
+	return h.composedHandler(mode).Enabled(ctx, level)
 }
 
 func (h *BufferLogHandler) Handle(ctx context.Context, r slog.Record) error {
-	rHandler := h.getRealHandler()
-	if rHandler != nil {
-		rh := rHandler
-		for _, group := range h.groups {
-			rh = rh.WithGroup(group)
+	// Handle might be called without checking Enabled first, so level set by RaiseLevel
+	// is enforced here as well
+	if h.belowRaisedLevel(r.Level) {
+		return nil
+	}
+	// mode is loaded once, so record is handled consistently even if it changes concurrently
+	mode := h.root().loadMode()
+	if extractors := h.getOptions().contextAttrs; len(extractors) > 0 {
+		r = addContextAttrs(ctx, r, extractors)
+	}
+	if middleware := h.getOptions().middleware; len(middleware) > 0 {
+		var keep bool
+		if r, keep = applyMiddleware(ctx, r, middleware); !keep {
+			return nil
 		}
-		if h.attrs != nil {
-			rh = rh.WithAttrs(h.attrs)
+	}
+	if mode.real != nil && !mode.paused {
+		rh := h.composedHandler(mode)
+		err := rh.Handle(ctx, r)
+		h.root().handled(err != nil)
+		if err != nil {
+			if rules := h.getOptions().redaction; len(rules) > 0 {
+				// redacting creates new record, so there is no need to clone it
+				r = redactRecord(r, h.groups, rules)
+			} else {
+				r = r.Clone()
+			}
+			h.deadLetters.Add(record{Record: r, attrs: h.attrs, groups: h.groups})
+			if policy := h.getOptions().rebuffer; policy != nil {
+				h.root().rebuffer(*policy)
+			}
+		}
+		return err
+	}
+	if h.isEmergency(ctx, r.Level) {
+		return record{Record: r, attrs: h.attrs, groups: h.groups}.emit(ctx, h.getOptions().emergency)
+	}
+	if rules := h.getOptions().filters; len(rules) > 0 && filtered(record{Record: r, attrs: h.attrs, groups: h.groups}, rules) {
+		return nil
+	}
+	if !h.sampler.keep(r.Level) {
+		h.stats.drop(ctx, record{Record: r, attrs: h.attrs, groups: h.groups})
+		return nil
+	}
+	if h.getOptions().resolveValues {
+		// resolving creates new record, so there is no need to clone it
+		r = resolveRecord(r)
+	} else {
+		// records must not be retained without cloning, caller is free to reuse it
+		r = r.Clone()
+	}
+	if o := h.getOptions(); o.stackLeveler != nil && r.Level >= o.stackLeveler.Level() {
+		r.AddAttrs(slog.String(o.stackKey, callerStack()))
+	}
+	if rules := h.getOptions().redaction; len(rules) > 0 {
+		r = redactRecord(r, h.groups, rules)
+	}
+	if maxBytes := h.getOptions().maxValueSize; maxBytes > 0 {
+		r = truncateRecord(r, maxBytes)
+	}
+	rec := record{Record: r, attrs: h.attrs, groups: h.groups}
+	addErr := h.buffer.Add(rec)
+	if addErr == nil {
+		h.enforceBounds()
+		h.getOptions().buffered(ctx, rec)
+		h.checkWatermark(ctx)
+		if o := h.getOptions(); mode.real == nil && o.promote != nil && r.Level >= o.promoteLeveler.Level() {
+			// record that triggered promotion is flushed together with the rest of the buffer
+			if err := h.SetRealHandler(ctx, o.promote); !errors.Is(err, ErrAlreadyBound) {
+				return err
+			}
 		}
-		return rh.Handle(ctx, r)
 	}
-	h.buffer.Add(record{
-		Record: r,
-		attrs:  h.attrs,
-		groups: h.groups,
-	})
 
-	return nil
+	// if handler switched to wrapper mode while record was being added, record might have
+	// missed the flush, so it is flushed here, to make sure it does not stay in the buffer
+	if current := h.root().loadMode(); current != mode && current.real != nil && !current.paused {
+		return errors.Join(addErr, h.flush(ctx, current.real, h.takeRecords(), &FlushReport{}))
+	}
+	return addErr
 }
 
 func (h *BufferLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	rHandler := h.getRealHandler()
-	if rHandler != nil {
-		return &BufferLogHandler{
-			real: rHandler.WithAttrs(attrs),
-		}
+	if len(attrs) == 0 {
+		return h
 	}
-
-	c := h.clone()
-	if c.attrs != nil {
-		c.attrs = append(c.attrs, attrs...)
-	} else {
-		c.attrs = attrs
+	if h.getOptions().resolveValues {
+		attrs = resolveAttrs(attrs)
 	}
+	if rules := h.getOptions().redaction; len(rules) > 0 {
+		attrs = replaceAttrs(h.groups, attrs, redact(rules))
+	}
+	c := h.clone()
+	c.attrs = nest(h.attrs, h.groups, attrs)
 	return c
 }
 
@@ -116,24 +287,13 @@ func (h *BufferLogHandler) WithGroup(name string) slog.Handler {
 		return h
 	}
 
-	rHandler := h.getRealHandler()
-
-	if rHandler != nil {
-		return &BufferLogHandler{
-			real: rHandler.WithGroup(name),
-		}
-	}
-
 	child := h.clone()
-	if len(h.groups) > 0 {
-		child.groups = append(child.groups, name)
-	} else {
-		child.groups = []string{name}
-	}
+	child.groups = append(slices.Clip(h.groups), name)
 	return child
 }
 
-// Discard removers all stored records.
+// Discard removers all stored records. To remove only some of them, see DiscardIf,
+// DiscardOlderThan and DiscardBelow.
 func (h *BufferLogHandler) Discard() {
 	h.buffer.Clear()
 }
@@ -143,50 +303,411 @@ func (h *BufferLogHandler) Discard() {
 // Also, from this point on, current handler behaves as simple wrapper and all
 // handling is passed to real handler (thus this instance is still usable,
 // in case reference to it is held somewhere).
+// Records that real handler fails to handle are sent to failover handler, if one
+// is configured, otherwise they are kept, so delivery can be re-attempted using RetryFlush.
+// Such failures are reported as FlushError. Real handler can be set only once, ErrAlreadyBound
+// is returned (and nothing is changed) if it is already set.
 func (h *BufferLogHandler) SetRealHandler(ctx context.Context, real slog.Handler) error {
-	var flushErr error
-	for rec := range h.buffer.Values() {
-		handler := real
-		for _, g := range rec.groups {
-			handler = handler.WithGroup(g)
+	_, err := h.SetRealHandlerWithReport(ctx, real)
+	return err
+}
+
+// SetRealHandlerWithReport works like SetRealHandler, but also returns report about flushed
+// records, so application can log or expose summary of the handoff.
+func (h *BufferLogHandler) SetRealHandlerWithReport(ctx context.Context, real slog.Handler) (FlushReport, error) {
+	if h.getRealHandler() != nil {
+		return FlushReport{}, ErrAlreadyBound
+	}
+	real = h.wrapReal(real)
+	return h.handoff(ctx, real, func(paused bool) {
+		h.root().mode.Store(&handlerMode{real: real, paused: paused})
+	})
+}
+
+// SetRealHandlers works like SetRealHandler, but buffered records and all subsequent
+// records are delivered to all provided handlers (e.g. stdout, file and network handlers),
+// combined using MultiHandler. Errors of all handlers are combined into returned error.
+func (h *BufferLogHandler) SetRealHandlers(ctx context.Context, handlers ...slog.Handler) error {
+	return h.SetRealHandler(ctx, NewMultiHandler(handlers...))
+}
+
+// FlushMerged sets provided real handler on all provided handlers (like SetRealHandler), but
+// records buffered by all of them are interleaved by their time and flushed as a single
+// chronologically ordered stream. This is useful when each subsystem of application buffered
+// records separately. Errors of all handlers are combined into returned error. Nothing is
+// flushed if real handler of any of provided handlers is already set (ErrAlreadyBound).
+func FlushMerged(ctx context.Context, real slog.Handler, handlers ...*BufferLogHandler) error {
+	type ownedRecord struct {
+		record
+		owner *BufferLogHandler
+		real  slog.Handler
+	}
+	for _, h := range handlers {
+		if h.getRealHandler() != nil {
+			return ErrAlreadyBound
 		}
-		if len(rec.attrs) > 0 {
-			handler = handler.WithAttrs(rec.attrs)
+		if err := h.checkHealth(ctx); err != nil {
+			return err
 		}
-		multierr.AppendFunc(&flushErr, func() error { return handler.Handle(ctx, rec.Record) })
 	}
 
-	// we don't need storage anymore, let GC collect it
-	h.buffer.Clear()
+	var records []ownedRecord
+	for _, h := range handlers {
+		wrapped := h.wrapReal(real)
+		for _, rec := range h.takeRecords() {
+			records = append(records, ownedRecord{record: rec, owner: h, real: wrapped})
+		}
+	}
+	slices.SortStableFunc(records, func(a, b ownedRecord) int { return a.Time.Compare(b.Time) })
+
+	var handlerErr error
+	failed := 0
+	for _, rec := range records {
+		if err := rec.owner.replayed(rec.record, rec.owner.now()).emit(ctx, rec.real); err != nil {
+			handlerErr = errors.Join(handlerErr, err)
+			failed++
+			rec.owner.handleFailed(ctx, rec.record)
+		}
+	}
+	flushErr := newFlushError(failed, handlerErr)
+
+	// switches handlers to wrapper mode, flushing records logged in the meantime
+	for _, h := range handlers {
+		flushErr = errors.Join(flushErr, h.SetRealHandler(ctx, real))
+	}
+	return flushErr
+}
+
+// Pause makes handler buffer records again, even though real handler is set, until
+// Resume is called. It affects all handlers derived from the same root handler.
+// Pause has no effect if real handler is not set yet.
+func (h *BufferLogHandler) Pause() {
+	h.root().setPaused(true)
+}
+
+// Resume flushes records buffered since Pause was called to real handler and switches
+// handler back to wrapper mode.
+func (h *BufferLogHandler) Resume(ctx context.Context) error {
+	_, err := h.ResumeWithReport(ctx)
+	return err
+}
 
-	// switch to wrapper mode
-	h.real = real
+// ResumeWithReport works like Resume, but also returns report about flushed records.
+func (h *BufferLogHandler) ResumeWithReport(ctx context.Context) (FlushReport, error) {
+	root := h.root()
+	real := root.loadMode().real
+	if real == nil {
+		return FlushReport{}, ErrNoRealHandler
+	}
+	return h.handoff(ctx, real, func(paused bool) {
+		if !paused {
+			root.setPaused(false)
+		}
+	})
+}
+
+// Close stops background recovery of real handler (see WithAutoRebuffer and
+// WithCircuitBreaker) and pending timers (e.g. of WithWatchdog) and releases
+// resources held by storage of the handler, e.g. file of handler created using
+// NewFileBufferLogHandler. Handler must not be used after it is closed.
+func (h *BufferLogHandler) Close() error {
+	root := h.root()
+	root.closeOnce.Do(func() {
+		if root.closed != nil {
+			close(root.closed)
+		}
+	})
+	if closer, ok := h.buffer.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// handoff flushes buffered records to real handler and calls provided function to
+// switch handler to wrapper mode. Nothing is done if health check fails. If flush stopped
+// at failed record (see WithStopOnError), handler is switched to paused mode instead.
+func (h *BufferLogHandler) handoff(ctx context.Context, real slog.Handler, switchMode func(paused bool)) (FlushReport, error) {
+	// handoff is aborted before anything is flushed, so records are not partially
+	// delivered to sink that is not ready
+	if err := h.checkHealth(ctx); err != nil {
+		return FlushReport{}, err
+	}
+
+	var report FlushReport
+	start := h.now()
+
+	// records are taken out of the buffer and emitted without holding the buffer lock,
+	// so real handler (or attribute values it resolves) is free to log using this handler
+	flushErr := h.flush(ctx, real, h.takeRecords(), &report)
+
+	if h.getOptions().stopOnError && report.Failed > 0 {
+		// records that were not delivered are kept for RetryFlush, so records logged
+		// from now on are buffered behind them, instead of overtaking them
+		switchMode(true)
+		report.Duration = h.now().Sub(start)
+		return report, flushErr
+	}
+	switchMode(false)
+	h.getOptions().handedOff(real)
+
+	// records logged while flush was in progress (e.g. by real handler itself)
+	// ended up in the buffer, so they have to be flushed as well
+	flushErr = errors.Join(flushErr, h.flush(ctx, real, h.takeRecords(), &report))
+	report.Duration = h.now().Sub(start)
+	return report, flushErr
+}
+
+// flush emits provided records to real handler, in order (or sorted by time, if configured),
+// adding them to provided report. Records are emitted in batches and progress is reported
+// after each one, if configured.
+func (h *BufferLogHandler) flush(ctx context.Context, real slog.Handler, records []record, report *FlushReport) error {
+	o := h.getOptions()
+	if o.sortByTime {
+		slices.SortStableFunc(records, func(a, b record) int { return a.Time.Compare(b.Time) })
+	}
+	batchSize := o.flushBatchSize
+	if batchSize <= 0 {
+		batchSize = len(records)
+	}
+
+	if len(records) == 0 {
+		return nil
+	}
+
+	var flushErr, handlerErr error
+	emitted, failed := 0, 0
+	now := h.now()
+	o.flushStarted(len(records))
+	defer func() {
+		o.metrics.Flushed(ctx, emitted, failed, h.now().Sub(now))
+		o.flushEnded(flushErr)
+	}()
+	for start := 0; start < len(records); start += batchSize {
+		end := min(start+batchSize, len(records))
+		batch, err := h.emitParallel(ctx, real, records[start:end], now)
+		handlerErr = errors.Join(handlerErr, err)
+		report.add(batch)
+		emitted, failed = end, failed+batch.Failed
+
+		if o.stopOnError && batch.Failed > 0 {
+			for _, rec := range records[end:] {
+				h.deadLetters.Add(rec)
+			}
+			flushErr = errors.Join(newFlushError(failed, handlerErr), ErrFlushAborted)
+			return flushErr
+		}
+
+		progress := FlushProgress{Emitted: end, Remaining: len(records) - end, Failed: failed}
+		proceed := o.flushProgress == nil || o.flushProgress(progress)
+		if progress.Remaining > 0 && (!proceed || ctx.Err() != nil) {
+			// remaining records are kept for retry, the same way as records that failed
+			for _, rec := range records[end:] {
+				h.deadLetters.Add(rec)
+			}
+			flushErr = errors.Join(newFlushError(failed, handlerErr), ErrFlushAborted)
+			return flushErr
+		}
+	}
+	flushErr = newFlushError(failed, handlerErr)
 	return flushErr
 }
 
+// emitParallel splits provided batch of records between configured number of workers, which
+// emit their parts concurrently. It returns report about emitted records (without duration).
+func (h *BufferLogHandler) emitParallel(ctx context.Context, real slog.Handler, batch []record, now time.Time) (FlushReport, error) {
+	workers := min(h.getOptions().flushWorkers, len(batch))
+	if workers <= 1 {
+		return h.emitBatch(ctx, real, batch, now)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		lock     sync.Mutex
+		report   FlushReport
+		batchErr error
+	)
+	partSize := (len(batch) + workers - 1) / workers
+	for part := range slices.Chunk(batch, partSize) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			partReport, err := h.emitBatch(ctx, real, part, now)
+			lock.Lock()
+			defer lock.Unlock()
+			report.add(partReport)
+			batchErr = errors.Join(batchErr, err)
+		}()
+	}
+	wg.Wait()
+	return report, batchErr
+}
+
+// emitBatch emits provided batch of records to real handler. If real handler implements
+// BatchHandler, records are delivered using single HandleBatch call, otherwise one by one.
+// Records real handler is not enabled for are skipped, if configured (see WithFlushLevelCheck).
+// If configured (see WithStopOnError), emitting stops at the first failed record, which is
+// kept together with the rest of the batch for RetryFlush. It returns report about emitted
+// records (without duration).
+func (h *BufferLogHandler) emitBatch(ctx context.Context, real slog.Handler, batch []record, now time.Time) (FlushReport, error) {
+	var report FlushReport
+	o := h.getOptions()
+	if o.flushLevelCheck {
+		enabled := make([]record, 0, len(batch))
+		for _, rec := range batch {
+			if real.Enabled(ctx, rec.Level) {
+				enabled = append(enabled, rec)
+			}
+		}
+		report.Skipped = len(batch) - len(enabled)
+		batch = enabled
+	}
+	if bh, ok := real.(BatchHandler); ok {
+		records := make([]slog.Record, 0, len(batch))
+		for _, rec := range batch {
+			records = append(records, h.replayed(rec, now).materialize())
+			report.Bytes += recordSize(rec)
+		}
+		report.Emitted = len(batch)
+		if err := bh.HandleBatch(ctx, records); err != nil {
+			for _, rec := range batch {
+				if o.stopOnError {
+					h.deadLetters.Add(rec)
+				} else {
+					h.handleFailed(ctx, rec)
+				}
+			}
+			report.Failed = len(batch)
+			return report, err
+		}
+		return report, nil
+	}
+
+	var batchErr error
+	for i, rec := range batch {
+		report.Emitted++
+		report.Bytes += recordSize(rec)
+		if err := h.replayed(rec, now).emit(ctx, real); err != nil {
+			batchErr = errors.Join(batchErr, err)
+			report.Failed++
+			if o.stopOnError {
+				for _, rest := range batch[i:] {
+					h.deadLetters.Add(rest)
+				}
+				return report, batchErr
+			}
+			h.handleFailed(ctx, rec)
+		}
+	}
+	return report, batchErr
+}
+
+// handleFailed takes care of record that real handler failed to handle. Record is sent
+// to failover handler, if configured, and kept for later retry if that fails as well.
+func (h *BufferLogHandler) handleFailed(ctx context.Context, rec record) {
+	if failover := h.getOptions().failover; failover != nil && rec.emit(ctx, failover) == nil {
+		return
+	}
+	h.deadLetters.Add(rec)
+}
+
+// wrapReal applies de-duplication, group flattening, attribute replacement, correlation attributes and correlation group
+// to provided real handler, according to options.
+func (h *BufferLogHandler) wrapReal(real slog.Handler) slog.Handler {
+	o := h.getOptions()
+	if rewrite := o.attrsRewrite(); rewrite != nil {
+		real = &rewriteHandler{handler: real, rewrite: rewrite}
+	}
+	if o.replaceAttr != nil {
+		real = &replaceAttrHandler{handler: real, replace: o.replaceAttr}
+	}
+	if len(o.correlationAttrs) > 0 {
+		real = real.WithAttrs(o.correlationAttrs)
+	}
+	if len(o.correlationGroup) > 0 {
+		real = real.WithGroup(o.correlationGroup)
+	}
+	return real
+}
+
+// isEmergency reports if record of provided level should bypass the buffer and go
+// to emergency handler.
+func (h *BufferLogHandler) isEmergency(ctx context.Context, level slog.Level) bool {
+	o := h.getOptions()
+	return o.emergency != nil && level >= o.emergencyLeveler.Level() && o.emergency.Enabled(ctx, level)
+}
+
 // clone creates a copy of current handler.
 // buffer is reused and all other relevant fields are copied.
 func (h *BufferLogHandler) clone() *BufferLogHandler {
 	// maxRecords is not copied since it is irrelevant, it is only used
 	// to create buffer, and buffer is shared, so we don't need it anymore.
 	return &BufferLogHandler{
-		leveler: h.leveler,
-		real:    h.real,
-		buffer:  h.buffer,
-		attrs:   slices.Clone(h.attrs),
-		groups:  slices.Clone(h.groups),
-		parent:  h,
+		leveler:     h.leveler,
+		buffer:      h.buffer,
+		deadLetters: h.deadLetters,
+		stats:       h.stats,
+		sampler:     h.sampler,
+		attrs:       h.attrs,
+		groups:      h.groups,
+		origin:      h.root(),
+		opts:        h.opts,
+	}
+}
+
+// loadMode returns current mode of the handler. It should be called on root handler.
+func (h *BufferLogHandler) loadMode() *handlerMode {
+	if mode := h.mode.Load(); mode != nil {
+		return mode
+	}
+	return bufferingMode
+}
+
+// setPaused changes pause state of the handler, if real handler is set. It should be
+// called on root handler.
+func (h *BufferLogHandler) setPaused(paused bool) {
+	for {
+		mode := h.loadMode()
+		if mode.real == nil || mode.paused == paused {
+			return
+		}
+		if h.mode.CompareAndSwap(mode, &handlerMode{real: mode.real, paused: paused}) {
+			return
+		}
 	}
 }
 
-// getRealHandler returns instance of real handler either from current instance or
-// from parent instance (recursively).
+// composedHandler returns real handler of this handler in provided mode, with attributes
+// and groups of this handler applied. Result is cached until mode changes.
+func (h *BufferLogHandler) composedHandler(mode *handlerMode) slog.Handler {
+	if len(h.attrs) == 0 && len(h.groups) == 0 {
+		return mode.real
+	}
+	if c := h.composed.Load(); c != nil && c.mode == mode {
+		return c.handler
+	}
+	rh := compose(mode.real, h.groups, h.attrs)
+	h.composed.Store(&composedHandler{mode: mode, handler: rh})
+	return rh
+}
+
+// getRealHandler returns real handler of this handler, nil if it is not set.
 func (h *BufferLogHandler) getRealHandler() slog.Handler {
-	if h.real != nil {
-		return h.real
+	return h.root().loadMode().real
+}
+
+// root returns handler from which this handler was (directly or indirectly) derived.
+func (h *BufferLogHandler) root() *BufferLogHandler {
+	if h.origin != nil {
+		return h.origin
 	}
-	if h.parent != nil {
-		return h.parent.getRealHandler()
+	return h
+}
+
+// getOptions returns options of this handler, falling back to defaults for handlers
+// that were not created using constructor functions.
+func (h *BufferLogHandler) getOptions() *options {
+	if h.opts == nil {
+		return defaultOptions
 	}
-	return nil
+	return h.opts
 }
@@ -1,10 +1,17 @@
 package slogbuffer_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/delicb/slogbuffer"
+	"io"
 	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestBufferLogHandler_Handle_Level(t *testing.T) {
@@ -213,3 +220,471 @@ func TestBufferLogHandler_AfterSetRealHandler(t *testing.T) {
 	expectMsg(t, lines[0], "info msg")
 	expectAttr(t, lines[0], "common", "attr")
 }
+
+// attrAddingHandler adds attribute to every record before passing it to wrapped handler.
+type attrAddingHandler struct {
+	slog.Handler
+	attr slog.Attr
+}
+
+func (h attrAddingHandler) Handle(ctx context.Context, r slog.Record) error {
+	r.AddAttrs(h.attr)
+	return h.Handler.Handle(ctx, r)
+}
+
+func TestBufferLogHandler_RecordReuse(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+
+	// enough attributes for record to store some of them outside of record itself,
+	// added one by one, so that storage has spare capacity
+	r := newRecord(slog.LevelInfo, "info msg")
+	for i := range 8 {
+		r.Add(fmt.Sprintf("a%d", i+1), i+1)
+	}
+	if err := h.Handle(context.Background(), r); err != nil {
+		t.Fatalf("handling record: %v", err)
+	}
+	// caller is allowed to keep modifying record after Handle returns
+	r.AddAttrs(slog.String("caller", "modified"))
+
+	// when
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandler(t, h, attrAddingHandler{Handler: rh, attr: slog.String("handler", "added")})
+	lines := getLines(t, reader)
+
+	// then
+	expectLinesNo(t, lines, 1)
+	expectAttr(t, lines[0], "a8", "8")
+	expectAttr(t, lines[0], "handler", "added")
+	expectNoAttr(t, lines[0], "caller", "modified")
+	if strings.Contains(lines[0], "!BUG") {
+		t.Fatalf("record modified without cloning, line is %s", lines[0])
+	}
+}
+
+// reentrantHandler logs using provided logger the first time it handles a record.
+type reentrantHandler struct {
+	slog.Handler
+	logger *slog.Logger
+	logged *bool
+}
+
+func (h reentrantHandler) Handle(ctx context.Context, r slog.Record) error {
+	if !*h.logged {
+		*h.logged = true
+		h.logger.Info("reentrant msg")
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// loggingValuer is [slog.LogValuer] that logs using provided logger when resolved.
+type loggingValuer struct {
+	logger *slog.Logger
+}
+
+func (v loggingValuer) LogValue() slog.Value {
+	v.logger.Info("valuer msg")
+	return slog.StringValue("value")
+}
+
+// setRealHandlerWithTimeout fails the test if setting real handler does not finish in time.
+func setRealHandlerWithTimeout(t *testing.T, h *slogbuffer.BufferLogHandler, real slog.Handler) {
+	t.Helper()
+	done := make(chan error)
+	go func() {
+		done <- h.SetRealHandler(context.Background(), real)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("setting real handler: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("setting real handler did not finish, possible deadlock")
+	}
+}
+
+func TestBufferLogHandler_ReentrantRealHandler(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	l := slog.New(h)
+
+	l.Info("first msg")
+	l.Info("second msg")
+
+	// when
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandlerWithTimeout(t, h, reentrantHandler{Handler: rh, logger: l, logged: new(bool)})
+	lines := getLines(t, reader)
+
+	// then
+	expectLinesNo(t, lines, 3)
+	expectMsg(t, lines[0], "first msg")
+	expectMsg(t, lines[1], "second msg")
+	expectMsg(t, lines[2], "reentrant msg")
+}
+
+func TestBufferLogHandler_ReentrantLogValuer(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithValueResolution(false))
+	l := slog.New(h)
+
+	l.Info("info msg", "valuer", loggingValuer{logger: l})
+
+	// when
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandlerWithTimeout(t, h, rh)
+	lines := getLines(t, reader)
+
+	// then
+	expectLinesNo(t, lines, 2)
+	expectMsg(t, lines[0], "info msg")
+	expectAttr(t, lines[0], "valuer", "value")
+	expectMsg(t, lines[1], "valuer msg")
+}
+
+func TestBufferLogHandler_SetRealHandlers(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	l := slog.New(h)
+	l.WithGroup("g1").With("common", "attr").Info("buffered msg")
+
+	// when
+	first, firstReader := getSimplifiedTextHandler()
+	second, secondReader := getSimplifiedTextHandler()
+	err := h.SetRealHandlers(context.Background(), first, newFailingHandler(second, 1))
+	l.Info("after flush msg")
+
+	// then
+	if !errors.Is(err, errHandlerFailed) {
+		t.Fatalf("expected error of failing handler, got: %v", err)
+	}
+	firstLines := getLines(t, firstReader)
+	expectLinesNo(t, firstLines, 2)
+	expectMsg(t, firstLines[0], "buffered msg")
+	expectAttr(t, firstLines[0], "g1.common", "attr")
+	expectMsg(t, firstLines[1], "after flush msg")
+
+	secondLines := getLines(t, secondReader)
+	expectLinesNo(t, secondLines, 1)
+	expectMsg(t, secondLines[0], "after flush msg")
+}
+
+func TestFlushMerged(t *testing.T) {
+	// given
+	db := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	http := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	now := time.Now()
+	for i, h := range []*slogbuffer.BufferLogHandler{db, http, db, http} {
+		msg := fmt.Sprintf("msg %d", i)
+		if err := h.Handle(context.Background(), slog.NewRecord(now.Add(time.Duration(i-4)*time.Second), slog.LevelInfo, msg, 0)); err != nil {
+			t.Fatalf("handling record: %v", err)
+		}
+	}
+	slog.New(http).WithGroup("g1").With("common", "attr").Info("msg 4")
+
+	// when
+	rh, reader := getSimplifiedTextHandler()
+	err := slogbuffer.FlushMerged(context.Background(), rh, http, db)
+
+	// then
+	if err != nil {
+		t.Fatalf("flushing merged: %v", err)
+	}
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 5)
+	for i, line := range lines {
+		expectMsg(t, line, fmt.Sprintf("msg %d", i))
+	}
+	expectAttr(t, lines[4], "g1.common", "attr")
+	if db.IsBuffering() || http.IsBuffering() {
+		t.Fatalf("expected all handlers to be flushed")
+	}
+}
+
+func TestBufferLogHandler_ConcurrentSetRealHandler(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	l := slog.New(h).WithGroup("g1").With("common", "attr")
+	rh, reader := getSimplifiedTextHandler()
+
+	// when
+	var wg sync.WaitGroup
+	for g := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 100 {
+				l.Info("msg", "goroutine", g, "i", i)
+			}
+		}()
+	}
+	setRealHandler(t, h, rh)
+	wg.Wait()
+
+	// then
+	// every record is either flushed or passed to real handler directly
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 400)
+	for _, line := range lines {
+		expectAttr(t, line, "g1.common", "attr")
+	}
+}
+
+func TestBufferLogHandler_WrapperModeAllocations(t *testing.T) {
+	if raceEnabled {
+		t.Skip("race detector allocates on its own")
+	}
+
+	// given
+	rh := slog.NewTextHandler(io.Discard, nil)
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	buffered := slog.New(h).WithGroup("g1").With("common", "attr")
+	direct := slog.New(rh).WithGroup("g1").With("common", "attr")
+	setRealHandler(t, h, rh)
+
+	// when
+	bufferedAllocs := testing.AllocsPerRun(100, func() { buffered.Info("msg", "foo", "bar") })
+	directAllocs := testing.AllocsPerRun(100, func() { direct.Info("msg", "foo", "bar") })
+
+	// then
+	// handler derived before real handler was set does not compose real handler for every record
+	if bufferedAllocs > directAllocs {
+		t.Fatalf("expected no allocations over %v of real handler, got %v", directAllocs, bufferedAllocs)
+	}
+}
+
+func TestBufferLogHandler_BufferingAllocations(t *testing.T) {
+	if raceEnabled {
+		t.Skip("race detector allocates on its own")
+	}
+
+	// given
+	h := slogbuffer.NewBoundBufferLogHandler(slog.LevelDebug, 10)
+	l := slog.New(h).With("common", "attr")
+	ctx := context.Background()
+	for range 10 {
+		l.LogAttrs(ctx, slog.LevelInfo, "msg", slog.String("foo", "bar"))
+	}
+
+	// when
+	allocs := testing.AllocsPerRun(100, func() { l.LogAttrs(ctx, slog.LevelInfo, "msg", slog.String("foo", "bar")) })
+
+	// then
+	// records are stored in the buffer by value, so buffering them does not allocate
+	if allocs > 0 {
+		t.Fatalf("expected no allocations, got %v", allocs)
+	}
+}
+
+func BenchmarkBufferLogHandler_WrapperMode(b *testing.B) {
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	l := slog.New(h).WithGroup("g1").With("common", "attr")
+	if err := h.SetRealHandler(context.Background(), slog.NewTextHandler(io.Discard, nil)); err != nil {
+		b.Fatalf("setting real handler: %v", err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		l.Info("benchmark msg", "key", "value")
+	}
+}
+
+func TestBufferLogHandler_DeepDerivation(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	l := slog.New(h)
+	for i := range 100 {
+		l = l.With(fmt.Sprintf("a%d", i), i)
+	}
+	l.Info("buffered msg")
+
+	// when
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandler(t, h, rh)
+	l.Info("after flush msg")
+	// state changed through deeply derived handler is shared with root
+	l.Handler().(*slogbuffer.BufferLogHandler).Pause()
+	l.Info("paused msg")
+
+	// then
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 2)
+	expectAttr(t, lines[0], "a99", "99")
+	expectAttr(t, lines[1], "a99", "99")
+	if h.State() != slogbuffer.Paused || h.Len() != 1 {
+		t.Fatalf("expected root handler to be paused with 1 record, got %s with %d", h.State(), h.Len())
+	}
+}
+
+func TestBufferLogHandler_DerivedAfterSetRealHandler(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelInfo)
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandler(t, h, rh)
+	derived := h.WithAttrs([]slog.Attr{slog.String("foo", "bar")}).WithGroup("g").(*slogbuffer.BufferLogHandler)
+	l := slog.New(derived)
+
+	// when
+	derived.Pause()
+	l.Debug("debug msg")
+	l.Info("paused msg", "no", 1)
+
+	// then
+	// derived handler buffers to the same buffer and respects level of the root
+	if h.Len() != 1 || derived.Len() != 1 {
+		t.Fatalf("expected 1 buffered record, got %d (derived %d)", h.Len(), derived.Len())
+	}
+	records := derived.Records()
+	expectRecordAttr(t, records[0], "foo", slog.StringValue("bar"))
+	expectRecordAttr(t, records[0], "g", slog.GroupValue(slog.Int("no", 1)))
+
+	if err := derived.Resume(context.Background()); err != nil {
+		t.Fatalf("resuming: %v", err)
+	}
+	l.Info("resumed msg")
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 2)
+	expectMsg(t, lines[0], "paused msg")
+	expectAttr(t, lines[0], "foo", "bar")
+	expectAttr(t, lines[0], "g.no", "1")
+	expectMsg(t, lines[1], "resumed msg")
+	expectAttr(t, lines[1], "foo", "bar")
+
+	// discarding through derived handler affects shared buffer
+	derived.Pause()
+	l.Info("discarded msg")
+	derived.Discard()
+	if h.Len() != 0 {
+		t.Fatalf("expected empty buffer, got %d records", h.Len())
+	}
+}
+
+func TestBufferLogHandler_AttrsBeforeGroup(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	l := slog.New(h).With("a", 1).WithGroup("g").With("b", 2).WithGroup("h")
+
+	// when
+	l.Info("buffered msg", "c", 3)
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandler(t, h, rh)
+	l.Info("wrapped msg", "c", 3)
+	slog.New(h).With("a", 1).WithGroup("g").With("b", 2).WithGroup("h").Info("derived msg", "c", 3)
+
+	// then
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 3)
+	for _, line := range lines {
+		expectAttr(t, line, "a", "1")
+		expectAttr(t, line, "g.b", "2")
+		expectAttr(t, line, "g.h.c", "3")
+		expectNoAttr(t, line, "g.a", "1")
+	}
+}
+
+func TestBufferLogHandler_SiblingDerivation(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	parent := slog.New(h).With("parent", "attr").WithGroup("g").With("a", 0)
+
+	// when
+	// siblings derived concurrently from the same parent must not share attributes
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			parent.With("sibling", i).Info("sibling msg", "no", i)
+		}()
+	}
+	wg.Wait()
+	parent.Info("parent msg")
+
+	// then
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandler(t, h, rh)
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 11)
+	noRe := regexp.MustCompile(`g\.no=(\d+)`)
+	for _, line := range lines[:10] {
+		no := noRe.FindStringSubmatch(line)[1]
+		expectAttr(t, line, "g.sibling", no)
+		if strings.Count(line, "g.sibling=") != 1 {
+			t.Fatalf("expected single sibling attribute, line is %s", line)
+		}
+	}
+	expectAttr(t, lines[10], "parent", "attr")
+	expectAttr(t, lines[10], "g.a", "0")
+	expectNoAttr(t, lines[10], "g.sibling", "0")
+}
+
+func BenchmarkBufferLogHandler_WithAttrs(b *testing.B) {
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	parent := h.WithAttrs([]slog.Attr{slog.String("parent", "attr")}).WithGroup("g")
+	attrs := []slog.Attr{slog.Int("no", 42)}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		parent.WithAttrs(attrs)
+	}
+}
+
+func BenchmarkBufferLogHandler_Buffering(b *testing.B) {
+	for _, bc := range []struct {
+		name       string
+		maxRecords int
+		opts       []slogbuffer.Option
+	}{
+		{name: "memory"},
+		// storage of bound buffer is allocated once, so only cost of buffering is measured
+		{name: "memory bound", maxRecords: 10_000},
+		{name: "compressed", opts: []slogbuffer.Option{slogbuffer.WithCompression(0)}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			h := slogbuffer.NewBoundBufferLogHandler(slog.LevelDebug, bc.maxRecords, bc.opts...)
+			l := slog.New(h).With("common", "attr")
+			ctx := context.Background()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := range b.N {
+				l.LogAttrs(ctx, slog.LevelInfo, "buffered msg", slog.Int("no", i), slog.String("foo", "bar"))
+				if i%10_000 == 0 {
+					// keeps memory bounded, cost of discarding is not measured
+					b.StopTimer()
+					h.Discard()
+					b.StartTimer()
+				}
+			}
+		})
+	}
+}
+
+func TestBufferLogHandler_WithStackTrace(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithStackTrace(slog.LevelError, ""))
+	l := slog.New(h)
+
+	// when
+	l.Info("info msg")
+	l.Error("error msg")
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandler(t, h, rh)
+	l.Error("direct msg")
+
+	// then
+	records := getLines(t, reader)
+	expectLinesNo(t, records, 3)
+	if strings.Contains(records[0], "stack=") {
+		t.Fatalf("expected no stack trace in %q", records[0])
+	}
+	expectContains(t, records[1], "stack=")
+	// stack starts at code that logged the record, not inside slog
+	expectContains(t, records[1], `stack="github.com/delicb/slogbuffer_test.TestBufferLogHandler_WithStackTrace`)
+	if strings.Contains(records[2], "stack=") {
+		t.Fatalf("expected no stack trace in %q", records[2])
+	}
+}
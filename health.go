@@ -0,0 +1,23 @@
+package slogbuffer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrHealthCheckFailed is returned when records are not flushed because health check
+// configured using WithHealthCheck failed. It wraps error returned by health check.
+var ErrHealthCheckFailed = errors.New("slogbuffer: health check failed")
+
+// checkHealth runs health check configured using WithHealthCheck, if any.
+func (h *BufferLogHandler) checkHealth(ctx context.Context) error {
+	check := h.getOptions().healthCheck
+	if check == nil {
+		return nil
+	}
+	if err := check(ctx); err != nil {
+		return fmt.Errorf("%w: %w", ErrHealthCheckFailed, err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package slogbuffer_test
+
+import (
+	"context"
+	"errors"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"testing"
+)
+
+func TestBufferLogHandler_WithHealthCheck(t *testing.T) {
+	// given
+	errNotReady := errors.New("not ready")
+	ready := false
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithHealthCheck(func(context.Context) error {
+		if !ready {
+			return errNotReady
+		}
+		return nil
+	}))
+	l := slog.New(h)
+	l.Info("first msg")
+	l.Info("second msg")
+	rh, reader := getSimplifiedTextHandler()
+
+	// when
+	err := h.SetRealHandler(context.Background(), rh)
+
+	// then
+	if !errors.Is(err, slogbuffer.ErrHealthCheckFailed) || !errors.Is(err, errNotReady) {
+		t.Fatalf("expected health check error, got %v", err)
+	}
+	expectLinesNo(t, getLines(t, reader), 0)
+	if h.Len() != 2 || h.State() != slogbuffer.Buffering {
+		t.Fatalf("expected records to remain buffered, got %d in state %s", h.Len(), h.State())
+	}
+
+	// when
+	ready = true
+	setRealHandler(t, h, rh)
+
+	// then
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 2)
+	expectMsg(t, lines[0], "first msg")
+	expectMsg(t, lines[1], "second msg")
+}
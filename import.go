@@ -0,0 +1,142 @@
+package slogbuffer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"time"
+)
+
+// ReadJSON parses newline delimited JSON produced by WriteJSON (or WriteTo) back into records.
+// Attributes of records are nested in groups they belonged to, so handling them produces the
+// same output as handling original records would. Numbers are decoded as int64 or float64,
+// JSON objects as groups and other values as their JSON representation, so values that were
+// not native to JSON (e.g. durations or errors) are not restored to their original types.
+//
+// Line that can not be parsed is yielded as error (with zero record) and iteration continues
+// with the next one, unless consumer stops it. Error reading from provided reader is yielded
+// last.
+func ReadJSON(r io.Reader) iter.Seq2[slog.Record, error] {
+	return func(yield func(slog.Record, error) bool) {
+		br := bufio.NewReader(r)
+		for lineNo := 1; ; lineNo++ {
+			line, err := br.ReadBytes('\n')
+			if len(bytes.TrimSpace(line)) > 0 {
+				rec, decodeErr := decodeJSONRecord(line)
+				if decodeErr != nil {
+					decodeErr = fmt.Errorf("decoding record at line %d: %w", lineNo, decodeErr)
+				}
+				if !yield(rec, decodeErr) {
+					return
+				}
+			}
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				yield(slog.Record{}, err)
+				return
+			}
+		}
+	}
+}
+
+// ReplayJSON sends all records from newline delimited JSON produced by WriteJSON (see
+// ReadJSON) to provided handler. It is useful for replaying logs captured by another process
+// or migrating them to different sink. Lines that can not be parsed are skipped and reported
+// in returned error, together with errors returned by handler.
+func ReplayJSON(ctx context.Context, r io.Reader, handler slog.Handler) error {
+	var replayErr error
+	for rec, err := range ReadJSON(r) {
+		if err != nil {
+			replayErr = errors.Join(replayErr, err)
+			continue
+		}
+		replayErr = errors.Join(replayErr, handler.Handle(ctx, rec))
+	}
+	return replayErr
+}
+
+// decodeJSONRecord parses single record encoded by WriteJSON.
+func decodeJSONRecord(line []byte) (slog.Record, error) {
+	var raw struct {
+		Time    time.Time       `json:"time"`
+		Level   slog.Level      `json:"level"`
+		Message string          `json:"msg"`
+		Attrs   json.RawMessage `json:"attrs"`
+	}
+	if err := json.Unmarshal(line, &raw); err != nil {
+		return slog.Record{}, err
+	}
+	rec := slog.NewRecord(raw.Time, raw.Level, raw.Message, 0)
+	if len(raw.Attrs) > 0 {
+		attrs, err := decodeJSONAttrs(raw.Attrs)
+		if err != nil {
+			return slog.Record{}, err
+		}
+		rec.AddAttrs(attrs...)
+	}
+	return rec, nil
+}
+
+// decodeJSONAttrs parses JSON object into attributes, preserving their order.
+func decodeJSONAttrs(data []byte) ([]slog.Attr, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil {
+		return nil, err
+	} else if tok != json.Delim('{') {
+		return nil, fmt.Errorf("expected object, got %v", tok)
+	}
+	var attrs []slog.Attr
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := tok.(string)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		value, err := decodeJSONValue(raw)
+		if err != nil {
+			return nil, err
+		}
+		attrs = append(attrs, slog.Attr{Key: key, Value: value})
+	}
+	return attrs, nil
+}
+
+// decodeJSONValue converts JSON value to slog.Value, objects becoming groups.
+func decodeJSONValue(data json.RawMessage) (slog.Value, error) {
+	if data = bytes.TrimSpace(data); len(data) > 0 && data[0] == '{' {
+		attrs, err := decodeJSONAttrs(data)
+		if err != nil {
+			return slog.Value{}, err
+		}
+		return slog.GroupValue(attrs...), nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return slog.Value{}, err
+	}
+	if n, ok := v.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			return slog.Int64Value(i), nil
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return slog.Value{}, err
+		}
+		return slog.Float64Value(f), nil
+	}
+	return slog.AnyValue(v), nil
+}
@@ -0,0 +1,100 @@
+package slogbuffer_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestReadJSON(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	l := slog.New(h)
+	l.Info("info msg", "foo", "bar", "err", errors.New("some error"), "pi", 3.14, "ok", true)
+	l.WithGroup("g1").With("common", "attr").Warn("warn msg", slog.Group("g2", "no", 42))
+
+	exported := new(bytes.Buffer)
+	if err := h.WriteJSON(exported); err != nil {
+		t.Fatalf("writing json: %v", err)
+	}
+
+	// when
+	var records []slog.Record
+	for rec, err := range slogbuffer.ReadJSON(bytes.NewReader(exported.Bytes())) {
+		if err != nil {
+			t.Fatalf("reading json: %v", err)
+		}
+		records = append(records, rec)
+	}
+
+	// then
+	original := h.Records()
+	if len(records) != len(original) {
+		t.Fatalf("expected %d records, got %d", len(original), len(records))
+	}
+	for i, rec := range records {
+		if !rec.Time.Equal(original[i].Time) || rec.Level != original[i].Level || rec.Message != original[i].Message {
+			t.Fatalf("record %d differs: expected %v, got %v", i, original[i], rec)
+		}
+	}
+	expectRecordAttr(t, records[0], "foo", slog.StringValue("bar"))
+	expectRecordAttr(t, records[0], "err", slog.StringValue("some error"))
+	expectRecordAttr(t, records[0], "pi", slog.Float64Value(3.14))
+	expectRecordAttr(t, records[0], "ok", slog.BoolValue(true))
+	expectRecordAttr(t, records[1], "g1", slog.GroupValue(
+		slog.String("common", "attr"),
+		slog.Group("g2", slog.Int64("no", 42)),
+	))
+}
+
+func TestReplayJSON(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	l := slog.New(h)
+	l.Debug("debug msg", "foo", "bar")
+	l.WithGroup("g1").Warn("warn msg", "foo", "bar")
+
+	exported := new(bytes.Buffer)
+	if err := h.WriteJSON(exported); err != nil {
+		t.Fatalf("writing json: %v", err)
+	}
+	input := strings.Replace(exported.String(), "\n", "\nnot json\n", 1)
+
+	// when
+	rh, reader := getSimplifiedTextHandler()
+	err := slogbuffer.ReplayJSON(context.Background(), strings.NewReader(input), rh)
+	lines := getLines(t, reader)
+
+	// then
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("expected error about malformed line 2, got %v", err)
+	}
+	expectLinesNo(t, lines, 2)
+	expectLevel(t, lines[0], slog.LevelDebug)
+	expectMsg(t, lines[0], "debug msg")
+	expectAttr(t, lines[0], "foo", "bar")
+	expectLevel(t, lines[1], slog.LevelWarn)
+	expectMsg(t, lines[1], "warn msg")
+	expectAttr(t, lines[1], "g1.foo", "bar")
+}
+
+func TestReadJSON_Stop(t *testing.T) {
+	// given
+	input := strings.Repeat(`{"time":"2024-01-01T00:00:00Z","level":"INFO","msg":"m"}`+"\n", 3)
+
+	// when
+	count := 0
+	for range slogbuffer.ReadJSON(strings.NewReader(input)) {
+		count++
+		break
+	}
+
+	// then
+	if count != 1 {
+		t.Fatalf("expected iteration to stop after first record, got %d", count)
+	}
+}
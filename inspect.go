@@ -0,0 +1,112 @@
+package slogbuffer
+
+import (
+	"log/slog"
+)
+
+// Records returns copies of currently buffered records, in the order they were logged.
+// Attributes and groups of the logger that produced a record (added using
+// [slog.Logger.With] and [slog.Logger.WithGroup]) are folded into attributes of the record,
+// so returned records are self-contained. Buffer is not changed.
+func (h *BufferLogHandler) Records() []slog.Record {
+	return materializeAll(h.buffer.Snapshot())
+}
+
+// materializeAll materializes all provided records.
+func materializeAll(records []record) []slog.Record {
+	res := make([]slog.Record, 0, len(records))
+	for _, rec := range records {
+		res = append(res, rec.materialize())
+	}
+	return res
+}
+
+// State describes mode in which handler operates.
+type State int
+
+const (
+	// Buffering is state of handler before real handler is set. Records are buffered.
+	Buffering State = iota
+	// Flushed is state of handler after real handler is set. Records are passed to real handler.
+	Flushed
+	// Paused is state of handler after Pause is called. Real handler is set, but records
+	// are buffered until Resume is called.
+	Paused
+)
+
+// String returns name of the state.
+func (s State) String() string {
+	switch s {
+	case Buffering:
+		return "Buffering"
+	case Flushed:
+		return "Flushed"
+	case Paused:
+		return "Paused"
+	default:
+		return "Unknown"
+	}
+}
+
+// State returns current state of the handler.
+func (h *BufferLogHandler) State() State {
+	mode := h.root().loadMode()
+	if mode.real == nil {
+		return Buffering
+	}
+	if mode.paused {
+		return Paused
+	}
+	return Flushed
+}
+
+// IsBuffering reports if handler currently holds records in memory instead of passing
+// them to real handler.
+func (h *BufferLogHandler) IsBuffering() bool {
+	return h.State() != Flushed
+}
+
+// Len returns number of currently buffered records.
+func (h *BufferLogHandler) Len() int {
+	return h.buffer.Len()
+}
+
+// Cap returns maximum number of records handler can buffer. Handler with unbound
+// buffer returns 0.
+func (h *BufferLogHandler) Cap() int {
+	return h.buffer.Cap()
+}
+
+// Head returns copies of at most n oldest buffered records, oldest first. Like with
+// Records, attributes and groups of the logger are folded into attributes of records.
+func (h *BufferLogHandler) Head(n int) []slog.Record {
+	return materializeAll(h.buffer.Head(n))
+}
+
+// Tail returns copies of at most n newest buffered records, oldest first. Like with
+// Records, attributes and groups of the logger are folded into attributes of records.
+func (h *BufferLogHandler) Tail(n int) []slog.Record {
+	return materializeAll(h.buffer.Tail(n))
+}
+
+// PeekOldest returns copy of the oldest buffered record without removing it. Returns false
+// if buffer is empty. Like with Records, attributes and groups of the logger are folded into
+// attributes of the record.
+func (h *BufferLogHandler) PeekOldest() (slog.Record, bool) {
+	head := h.buffer.Head(1)
+	if len(head) == 0 {
+		return slog.Record{}, false
+	}
+	return head[0].materialize(), true
+}
+
+// PeekNewest returns copy of the newest buffered record without removing it. Returns false
+// if buffer is empty. Like with Records, attributes and groups of the logger are folded into
+// attributes of the record.
+func (h *BufferLogHandler) PeekNewest() (slog.Record, bool) {
+	tail := h.buffer.Tail(1)
+	if len(tail) == 0 {
+		return slog.Record{}, false
+	}
+	return tail[0].materialize(), true
+}
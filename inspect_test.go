@@ -0,0 +1,288 @@
+package slogbuffer_test
+
+import (
+	"context"
+	"errors"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestBufferLogHandler_Records(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelInfo)
+	l := slog.New(h)
+
+	l.Debug("discarded msg")
+	l.Info("info msg", "foo", "bar")
+	l.WithGroup("g1").With("common", "attr").Warn("warn msg", "foo", "bar")
+
+	// when
+	records := h.Records()
+
+	// then
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+
+	if records[0].Message != "info msg" || records[0].Level != slog.LevelInfo {
+		t.Fatalf("unexpected record %v", records[0])
+	}
+	expectRecordAttr(t, records[0], "foo", slog.StringValue("bar"))
+
+	if records[1].Message != "warn msg" || records[1].Level != slog.LevelWarn {
+		t.Fatalf("unexpected record %v", records[1])
+	}
+	expectRecordAttr(t, records[1], "g1", slog.GroupValue(slog.String("common", "attr"), slog.String("foo", "bar")))
+
+	// records are still buffered
+	if len(h.Records()) != 2 {
+		t.Fatalf("records removed from buffer")
+	}
+}
+
+func expectState(t *testing.T, h *slogbuffer.BufferLogHandler, state slogbuffer.State) {
+	t.Helper()
+	if h.State() != state {
+		t.Fatalf("expected state %s, got %s", state, h.State())
+	}
+	if h.IsBuffering() != (state != slogbuffer.Flushed) {
+		t.Fatalf("unexpected IsBuffering %v in state %s", h.IsBuffering(), state)
+	}
+}
+
+func TestBufferLogHandler_LenCap(t *testing.T) {
+	// given
+	bound := slogbuffer.NewBoundBufferLogHandler(slog.LevelInfo, 2)
+	unbound := slogbuffer.NewBufferLogHandler(slog.LevelInfo)
+
+	// when
+	for range 3 {
+		slog.New(bound).Info("info msg")
+		slog.New(unbound).Info("info msg")
+	}
+
+	// then
+	if bound.Len() != 2 || bound.Cap() != 2 {
+		t.Fatalf("unexpected bound buffer len %d and cap %d", bound.Len(), bound.Cap())
+	}
+	if unbound.Len() != 3 || unbound.Cap() != 0 {
+		t.Fatalf("unexpected unbound buffer len %d and cap %d", unbound.Len(), unbound.Cap())
+	}
+
+	rh, _ := getSimplifiedTextHandler()
+	setRealHandler(t, bound, rh)
+	if bound.Len() != 0 {
+		t.Fatalf("expected empty buffer after flush, got %d records", bound.Len())
+	}
+}
+
+func TestBufferLogHandler_State(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelInfo)
+	l := slog.New(h)
+	beforeHandoff := l.With("before", "handoff")
+	expectState(t, h, slogbuffer.Buffering)
+
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandler(t, h, rh)
+	expectState(t, h, slogbuffer.Flushed)
+	afterHandoff := l.With("after", "handoff")
+
+	// when
+	h.Pause()
+	expectState(t, h, slogbuffer.Paused)
+
+	l.Info("first msg")
+	beforeHandoff.Info("second msg")
+	afterHandoff.Info("third msg")
+
+	// then
+	expectLinesNo(t, getLines(t, reader), 0)
+	if h.Len() != 3 {
+		t.Fatalf("expected 3 buffered records, got %d", h.Len())
+	}
+
+	if err := h.Resume(context.Background()); err != nil {
+		t.Fatalf("resuming: %v", err)
+	}
+	expectState(t, h, slogbuffer.Flushed)
+	l.Info("fourth msg")
+
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 4)
+	expectMsg(t, lines[0], "first msg")
+	expectMsg(t, lines[1], "second msg")
+	expectAttr(t, lines[1], "before", "handoff")
+	expectMsg(t, lines[2], "third msg")
+	expectAttr(t, lines[2], "after", "handoff")
+	expectMsg(t, lines[3], "fourth msg")
+}
+
+func TestBufferLogHandler_PauseBeforeHandoff(t *testing.T) {
+	h := slogbuffer.NewBufferLogHandler(slog.LevelInfo)
+
+	h.Pause()
+	expectState(t, h, slogbuffer.Buffering)
+
+	if err := h.Resume(context.Background()); !errors.Is(err, slogbuffer.ErrNoRealHandler) {
+		t.Fatalf("expected ErrNoRealHandler, got %v", err)
+	}
+}
+
+func TestBufferLogHandler_HasLevel(t *testing.T) {
+	// given
+	h := slogbuffer.NewBoundBufferLogHandler(slog.LevelDebug, 2)
+	l := slog.New(h)
+
+	if h.HasLevel(slog.LevelDebug) {
+		t.Fatalf("empty buffer should not have any level")
+	}
+
+	// when
+	l.Debug("debug msg")
+	l.Warn("warn msg")
+
+	// then
+	if !h.HasLevel(slog.LevelInfo) {
+		t.Fatalf("expected record at or above info level")
+	}
+	if h.HasLevel(slog.LevelError) {
+		t.Fatalf("unexpected record at or above error level")
+	}
+
+	// warning is evicted by new records
+	l.Debug("debug msg")
+	l.Debug("debug msg")
+	if h.HasLevel(slog.LevelInfo) {
+		t.Fatalf("unexpected record at or above info level after eviction")
+	}
+	if !h.HasLevel(slog.LevelDebug) {
+		t.Fatalf("expected record at or above debug level")
+	}
+
+	h.Discard()
+	if h.HasLevel(slog.LevelDebug) {
+		t.Fatalf("unexpected record after discard")
+	}
+}
+
+func TestBufferLogHandler_ApproxBytes(t *testing.T) {
+	// given
+	h := slogbuffer.NewBoundBufferLogHandler(slog.LevelDebug, 10)
+	l := slog.New(h).With("common", strings.Repeat("x", 1000))
+
+	if h.ApproxBytes() != 0 {
+		t.Fatalf("empty buffer should not hold any bytes, got %d", h.ApproxBytes())
+	}
+
+	// when
+	l.Info(strings.Repeat("m", 100), "foo", strings.Repeat("b", 100), slog.Group("g", "no", 42))
+	single := h.ApproxBytes()
+	for range 20 {
+		l.Info(strings.Repeat("m", 100), "foo", strings.Repeat("b", 100), slog.Group("g", "no", 42))
+	}
+
+	// then
+	// message and attributes of the record are counted, shared attributes of the logger are not
+	if single < 200 || single >= 1000 {
+		t.Fatalf("unexpected estimate %d for single record", single)
+	}
+	// evicted records are no longer counted
+	if h.ApproxBytes() != 10*single {
+		t.Fatalf("expected estimate %d for full buffer, got %d", 10*single, h.ApproxBytes())
+	}
+
+	h.Discard()
+	if h.ApproxBytes() != 0 {
+		t.Fatalf("expected no bytes after discard, got %d", h.ApproxBytes())
+	}
+}
+
+func TestBufferLogHandler_Dropped(t *testing.T) {
+	// given
+	h := slogbuffer.NewBoundBufferLogHandler(slog.LevelDebug, 3, slogbuffer.WithSampling(slog.LevelDebug, 2))
+	l := slog.New(h)
+
+	// when
+	for range 4 {
+		// every other record is sampled out
+		l.Debug("debug msg")
+	}
+	for range 3 {
+		// last two records evict both buffered debug records
+		l.Info("info msg")
+	}
+
+	// then
+	if h.Dropped() != 4 {
+		t.Fatalf("expected 4 dropped records, got %d", h.Dropped())
+	}
+	h.Discard()
+	if h.Dropped() != 4 {
+		t.Fatalf("discarded records should not be counted as dropped, got %d", h.Dropped())
+	}
+}
+
+func TestBufferLogHandler_HeadTail(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	l := slog.New(h)
+	for i := range 5 {
+		l.Info("msg", "no", i)
+	}
+
+	// when
+	head := h.Head(2)
+	tail := h.Tail(2)
+
+	// then
+	if len(head) != 2 || len(tail) != 2 {
+		t.Fatalf("expected 2 records, got %d in head and %d in tail", len(head), len(tail))
+	}
+	expectRecordAttr(t, head[0], "no", slog.IntValue(0))
+	expectRecordAttr(t, head[1], "no", slog.IntValue(1))
+	expectRecordAttr(t, tail[0], "no", slog.IntValue(3))
+	expectRecordAttr(t, tail[1], "no", slog.IntValue(4))
+
+	if h.Len() != 5 {
+		t.Fatalf("records removed from buffer")
+	}
+}
+
+func TestBufferLogHandler_PeekPop(t *testing.T) {
+	// given
+	h := slogbuffer.NewBoundBufferLogHandler(slog.LevelDebug, 3)
+	if _, ok := h.PeekOldest(); ok {
+		t.Fatalf("expected nothing to peek in empty buffer")
+	}
+	l := slog.New(h).WithGroup("g")
+	for i := range 4 {
+		l.Info("msg", "no", i)
+	}
+
+	// when
+	oldest, okOldest := h.PeekOldest()
+	newest, okNewest := h.PeekNewest()
+	popped, okPopped := h.PopOldest()
+
+	// then
+	if !okOldest || !okNewest || !okPopped {
+		t.Fatalf("expected records, got %t %t %t", okOldest, okNewest, okPopped)
+	}
+	expectRecordAttr(t, oldest, "g", slog.GroupValue(slog.Int("no", 1)))
+	expectRecordAttr(t, newest, "g", slog.GroupValue(slog.Int("no", 3)))
+	expectRecordAttr(t, popped, "g", slog.GroupValue(slog.Int("no", 1)))
+	if h.Len() != 2 {
+		t.Fatalf("expected 2 records after pop, got %d", h.Len())
+	}
+	if next, _ := h.PeekOldest(); !next.Time.Equal(h.Head(1)[0].Time) {
+		t.Fatalf("expected next record to become the oldest")
+	}
+	h.PopOldest()
+	h.PopOldest()
+	if _, ok := h.PopOldest(); ok {
+		t.Fatalf("expected nothing to pop from empty buffer")
+	}
+}
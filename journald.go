@@ -0,0 +1,256 @@
+//go:build linux
+
+package slogbuffer
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"log/slog"
+	"net"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// defaultJournalSocket is path of socket on which journald accepts records using native protocol.
+const defaultJournalSocket = "/run/systemd/journal/socket"
+
+// JournalOption configures optional behaviour of JournalHandler.
+type JournalOption func(*journalOptions)
+
+// journalOptions holds optional configuration of JournalHandler.
+type journalOptions struct {
+	// socket is path of journald socket
+	socket string
+	// leveler is minimal level of records sent to journald
+	leveler slog.Leveler
+	// identifier is value of SYSLOG_IDENTIFIER field, empty if not set
+	identifier string
+	// addSource controls if source code location of log call is sent
+	addSource bool
+}
+
+// WithJournalSocket makes JournalHandler send records to journald socket at provided path,
+// instead of default /run/systemd/journal/socket.
+func WithJournalSocket(path string) JournalOption {
+	return func(o *journalOptions) {
+		o.socket = path
+	}
+}
+
+// WithJournalLevel makes JournalHandler send only records at or above provided level.
+// By default, records at or above info level are sent.
+func WithJournalLevel(leveler slog.Leveler) JournalOption {
+	return func(o *journalOptions) {
+		o.leveler = leveler
+	}
+}
+
+// WithJournalIdentifier sets SYSLOG_IDENTIFIER field of all records, which is used by
+// journalctl to identify application (e.g. journalctl -t identifier).
+func WithJournalIdentifier(identifier string) JournalOption {
+	return func(o *journalOptions) {
+		o.identifier = identifier
+	}
+}
+
+// WithJournalSource makes JournalHandler send source code location of log call as
+// CODE_FILE, CODE_LINE and CODE_FUNC fields.
+func WithJournalSource() JournalOption {
+	return func(o *journalOptions) {
+		o.addSource = true
+	}
+}
+
+// JournalHandler is [slog.Handler] that sends records to systemd journal using its native
+// protocol, so attributes are kept as structured fields (e.g. attribute "user.id" becomes field
+// USER_ID), which can be used to filter records using journalctl. It is suitable as real handler
+// of BufferLogHandler for services that buffer records until they decide where to log.
+type JournalHandler struct {
+	conn *net.UnixConn
+	opts *journalOptions
+	// fields are encoded attributes added using WithAttrs
+	fields []byte
+	// prefix is prefix of field names of attributes, derived from groups
+	prefix string
+}
+
+// NewJournalHandler returns handler connected to journald socket. It returns error if journald
+// is not running.
+func NewJournalHandler(opts ...JournalOption) (*JournalHandler, error) {
+	o := &journalOptions{socket: defaultJournalSocket, leveler: slog.LevelInfo}
+	for _, opt := range opts {
+		opt(o)
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: o.socket, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	return &JournalHandler{conn: conn, opts: o}, nil
+}
+
+// compile time check that JournalHandler implements slog.Handler interface.
+var _ slog.Handler = &JournalHandler{}
+
+func (h *JournalHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.opts.leveler.Level()
+}
+
+func (h *JournalHandler) Handle(_ context.Context, r slog.Record) error {
+	var buf bytes.Buffer
+	writeJournalField(&buf, "MESSAGE", r.Message)
+	writeJournalField(&buf, "PRIORITY", strconv.Itoa(journalPriority(r.Level)))
+	if !r.Time.IsZero() {
+		// journald records time of reception, original time of (possibly buffered) record is kept
+		writeJournalField(&buf, "SYSLOG_TIMESTAMP", r.Time.Format(time.RFC3339Nano))
+	}
+	if h.opts.identifier != "" {
+		writeJournalField(&buf, "SYSLOG_IDENTIFIER", h.opts.identifier)
+	}
+	if h.opts.addSource && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		writeJournalField(&buf, "CODE_FILE", frame.File)
+		writeJournalField(&buf, "CODE_LINE", strconv.Itoa(frame.Line))
+		writeJournalField(&buf, "CODE_FUNC", frame.Function)
+	}
+	buf.Write(h.fields)
+	r.Attrs(func(a slog.Attr) bool {
+		appendJournalAttr(&buf, h.prefix, a)
+		return true
+	})
+	return h.send(buf.Bytes())
+}
+
+func (h *JournalHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var buf bytes.Buffer
+	buf.Write(h.fields)
+	for _, a := range attrs {
+		appendJournalAttr(&buf, h.prefix, a)
+	}
+	return &JournalHandler{conn: h.conn, opts: h.opts, fields: buf.Bytes(), prefix: h.prefix}
+}
+
+func (h *JournalHandler) WithGroup(name string) slog.Handler {
+	if len(name) == 0 {
+		return h
+	}
+	return &JournalHandler{conn: h.conn, opts: h.opts, fields: h.fields, prefix: h.prefix + name + "_"}
+}
+
+// Close closes connection to journald.
+func (h *JournalHandler) Close() error {
+	return h.conn.Close()
+}
+
+// send sends encoded record to journald. Records too large for single datagram are written
+// to temporary file, whose descriptor is passed to journald instead.
+func (h *JournalHandler) send(data []byte) error {
+	_, err := h.conn.Write(data)
+	if !errors.Is(err, syscall.EMSGSIZE) && !errors.Is(err, syscall.ENOBUFS) {
+		return err
+	}
+
+	f, err := os.CreateTemp("/dev/shm", "slogbuffer-journal-")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	// file is removed right away, journald reads it using passed descriptor
+	if err := os.Remove(f.Name()); err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	raw, err := h.conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	// connection is connected to journald socket, which is not allowed for WriteMsgUnix
+	var sendErr error
+	err = raw.Write(func(fd uintptr) bool {
+		sendErr = syscall.Sendmsg(int(fd), nil, syscall.UnixRights(int(f.Fd())), nil, 0)
+		return sendErr != syscall.EAGAIN
+	})
+	return errors.Join(err, sendErr)
+}
+
+// journalPriority maps level to syslog priority used by journald.
+func journalPriority(level slog.Level) int {
+	switch {
+	case level >= slog.LevelError:
+		return 3 // err
+	case level >= slog.LevelWarn:
+		return 4 // warning
+	case level >= slog.LevelInfo:
+		return 6 // info
+	default:
+		return 7 // debug
+	}
+}
+
+// appendJournalAttr appends attribute as journal field, with field name prefixed by provided
+// prefix. Attributes of groups are appended as separate fields, prefixed by name of the group.
+func appendJournalAttr(buf *bytes.Buffer, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "_"
+		}
+		for _, ga := range a.Value.Group() {
+			appendJournalAttr(buf, prefix, ga)
+		}
+		return
+	}
+	var value string
+	if a.Value.Kind() == slog.KindTime {
+		value = a.Value.Time().Format(time.RFC3339Nano)
+	} else {
+		value = a.Value.String()
+	}
+	if name := journalFieldName(prefix + a.Key); name != "" {
+		writeJournalField(buf, name, value)
+	}
+}
+
+// journalFieldName converts attribute key to valid journal field name: it consists only of
+// uppercase letters, digits and underscores, does not start with underscore or digit (which
+// are reserved for fields set by journald) and is at most 64 characters long.
+func journalFieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+	name = strings.TrimLeft(name, "_0123456789")
+	return name[:min(len(name), 64)]
+}
+
+// writeJournalField writes field in format of journald native protocol. Values containing
+// new line are prefixed by their length.
+func writeJournalField(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+	if !strings.Contains(value, "\n") {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+	buf.WriteByte('\n')
+	_ = binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
@@ -0,0 +1,137 @@
+//go:build linux
+
+package slogbuffer_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"github.com/delicb/slogbuffer"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// listenJournal returns fake journald socket.
+func listenJournal(t *testing.T) (string, *net.UnixConn) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "journal.socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return path, conn
+}
+
+// readJournalEntry reads single entry sent to fake journald socket, either directly or
+// using passed file descriptor, and parses its fields.
+func readJournalEntry(t *testing.T, conn *net.UnixConn) map[string]string {
+	t.Helper()
+	data := make([]byte, 1<<20)
+	oob := make([]byte, 1024)
+	n, oobn, _, _, err := conn.ReadMsgUnix(data, oob)
+	if err != nil {
+		t.Fatalf("reading entry: %v", err)
+	}
+	data = data[:n]
+	if oobn > 0 {
+		messages, err := syscall.ParseSocketControlMessage(oob[:oobn])
+		if err != nil {
+			t.Fatalf("parsing control message: %v", err)
+		}
+		fds, err := syscall.ParseUnixRights(&messages[0])
+		if err != nil {
+			t.Fatalf("parsing descriptors: %v", err)
+		}
+		f := os.NewFile(uintptr(fds[0]), "entry")
+		defer f.Close()
+		// descriptor shares offset with the file of the sender, which is at its end
+		if data, err = io.ReadAll(io.NewSectionReader(f, 0, math.MaxInt64)); err != nil {
+			t.Fatalf("reading entry file: %v", err)
+		}
+	}
+
+	fields := map[string]string{}
+	for len(data) > 0 {
+		line, rest, _ := bytes.Cut(data, []byte("\n"))
+		if name, value, ok := bytes.Cut(line, []byte("=")); ok {
+			fields[string(name)] = string(value)
+			data = rest
+			continue
+		}
+		size := binary.LittleEndian.Uint64(rest)
+		fields[string(line)] = string(rest[8 : 8+size])
+		data = rest[8+size+1:]
+	}
+	return fields
+}
+
+func TestJournalHandler(t *testing.T) {
+	// given
+	path, conn := listenJournal(t)
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	l := slog.New(h).With("request-id", 42).WithGroup("user")
+	l.Warn("buffered msg", "name", "multi\nline", slog.Group("address", "city", "Belgrade"))
+	l.Debug("ignored msg")
+
+	// when
+	journal, err := slogbuffer.NewJournalHandler(
+		slogbuffer.WithJournalSocket(path), slogbuffer.WithJournalIdentifier("test"))
+	if err != nil {
+		t.Fatalf("connecting to journal: %v", err)
+	}
+	defer journal.Close()
+	setRealHandler(t, h, journal)
+
+	// then
+	fields := readJournalEntry(t, conn)
+	expected := map[string]string{
+		"MESSAGE":           "buffered msg",
+		"PRIORITY":          "4",
+		"SYSLOG_IDENTIFIER": "test",
+		"REQUEST_ID":        "42",
+		"USER_NAME":         "multi\nline",
+		"USER_ADDRESS_CITY": "Belgrade",
+	}
+	for name, value := range expected {
+		if fields[name] != value {
+			t.Fatalf("expected field %s=%q, got %q (all fields %v)", name, value, fields[name], fields)
+		}
+	}
+	if _, ok := fields["SYSLOG_TIMESTAMP"]; !ok {
+		t.Fatalf("expected original time of record, got %v", fields)
+	}
+}
+
+func TestJournalHandler_LargeRecord(t *testing.T) {
+	// given
+	path, conn := listenJournal(t)
+	journal, err := slogbuffer.NewJournalHandler(slogbuffer.WithJournalSocket(path))
+	if err != nil {
+		t.Fatalf("connecting to journal: %v", err)
+	}
+	defer journal.Close()
+
+	// when
+	// record larger than maximum datagram size is passed using file descriptor
+	payload := strings.Repeat("x", 1<<20)
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "large msg", 0)
+	r.AddAttrs(slog.String("payload", payload))
+	if err := journal.Handle(context.Background(), r); err != nil {
+		t.Fatalf("handling record: %v", err)
+	}
+
+	// then
+	fields := readJournalEntry(t, conn)
+	if fields["MESSAGE"] != "large msg" || fields["PAYLOAD"] != payload {
+		t.Fatalf("unexpected entry with message %q and payload of %d bytes", fields["MESSAGE"], len(fields["PAYLOAD"]))
+	}
+}
@@ -0,0 +1,229 @@
+package slogbuffer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// KeyFunc returns key of buffer record should be stored in (e.g. tenant or job ID). Record
+// has attributes of the logger added before any group was opened, followed by its own attributes.
+type KeyFunc func(ctx context.Context, r slog.Record) string
+
+// KeyFromAttr returns KeyFunc that uses value of top level attribute with provided key as
+// key of the buffer. Records without such attribute are stored in buffer with empty key.
+func KeyFromAttr(attrKey string) KeyFunc {
+	return func(_ context.Context, r slog.Record) string {
+		var key string
+		r.Attrs(func(a slog.Attr) bool {
+			if a.Key == attrKey {
+				key = a.Value.Resolve().String()
+				return false
+			}
+			return true
+		})
+		return key
+	}
+}
+
+// KeyFromContext returns KeyFunc that uses value stored in context under provided context key
+// (formatted using fmt.Sprint, unless it is string) as key of the buffer. Records logged with
+// context that does not carry such value are stored in buffer with empty key.
+func KeyFromContext(ctxKey any) KeyFunc {
+	return func(ctx context.Context, _ slog.Record) string {
+		switch v := ctx.Value(ctxKey).(type) {
+		case nil:
+			return ""
+		case string:
+			return v
+		default:
+			return fmt.Sprint(v)
+		}
+	}
+}
+
+// KeyedBufferHandler is [slog.Handler] that keeps separate buffer for each key (e.g. tenant
+// or job ID) extracted from records using KeyFunc, so records of each key can be flushed or
+// discarded independently. Buffers are created on demand, using provided level, maximum number
+// of records and options.
+//
+// Buffer is kept until it is discarded, so number of keys should be bounded (e.g. tenants, not
+// requests) or limited using WithMaxKeys. Options that register handler globally or start
+// background goroutine (WithExpvar and WithWatchdog) are ignored for buffers of keys.
+type KeyedBufferHandler struct {
+	buffers *keyedBuffers
+	// segments are attributes and groups of this handler, in order they were added,
+	// applied to buffer of the key when record is handled
+	segments []segment
+	// attrs are attributes added before any group was opened, passed to KeyFunc
+	attrs []slog.Attr
+	// grouped is set once group is opened
+	grouped bool
+}
+
+// keyedBuffers holds buffers of KeyedBufferHandler, shared with all handlers derived from it.
+type keyedBuffers struct {
+	key        KeyFunc
+	leveler    slog.Leveler
+	maxRecords int
+	maxKeys    int
+	opts       []Option
+
+	// lock is held for reading while record is handled by buffer of its key, so buffer can not
+	// be discarded in the meantime, and for writing while buffers are created or discarded
+	lock     sync.RWMutex
+	handlers map[string]*BufferLogHandler
+}
+
+// NewKeyedBufferHandler creates handler that stores records in buffer of key returned by provided
+// function. Each buffer stores at most maxRecords (0 means unbound) records at or above provided level.
+func NewKeyedBufferHandler(key KeyFunc, leveler slog.Leveler, maxRecords int, opts ...Option) *KeyedBufferHandler {
+	perKey := append(slices.Clip(opts), func(o *options) {
+		o.expvarName = ""
+		o.watchdogAfter = 0
+	})
+	return &KeyedBufferHandler{buffers: &keyedBuffers{
+		key:        key,
+		leveler:    leveler,
+		maxRecords: maxRecords,
+		maxKeys:    newOptions(opts).maxKeys,
+		opts:       perKey,
+		handlers:   make(map[string]*BufferLogHandler),
+	}}
+}
+
+// get returns buffer of provided key, creating it if it does not exist. It returns
+// ErrBufferFull if maximum number of buffers was reached.
+func (b *keyedBuffers) get(key string) (*BufferLogHandler, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	h, ok := b.handlers[key]
+	if !ok {
+		if b.maxKeys > 0 && len(b.handlers) >= b.maxKeys {
+			return nil, ErrBufferFull
+		}
+		h = NewBoundBufferLogHandler(b.leveler, b.maxRecords, b.opts...)
+		b.handlers[key] = h
+	}
+	return h, nil
+}
+
+// handle passes record to buffer of provided key, creating it if it does not exist. Buffer
+// is not discarded while record is being handled, so record is never stored in buffer that
+// was already removed.
+func (b *keyedBuffers) handle(key string, handle func(h *BufferLogHandler) error) error {
+	for {
+		b.lock.RLock()
+		if h, ok := b.handlers[key]; ok {
+			defer b.lock.RUnlock()
+			return handle(h)
+		}
+		b.lock.RUnlock()
+		// buffer might be discarded again before lock is reacquired, in which case
+		// it is created again
+		if _, err := b.get(key); err != nil {
+			return err
+		}
+	}
+}
+
+// Buffer returns buffer of provided key, or nil if no record was stored under it.
+func (h *KeyedBufferHandler) Buffer(key string) *BufferLogHandler {
+	h.buffers.lock.RLock()
+	defer h.buffers.lock.RUnlock()
+	return h.buffers.handlers[key]
+}
+
+// Keys returns sorted keys of all buffers.
+func (h *KeyedBufferHandler) Keys() []string {
+	h.buffers.lock.RLock()
+	defer h.buffers.lock.RUnlock()
+	return slices.Sorted(maps.Keys(h.buffers.handlers))
+}
+
+// Flush sets provided real handler on buffer of provided key (see SetRealHandler), so its
+// records are flushed and records of the key logged afterward are passed to real handler.
+func (h *KeyedBufferHandler) Flush(ctx context.Context, key string, real slog.Handler) error {
+	bh, err := h.buffers.get(key)
+	if err != nil {
+		return err
+	}
+	return bh.SetRealHandler(ctx, real)
+}
+
+// Discard removes buffer of provided key with all its records. Records of the key logged
+// afterward are stored in new buffer. It waits for records that are being handled, so it must
+// not be called from real handler of a key or from callbacks of buffer options.
+func (h *KeyedBufferHandler) Discard(key string) {
+	h.buffers.lock.Lock()
+	defer h.buffers.lock.Unlock()
+	if bh, ok := h.buffers.handlers[key]; ok {
+		bh.Discard()
+		delete(h.buffers.handlers, key)
+	}
+}
+
+// Implementation of slog.Handler interface.
+
+// compile time check that KeyedBufferHandler implements slog.Handler interface.
+var _ slog.Handler = &KeyedBufferHandler{}
+
+// Enabled reports if records of provided level are buffered. Key of the record is not known
+// at this point, so level of real handlers of flushed keys is not considered.
+func (h *KeyedBufferHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.buffers.leveler.Level()
+}
+
+func (h *KeyedBufferHandler) Handle(ctx context.Context, r slog.Record) error {
+	keyRecord := r
+	if len(h.attrs) > 0 {
+		keyRecord = slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+		keyRecord.AddAttrs(h.attrs...)
+		r.Attrs(func(a slog.Attr) bool {
+			keyRecord.AddAttrs(a)
+			return true
+		})
+	}
+	return h.buffers.handle(h.buffers.key(ctx, keyRecord), func(bh *BufferLogHandler) error {
+		var target slog.Handler = bh
+		for _, s := range h.segments {
+			if s.attrs != nil {
+				target = target.WithAttrs(s.attrs)
+			} else {
+				target = target.WithGroup(s.group)
+			}
+		}
+		return target.Handle(ctx, r)
+	})
+}
+
+func (h *KeyedBufferHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	c := &KeyedBufferHandler{
+		buffers:  h.buffers,
+		segments: append(slices.Clip(h.segments), segment{attrs: attrs}),
+		attrs:    h.attrs,
+		grouped:  h.grouped,
+	}
+	if !h.grouped {
+		c.attrs = append(slices.Clip(h.attrs), attrs...)
+	}
+	return c
+}
+
+func (h *KeyedBufferHandler) WithGroup(name string) slog.Handler {
+	if len(name) == 0 {
+		return h
+	}
+	return &KeyedBufferHandler{
+		buffers:  h.buffers,
+		segments: append(slices.Clip(h.segments), segment{group: name}),
+		attrs:    h.attrs,
+		grouped:  true,
+	}
+}
@@ -0,0 +1,144 @@
+package slogbuffer_test
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+type tenantKey struct{}
+
+func TestKeyedBufferHandler(t *testing.T) {
+	// given
+	h := slogbuffer.NewKeyedBufferHandler(slogbuffer.KeyFromAttr("tenant"), slog.LevelInfo, 2)
+	l := slog.New(h)
+
+	// when
+	acme := l.With("tenant", "acme").WithGroup("g1")
+	acme.Info("acme 1", "no", 1)
+	acme.Info("acme 2", "no", 2)
+	acme.Info("acme 3", "no", 3)
+	l.Info("globex 1", "tenant", "globex")
+	l.Info("no tenant")
+	l.Debug("ignored", "tenant", "initech")
+
+	// then
+	if keys := h.Keys(); !slices.Equal(keys, []string{"", "acme", "globex"}) {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+	if h.Buffer("initech") != nil {
+		t.Fatalf("expected no buffer for records below level")
+	}
+
+	// each buffer is bound separately
+	rh, reader := getSimplifiedTextHandler()
+	if err := h.Flush(context.Background(), "acme", rh); err != nil {
+		t.Fatalf("flushing: %v", err)
+	}
+	acme.Info("acme 4", "no", 4)
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 3)
+	expectMsg(t, lines[0], "acme 2")
+	expectAttr(t, lines[0], "tenant", "acme")
+	expectAttr(t, lines[0], "g1.no", "2")
+	expectMsg(t, lines[2], "acme 4")
+
+	// discarded buffer is removed, records logged afterward go to new buffer
+	h.Discard("globex")
+	if h.Buffer("globex") != nil {
+		t.Fatalf("expected buffer to be removed")
+	}
+	l.Info("globex 2", "tenant", "globex")
+	if n := h.Buffer("globex").Len(); n != 1 {
+		t.Fatalf("expected 1 record in new buffer, got %d", n)
+	}
+	if n := h.Buffer("").Len(); n != 1 {
+		t.Fatalf("expected 1 record without tenant, got %d", n)
+	}
+}
+
+func TestKeyFromContext(t *testing.T) {
+	// given
+	h := slogbuffer.NewKeyedBufferHandler(slogbuffer.KeyFromContext(tenantKey{}), slog.LevelDebug, 0)
+	l := slog.New(h)
+
+	// when
+	l.InfoContext(context.WithValue(context.Background(), tenantKey{}, "acme"), "acme msg")
+	l.InfoContext(context.WithValue(context.Background(), tenantKey{}, 42), "numeric msg")
+
+	// then
+	if keys := h.Keys(); !slices.Equal(keys, []string{"42", "acme"}) {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+}
+
+func TestKeyedBufferHandler_WithMaxKeys(t *testing.T) {
+	// given
+	h := slogbuffer.NewKeyedBufferHandler(slogbuffer.KeyFromAttr("tenant"), slog.LevelInfo, 0,
+		slogbuffer.WithMaxKeys(2), slogbuffer.WithExpvar("keyed_buffer_test"))
+	l := slog.New(h)
+
+	// when
+	l.Info("acme", "tenant", "acme")
+	l.Info("globex", "tenant", "globex")
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "initech", 0)
+	record.AddAttrs(slog.String("tenant", "initech"))
+	err := h.Handle(context.Background(), record)
+
+	// then
+	if keys := h.Keys(); !slices.Equal(keys, []string{"acme", "globex"}) {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+	if !errors.Is(err, slogbuffer.ErrBufferFull) {
+		t.Fatalf("expected ErrBufferFull for new key, got %v", err)
+	}
+	// buffers of keys are not published, since each of them would replace the previous one
+	if expvar.Get("keyed_buffer_test") != nil {
+		t.Fatalf("expected buffers of keys not to be published")
+	}
+
+	// when
+	h.Discard("acme")
+	l.Info("initech", "tenant", "initech")
+
+	// then
+	if keys := h.Keys(); !slices.Equal(keys, []string{"globex", "initech"}) {
+		t.Fatalf("unexpected keys after discard %v", keys)
+	}
+}
+
+func TestKeyedBufferHandler_ConcurrentDiscard(t *testing.T) {
+	// given
+	h := slogbuffer.NewKeyedBufferHandler(slogbuffer.KeyFromAttr("tenant"), slog.LevelInfo, 0)
+	l := slog.New(h).With("tenant", "acme")
+	var wg sync.WaitGroup
+
+	// when
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 1000 {
+				l.Info("msg")
+			}
+		}()
+	}
+	for range 100 {
+		h.Discard("acme")
+	}
+	wg.Wait()
+	h.Discard("acme")
+	l.Info("after discard")
+
+	// then
+	// record logged after discard is never stored in buffer that was already removed
+	if n := h.Buffer("acme").Len(); n != 1 {
+		t.Fatalf("expected only record logged after discard, got %d", n)
+	}
+}
@@ -0,0 +1,149 @@
+package slogbuffer
+
+import (
+	"log/slog"
+	"maps"
+	"slices"
+	"sync/atomic"
+)
+
+// leveledStorage keeps records in memory, in separate buffers (bands) for ranges of levels,
+// each with its own capacity, so chatty low level records can not evict records of higher
+// levels. Like with shardedStorage, records are numbered when they are added, so order is
+// restored when records are read. Records matching pin rules are kept in separate unbound
+// buffer, so they are never evicted.
+type leveledStorage struct {
+	// bands are sorted by level, lowest first
+	bands []levelBand
+	// pinned holds records matching any of pin rules, nil if there are no pin rules
+	pinned *buffer[sequencedRecord]
+	pin    []PinRule
+	// seq is number of the last added record
+	seq atomic.Uint64
+}
+
+// levelBand holds records at or above level and below level of the next band.
+type levelBand struct {
+	level  slog.Level
+	buffer *buffer[sequencedRecord]
+}
+
+// newLeveledStorage creates storage with band for each level in provided capacities and
+// buffer for records matching provided pin rules, which calls provided hooks (if not nil)
+// for every added, removed and evicted record.
+func newLeveledStorage(capacities map[slog.Level]int, pin []PinRule, onAdd, onRemove, onEvict func(record)) *leveledStorage {
+	s := &leveledStorage{pin: pin}
+	if len(pin) > 0 {
+		s.pinned = newSequencedBuffer(0, onAdd, onRemove, onEvict)
+	}
+	for _, level := range slices.Sorted(maps.Keys(capacities)) {
+		capacity := max(capacities[level], 0)
+		s.bands = append(s.bands, levelBand{level: level, buffer: newSequencedBuffer(capacity, onAdd, onRemove, onEvict)})
+	}
+	return s
+}
+
+func (s *leveledStorage) Add(rec record) error {
+	for _, pin := range s.pin {
+		if pin(rec.Record) {
+			s.pinned.Add(sequencedRecord{record: rec, seq: s.seq.Add(1)})
+			return nil
+		}
+	}
+
+	// records below level of the lowest band go to the lowest band
+	band := s.bands[0]
+	for _, b := range s.bands[1:] {
+		if rec.Level < b.level {
+			break
+		}
+		band = b
+	}
+	band.buffer.Add(sequencedRecord{record: rec, seq: s.seq.Add(1)})
+	return nil
+}
+
+func (s *leveledStorage) Take() []record {
+	return mergeSequenced(s.buffers(), func(b *buffer[sequencedRecord]) []sequencedRecord { return b.Take() })
+}
+
+func (s *leveledStorage) Snapshot() []record {
+	return mergeSequenced(s.buffers(), func(b *buffer[sequencedRecord]) []sequencedRecord { return b.Snapshot() })
+}
+
+func (s *leveledStorage) Head(n int) []record {
+	records := s.Snapshot()
+	return records[:min(max(n, 0), len(records))]
+}
+
+func (s *leveledStorage) Tail(n int) []record {
+	records := s.Snapshot()
+	return records[len(records)-min(max(n, 0), len(records)):]
+}
+
+func (s *leveledStorage) PopOldest() (record, bool) {
+	return popSequenced(s.buffers())
+}
+
+func (s *leveledStorage) Clear() {
+	for _, b := range s.buffers() {
+		b.Clear()
+	}
+}
+
+func (s *leveledStorage) Len() int {
+	total := 0
+	for _, b := range s.buffers() {
+		total += b.Len()
+	}
+	return total
+}
+
+// Cap returns maximum number of records across all bands (not counting pinned records), 0 if
+// any of bands is not limited.
+func (s *leveledStorage) Cap() int {
+	total := 0
+	for _, band := range s.bands {
+		capacity := band.buffer.Cap()
+		if capacity == 0 {
+			return 0
+		}
+		total += capacity
+	}
+	return total
+}
+
+// resize changes capacity of the only band, which is the case when only pin rules are used.
+// Capacities of multiple bands are configured per level, so they can not be resized.
+func (s *leveledStorage) resize(maxRecords int) error {
+	if len(s.bands) != 1 {
+		return ErrResizeNotSupported
+	}
+	s.bands[0].buffer.resize(maxRecords)
+	return nil
+}
+
+// buffers returns buffers of all bands and buffer of pinned records.
+func (s *leveledStorage) buffers() []*buffer[sequencedRecord] {
+	res := make([]*buffer[sequencedRecord], 0, len(s.bands)+1)
+	for _, band := range s.bands {
+		res = append(res, band.buffer)
+	}
+	if s.pinned != nil {
+		res = append(res, s.pinned)
+	}
+	return res
+}
+
+// evictWhile evicts the oldest records that are not pinned.
+func (s *leveledStorage) evictWhile(evict func(oldest record) bool) {
+	bands := make([]*buffer[sequencedRecord], 0, len(s.bands))
+	for _, band := range s.bands {
+		bands = append(bands, band.buffer)
+	}
+	evictSequenced(bands, evict)
+}
+
+func (s *leveledStorage) removeIf(remove func(rec record) bool) {
+	removeSequenced(s.buffers(), remove)
+}
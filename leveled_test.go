@@ -0,0 +1,70 @@
+package slogbuffer_test
+
+import (
+	"fmt"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"testing"
+)
+
+func TestBufferLogHandler_WithLevelCapacity(t *testing.T) {
+	// given
+	h := slogbuffer.NewBoundBufferLogHandler(slog.LevelDebug, 1,
+		slogbuffer.WithLevelCapacity(slog.LevelDebug, 3),
+		slogbuffer.WithLevelCapacity(slog.LevelInfo, 2),
+		slogbuffer.WithLevelCapacity(slog.LevelWarn, 2),
+	)
+	l := slog.New(h)
+
+	// when
+	l.Warn("warn 1")
+	l.Info("info 1")
+	l.Error("error 1")
+	for i := range 100 {
+		l.Debug(fmt.Sprintf("debug %d", i))
+	}
+	l.Info("info 2")
+	l.Info("info 3")
+
+	// then
+	if h.Len() != 7 || h.Cap() != 7 {
+		t.Fatalf("expected 7 of 7 records, got %d of %d", h.Len(), h.Cap())
+	}
+	if h.Dropped() != 98 {
+		t.Fatalf("expected 98 dropped records, got %d", h.Dropped())
+	}
+
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandler(t, h, rh)
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 7)
+	for i, expected := range []string{"warn 1", "error 1", "debug 97", "debug 98", "debug 99", "info 2", "info 3"} {
+		expectMsg(t, lines[i], expected)
+	}
+}
+
+func TestBufferLogHandler_WithLevelCapacity_Order(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug,
+		slogbuffer.WithLevelCapacity(slog.LevelInfo, 0),
+		slogbuffer.WithLevelCapacity(slog.LevelError, 0),
+	)
+	l := slog.New(h)
+
+	// when
+	l.Debug("debug")
+	l.Error("error")
+	l.Info("info")
+	l.Warn("warn")
+
+	// then
+	if h.Cap() != 0 {
+		t.Fatalf("expected unbound buffer, got capacity %d", h.Cap())
+	}
+	records := h.Records()
+	for i, expected := range []string{"debug", "error", "info", "warn"} {
+		if records[i].Message != expected {
+			t.Fatalf("expected %q at position %d, got %q", expected, i, records[i].Message)
+		}
+	}
+}
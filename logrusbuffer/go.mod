@@ -0,0 +1,10 @@
+module github.com/delicb/slogbuffer/logrusbuffer
+
+go 1.23.1
+
+require (
+	github.com/delicb/slogbuffer v0.0.0-20261016134606-73c960bce6bf
+	github.com/sirupsen/logrus v1.9.3
+)
+
+require golang.org/x/sys v0.0.0-20220715151400-c0bba94af5f8 // indirect
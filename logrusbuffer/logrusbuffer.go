@@ -0,0 +1,110 @@
+// Package logrusbuffer provides [slog.Handler] that writes records to logrus logger, so
+// records buffered using [slogbuffer.BufferLogHandler] can be flushed to existing logrus
+// based infrastructure (formatters, hooks and outputs).
+//
+// It is a separate module, so slogbuffer itself does not depend on logrus.
+package logrusbuffer
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"log/slog"
+)
+
+// Handler is [slog.Handler] that writes records to [logrus.Logger]. Levels are mapped to the
+// closest logrus level at or below them (e.g. slog.LevelWarn+2 becomes logrus.WarnLevel and
+// levels below slog.LevelDebug become logrus.TraceLevel), never to fatal or panic levels, so
+// flushing records does not exit or panic. Attributes become logrus fields and, since logrus
+// fields are flat, groups are flattened into keys joined with dot (e.g. "req.id").
+//
+// Time of the record is preserved, but caller reported by logrus (if ReportCaller is enabled)
+// is the one that called Handle, not the one that logged the record.
+type Handler struct {
+	logger *logrus.Logger
+	// fields are attributes added using WithAttrs, with keys already prefixed by their groups
+	fields logrus.Fields
+	// prefix is prepended to keys of attributes, it is empty or ends with separator
+	prefix string
+}
+
+// NewHandler creates handler that writes records to provided logger.
+func NewHandler(logger *logrus.Logger) *Handler {
+	return &Handler{logger: logger}
+}
+
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.IsLevelEnabled(logrusLevel(level))
+}
+
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	level := logrusLevel(r.Level)
+	if !h.logger.IsLevelEnabled(level) {
+		return nil
+	}
+	fields := make(logrus.Fields, len(h.fields)+r.NumAttrs())
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addField(fields, h.prefix, a)
+		return true
+	})
+	h.logger.WithContext(ctx).WithTime(r.Time).WithFields(fields).Log(level, r.Message)
+	return nil
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	fields := make(logrus.Fields, len(h.fields)+len(attrs))
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	for _, a := range attrs {
+		addField(fields, h.prefix, a)
+	}
+	return &Handler{logger: h.logger, fields: fields, prefix: h.prefix}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &Handler{logger: h.logger, fields: h.fields, prefix: h.prefix + name + "."}
+}
+
+// logrusLevel returns logrus level closest to provided slog level, not above it.
+func logrusLevel(level slog.Level) logrus.Level {
+	switch {
+	case level < slog.LevelDebug:
+		return logrus.TraceLevel
+	case level < slog.LevelInfo:
+		return logrus.DebugLevel
+	case level < slog.LevelWarn:
+		return logrus.InfoLevel
+	case level < slog.LevelError:
+		return logrus.WarnLevel
+	default:
+		return logrus.ErrorLevel
+	}
+}
+
+// addField adds field representing provided attribute, flattening groups and following slog
+// rules for empty attributes and groups.
+func addField(fields logrus.Fields, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			addField(fields, prefix, ga)
+		}
+		return
+	}
+	fields[prefix+a.Key] = a.Value.Any()
+}
@@ -0,0 +1,60 @@
+package logrusbuffer_test
+
+import (
+	"context"
+	"errors"
+	"github.com/delicb/slogbuffer"
+	"github.com/delicb/slogbuffer/logrusbuffer"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHandler(t *testing.T) {
+	// given
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(logrus.DebugLevel)
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug - 4)
+	l := slog.New(h).With("service", "api").WithGroup("req").With("id", 7)
+	err := errors.New("boom")
+
+	// when
+	l.Log(context.Background(), slog.LevelDebug-4, "not enabled in logrus")
+	l.Debug("debug", "path", "/", slog.Group("user", "name", "alice"))
+	l.WithGroup("empty").Log(context.Background(), slog.LevelWarn+2, "warn")
+	l.Log(context.Background(), slog.LevelError+4, "critical", "err", err)
+	logged := time.Now()
+	if err := h.SetRealHandler(context.Background(), logrusbuffer.NewHandler(logger)); err != nil {
+		t.Fatalf("failed to set real handler: %v", err)
+	}
+
+	// then
+	entries := hook.AllEntries()
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	for i, expected := range []struct {
+		msg    string
+		level  logrus.Level
+		fields logrus.Fields
+	}{
+		{"debug", logrus.DebugLevel, logrus.Fields{"service": "api", "req.id": int64(7), "req.path": "/",
+			"req.user.name": "alice"}},
+		{"warn", logrus.WarnLevel, logrus.Fields{"service": "api", "req.id": int64(7)}},
+		{"critical", logrus.ErrorLevel, logrus.Fields{"service": "api", "req.id": int64(7), "req.err": err}},
+	} {
+		entry := entries[i]
+		if entry.Message != expected.msg || entry.Level != expected.level {
+			t.Fatalf("expected %q at %s, got %q at %s", expected.msg, expected.level, entry.Message, entry.Level)
+		}
+		if !reflect.DeepEqual(entry.Data, expected.fields) {
+			t.Fatalf("expected fields %v of %q, got %v", expected.fields, expected.msg, entry.Data)
+		}
+		if entry.Time.After(logged) {
+			t.Fatalf("expected time of record to be preserved, got %s", entry.Time)
+		}
+	}
+}
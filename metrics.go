@@ -0,0 +1,28 @@
+package slogbuffer
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Metrics receives measurements of handler operation, so they can be exported to monitoring
+// system (see WithMetrics). Implementations must be safe for concurrent use and should be
+// cheap, since they are called on logging path.
+type Metrics interface {
+	// Buffered is called for every record stored in the buffer.
+	Buffered(ctx context.Context, level slog.Level)
+	// Dropped is called for every record evicted from bound buffer to make space for newer
+	// records or skipped because of sampling.
+	Dropped(ctx context.Context, level slog.Level)
+	// Flushed is called after buffered records were flushed to real handler, with number of
+	// flushed records, number of records real handler failed to handle and duration of flush.
+	Flushed(ctx context.Context, records, failed int, duration time.Duration)
+}
+
+// noMetrics is Metrics implementation that ignores all measurements.
+type noMetrics struct{}
+
+func (noMetrics) Buffered(context.Context, slog.Level)             {}
+func (noMetrics) Dropped(context.Context, slog.Level)              {}
+func (noMetrics) Flushed(context.Context, int, int, time.Duration) {}
@@ -0,0 +1,75 @@
+package slogbuffer_test
+
+import (
+	"context"
+	"github.com/delicb/slogbuffer"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingMetrics is slogbuffer.Metrics implementation that records all measurements.
+type recordingMetrics struct {
+	lock     sync.Mutex
+	buffered map[slog.Level]int
+	dropped  map[slog.Level]int
+	flushes  [][2]int
+}
+
+func newRecordingMetrics() *recordingMetrics {
+	return &recordingMetrics{buffered: map[slog.Level]int{}, dropped: map[slog.Level]int{}}
+}
+
+func (m *recordingMetrics) Buffered(_ context.Context, level slog.Level) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.buffered[level]++
+}
+
+func (m *recordingMetrics) Dropped(_ context.Context, level slog.Level) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.dropped[level]++
+}
+
+func (m *recordingMetrics) Flushed(_ context.Context, records, failed int, duration time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if duration < 0 {
+		panic("negative flush duration")
+	}
+	m.flushes = append(m.flushes, [2]int{records, failed})
+}
+
+func TestWithMetrics(t *testing.T) {
+	// given
+	metrics := newRecordingMetrics()
+	h := slogbuffer.NewBoundBufferLogHandler(slog.LevelDebug, 3,
+		slogbuffer.WithMetrics(metrics), slogbuffer.WithSampling(slog.LevelDebug, 2))
+	l := slog.New(h)
+
+	// when
+	l.Debug("debug msg")
+	l.Debug("sampled out")
+	l.Info("info msg")
+	l.Info("info msg")
+	l.Warn("evicts debug msg")
+	err := h.SetRealHandler(context.Background(), newFailingHandler(slog.NewTextHandler(io.Discard, nil), 1))
+
+	// then
+	if err == nil {
+		t.Fatalf("expected flush error")
+	}
+	if metrics.buffered[slog.LevelDebug] != 1 || metrics.buffered[slog.LevelInfo] != 2 || metrics.buffered[slog.LevelWarn] != 1 {
+		t.Fatalf("unexpected buffered records %v", metrics.buffered)
+	}
+	if metrics.dropped[slog.LevelDebug] != 2 || len(metrics.dropped) != 1 {
+		t.Fatalf("unexpected dropped records %v", metrics.dropped)
+	}
+	// only flush with records is reported
+	if len(metrics.flushes) != 1 || metrics.flushes[0] != [2]int{3, 1} {
+		t.Fatalf("unexpected flushes %v", metrics.flushes)
+	}
+}
@@ -0,0 +1,162 @@
+package slogbuffer
+
+import (
+	"bufio"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// MiddlewareOption configures optional behaviour of HTTP middleware created using Middleware.
+type MiddlewareOption func(*middlewareOptions)
+
+// middlewareOptions holds optional configuration of HTTP middleware.
+type middlewareOptions struct {
+	// minStatus is minimal response status code for which records are flushed
+	minStatus int
+	// latencyThreshold is request duration above which records are flushed, 0 if disabled
+	latencyThreshold time.Duration
+	// maxRecords is maximum number of records buffered per request, 0 if unbound
+	maxRecords int
+	// correlationKey and correlationID configure correlation attribute of request records
+	correlationKey string
+	correlationID  func(*http.Request) string
+	// clock measures request latency and is used by buffer handler of each request
+	clock Clock
+}
+
+// WithFlushStatus configures minimal response status code for which request records are
+// flushed. Default is 500.
+func WithFlushStatus(minStatus int) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.minStatus = minStatus
+	}
+}
+
+// WithLatencyThreshold makes middleware flush records of requests that took longer than
+// provided threshold, regardless of response status.
+func WithLatencyThreshold(threshold time.Duration) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.latencyThreshold = threshold
+	}
+}
+
+// WithRequestBufferSize limits number of records buffered per request. Default is unbound.
+func WithRequestBufferSize(maxRecords int) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.maxRecords = maxRecords
+	}
+}
+
+// WithRequestCorrelation adds attribute with provided key and value returned by id function
+// (e.g. value of X-Request-ID header) to flushed records of each request, so records of
+// requests flushed concurrently remain attributable.
+func WithRequestCorrelation(key string, id func(r *http.Request) string) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.correlationKey = key
+		o.correlationID = id
+	}
+}
+
+// WithRequestClock sets clock used to measure request latency (see WithLatencyThreshold) and
+// by buffer handler of each request (see WithClock) instead of system clock, so they can be
+// tested without sleeping.
+func WithRequestClock(clock Clock) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		if clock != nil {
+			o.clock = clock
+		}
+	}
+}
+
+// Middleware returns [net/http] middleware that buffers records of each request and flushes
+// them to real handler only if request failed (response status is 500 or above by default,
+// or handler panicked) or took too long (see WithLatencyThreshold). Otherwise, records are
+// discarded. This makes it easy to have debug logs only for failed requests.
+//
+// Buffer handler of the request is stored in request context (see NewContext), so request
+// handlers can use LoggerFromContext or ContextHandler to log records to it.
+func Middleware(real slog.Handler, leveler slog.Leveler, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	o := &middlewareOptions{minStatus: http.StatusInternalServerError, clock: systemClock{}}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handlerOpts := []Option{WithClock(o.clock)}
+			if o.correlationID != nil {
+				handlerOpts = append(handlerOpts, WithCorrelationAttrs(slog.String(o.correlationKey, o.correlationID(r))))
+			}
+			h := NewBoundBufferLogHandler(leveler, o.maxRecords, handlerOpts...)
+			sw := &statusWriter{ResponseWriter: w}
+			start := o.clock.Now()
+
+			defer func() {
+				p := recover()
+				if p != nil || sw.status() >= o.minStatus ||
+					(o.latencyThreshold > 0 && o.clock.Now().Sub(start) > o.latencyThreshold) {
+					// records that real handler fails to handle are kept as dead letters,
+					// there is no one to report error to at this point
+					_ = h.SetRealHandler(r.Context(), real)
+				} else {
+					h.Discard()
+				}
+				if p != nil {
+					panic(p)
+				}
+			}()
+
+			next.ServeHTTP(sw, r.WithContext(NewContext(r.Context(), h)))
+		})
+	}
+}
+
+// statusWriter is [http.ResponseWriter] that remembers response status code.
+type statusWriter struct {
+	http.ResponseWriter
+	code int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush sends buffered data to the client, for handlers that stream responses (e.g. server-sent
+// events) and type-assert [http.Flusher].
+func (w *statusWriter) Flush() {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack lets handler take over the connection (e.g. for websockets). It returns error wrapping
+// [http.ErrNotSupported] if underlying response writer does not support it.
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Unwrap returns underlying response writer, for [http.ResponseController].
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// status returns response status code, which is 200 if it was not explicitly written.
+func (w *statusWriter) status() int {
+	if w.code == 0 {
+		return http.StatusOK
+	}
+	return w.code
+}
@@ -0,0 +1,126 @@
+package slogbuffer_test
+
+import (
+	"errors"
+	"github.com/delicb/slogbuffer"
+	"github.com/delicb/slogbuffer/slogbuffertest"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMiddleware(t *testing.T) {
+	rh, reader := getSimplifiedTextHandler()
+	clock := slogbuffertest.NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	handler := slogbuffer.Middleware(rh, slog.LevelDebug, slogbuffer.WithLatencyThreshold(50*time.Millisecond),
+		slogbuffer.WithRequestClock(clock))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			slogbuffer.LoggerFromContext(r.Context()).Debug("handling request", "path", r.URL.Path)
+			switch r.URL.Path {
+			case "/fail":
+				w.WriteHeader(http.StatusInternalServerError)
+			case "/slow":
+				clock.Advance(100 * time.Millisecond)
+			case "/panic":
+				panic("request panicked")
+			default:
+				_, _ = w.Write([]byte("ok"))
+			}
+		}),
+	)
+
+	for _, tc := range []struct {
+		path    string
+		flushed bool
+	}{
+		{path: "/ok", flushed: false},
+		{path: "/fail", flushed: true},
+		{path: "/slow", flushed: true},
+		{path: "/panic", flushed: true},
+	} {
+		t.Run(tc.path, func(t *testing.T) {
+			// given
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			w := httptest.NewRecorder()
+
+			// when
+			var recovered any
+			func() {
+				defer func() { recovered = recover() }()
+				handler.ServeHTTP(w, req)
+			}()
+
+			// then
+			if (recovered != nil) != (tc.path == "/panic") {
+				t.Fatalf("expected panic to be propagated only for failing handler, got: %v", recovered)
+			}
+			lines := getLines(t, reader)
+			if !tc.flushed {
+				expectLinesNo(t, lines, 0)
+				return
+			}
+			expectLinesNo(t, lines, 1)
+			expectMsg(t, lines[0], "handling request")
+			expectAttr(t, lines[0], "path", tc.path)
+		})
+	}
+}
+
+func TestMiddleware_WithRequestCorrelation(t *testing.T) {
+	// given
+	rh, reader := getSimplifiedTextHandler()
+	handler := slogbuffer.Middleware(rh, slog.LevelDebug,
+		slogbuffer.WithRequestCorrelation("request_id", func(r *http.Request) string {
+			return r.Header.Get("X-Request-ID")
+		}),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slogbuffer.LoggerFromContext(r.Context()).Debug("handling request")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "abc")
+
+	// when
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	// then
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 1)
+	expectAttr(t, lines[0], "request_id", "abc")
+}
+
+func TestMiddleware_ResponseWriterInterfaces(t *testing.T) {
+	// given
+	rh, _ := getSimplifiedTextHandler()
+	var flusher, hijacker bool
+	var hijackErr error
+	handler := slogbuffer.Middleware(rh, slog.LevelDebug)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var f http.Flusher
+			f, flusher = w.(http.Flusher)
+			if flusher {
+				_, _ = w.Write([]byte("event"))
+				f.Flush()
+			}
+			var h http.Hijacker
+			if h, hijacker = w.(http.Hijacker); hijacker {
+				_, _, hijackErr = h.Hijack()
+			}
+		}),
+	)
+	w := httptest.NewRecorder()
+
+	// when
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	// then
+	if !flusher || !w.Flushed {
+		t.Fatalf("expected response writer to implement http.Flusher and flush recorder")
+	}
+	// recorder can not be hijacked, so error is passed through
+	if !hijacker || !errors.Is(hijackErr, http.ErrNotSupported) {
+		t.Fatalf("expected response writer to implement http.Hijacker reporting unsupported hijack, got: %v", hijackErr)
+	}
+}
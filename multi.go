@@ -0,0 +1,66 @@
+package slogbuffer
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// MultiHandler is [slog.Handler] that passes records to multiple handlers (fan-out).
+// Attributes and groups are propagated to all handlers, record is passed only to handlers
+// that are enabled for its level and errors of all handlers are combined.
+//
+// It can be used standalone or as real handler of BufferLogHandler (see SetRealHandlers).
+type MultiHandler struct {
+	handlers []slog.Handler
+}
+
+// NewMultiHandler creates handler that passes records to all provided handlers.
+func NewMultiHandler(handlers ...slog.Handler) *MultiHandler {
+	return &MultiHandler{handlers: handlers}
+}
+
+// Implementation of slog.Handler interface.
+
+// compile time check that MultiHandler implements slog.Handler interface.
+var _ slog.Handler = &MultiHandler{}
+
+func (m *MultiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range m.handlers {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MultiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var handleErr error
+	for _, h := range m.handlers {
+		if !h.Enabled(ctx, r.Level) {
+			continue
+		}
+		// each handler gets its own copy, so handlers can not affect each other
+		handleErr = errors.Join(handleErr, h.Handle(ctx, r.Clone()))
+	}
+	return handleErr
+}
+
+func (m *MultiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, 0, len(m.handlers))
+	for _, h := range m.handlers {
+		handlers = append(handlers, h.WithAttrs(attrs))
+	}
+	return &MultiHandler{handlers: handlers}
+}
+
+func (m *MultiHandler) WithGroup(name string) slog.Handler {
+	if len(name) == 0 {
+		return m
+	}
+	handlers := make([]slog.Handler, 0, len(m.handlers))
+	for _, h := range m.handlers {
+		handlers = append(handlers, h.WithGroup(name))
+	}
+	return &MultiHandler{handlers: handlers}
+}
@@ -0,0 +1,42 @@
+package slogbuffer_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"testing"
+)
+
+func TestMultiHandler(t *testing.T) {
+	// given
+	first, firstReader := getSimplifiedTextHandler() // info level
+	debugWriter := new(bytes.Buffer)
+	second := slog.NewTextHandler(debugWriter, &slog.HandlerOptions{Level: slog.LevelDebug})
+	l := slog.New(slogbuffer.NewMultiHandler(first, newFailingHandler(second, 1)))
+
+	// when
+	err := l.Handler().Handle(context.Background(), newRecord(slog.LevelInfo, "first msg"))
+	l.WithGroup("g1").With("common", "attr").Debug("debug msg")
+	l.Info("info msg")
+
+	// then
+	if !errors.Is(err, errHandlerFailed) {
+		t.Fatalf("expected error of failing handler, got: %v", err)
+	}
+	if !l.Enabled(context.Background(), slog.LevelDebug) {
+		t.Fatalf("expected debug level to be enabled by second handler")
+	}
+
+	firstLines := getLines(t, firstReader)
+	expectLinesNo(t, firstLines, 2)
+	expectMsg(t, firstLines[0], "first msg")
+	expectMsg(t, firstLines[1], "info msg")
+
+	secondLines := getLines(t, debugWriter)
+	expectLinesNo(t, secondLines, 2)
+	expectMsg(t, secondLines[0], "debug msg")
+	expectAttr(t, secondLines[0], "g1.common", "attr")
+	expectMsg(t, secondLines[1], "info msg")
+}
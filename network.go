@@ -0,0 +1,252 @@
+package slogbuffer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+)
+
+// errNotConnected is returned when record is written while network connection is down.
+var errNotConnected = errors.New("slogbuffer: not connected")
+
+// NetworkOption configures optional behaviour of NetworkHandler.
+type NetworkOption func(*networkOptions)
+
+// networkOptions holds optional configuration of NetworkHandler.
+type networkOptions struct {
+	format       func(io.Writer) slog.Handler
+	reconnect    RetryPolicy
+	dialTimeout  time.Duration
+	writeTimeout time.Duration
+	bufferOpts   []Option
+}
+
+// WithNetworkFormat sets function that creates handler formatting records written to the
+// connection, e.g. one creating slog.TextHandler. Default is slog.JSONHandler with debug level,
+// so records are written as newline delimited JSON.
+func WithNetworkFormat(format func(io.Writer) slog.Handler) NetworkOption {
+	return func(o *networkOptions) {
+		o.format = format
+	}
+}
+
+// WithReconnectPolicy sets backoff between attempts to connect to the sink. MaxAttempts of
+// the policy is ignored, since handler keeps reconnecting until it is closed. Default is
+// backoff starting at 100ms and growing up to 10s.
+func WithReconnectPolicy(policy RetryPolicy) NetworkOption {
+	return func(o *networkOptions) {
+		o.reconnect = policy
+	}
+}
+
+// WithDialTimeout sets maximum time single connection attempt can take. Default is 5s.
+func WithDialTimeout(timeout time.Duration) NetworkOption {
+	return func(o *networkOptions) {
+		o.dialTimeout = timeout
+	}
+}
+
+// WithWriteTimeout sets maximum time writing single record can take, after which connection
+// is considered broken. Zero (default) means no timeout.
+func WithWriteTimeout(timeout time.Duration) NetworkOption {
+	return func(o *networkOptions) {
+		o.writeTimeout = timeout
+	}
+}
+
+// WithNetworkBufferOptions sets options of buffer that holds records while connection is down.
+func WithNetworkBufferOptions(opts ...Option) NetworkOption {
+	return func(o *networkOptions) {
+		o.bufferOpts = append(o.bufferOpts, opts...)
+	}
+}
+
+// NetworkHandler is [slog.Handler] that writes records to TCP or Unix socket sink (e.g. log
+// collector). While connection is down (including before it is established for the first
+// time), records are buffered and handler keeps reconnecting in background. Once connection
+// is established, records that failed to be written are delivered first, followed by records
+// buffered in the meantime, so the sink receives them in order they were logged.
+//
+// All methods of BufferLogHandler (Len, DeadLetters, Records...) are available, but real
+// handler is managed by NetworkHandler, so SetRealHandler, Pause and Resume should not be
+// called. Handlers derived using WithAttrs and WithGroup are BufferLogHandler instances
+// sharing the connection. Close has to be called to stop background goroutine.
+//
+// Record that is being written when connection breaks might be partially written, so sink
+// might receive it twice, once truncated. Also, TCP might report broken connection only on
+// write following the one that was lost, so records are not guaranteed to be delivered
+// exactly once.
+type NetworkHandler struct {
+	*BufferLogHandler
+	network string
+	address string
+	opts    *networkOptions
+	writer  *networkWriter
+
+	// reconnect signals background goroutine that connection has to be (re)established
+	reconnect chan struct{}
+	// stop is cancelled by Close, done is closed when background goroutine exits
+	stop context.CancelFunc
+	done chan struct{}
+}
+
+// NewNetworkHandler creates handler that writes records to provided network ("tcp", "unix"...)
+// address, buffering up to maxRecords (0 means unbound) records while connection is down.
+// Connection is established in background, so handler can be used right away.
+func NewNetworkHandler(network, address string, leveler slog.Leveler, maxRecords int, opts ...NetworkOption) *NetworkHandler {
+	o := &networkOptions{
+		format: func(w io.Writer) slog.Handler {
+			return slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug})
+		},
+		reconnect:   RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 10 * time.Second},
+		dialTimeout: 5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	h := &NetworkHandler{
+		BufferLogHandler: NewBoundBufferLogHandler(leveler, maxRecords, o.bufferOpts...),
+		network:          network,
+		address:          address,
+		opts:             o,
+		reconnect:        make(chan struct{}, 1),
+		stop:             stop,
+		done:             make(chan struct{}),
+	}
+	h.writer = &networkWriter{timeout: o.writeTimeout, disconnected: h.disconnected}
+
+	// real handler writes to whatever connection is current, so it is set only once and
+	// handler is paused until connection is established
+	_ = h.SetRealHandler(ctx, o.format(h.writer))
+	h.disconnected()
+	go h.run(ctx)
+	return h
+}
+
+// Connected reports if handler is currently connected to the sink.
+func (h *NetworkHandler) Connected() bool {
+	return h.writer.connected()
+}
+
+// Close stops reconnecting and closes connection to the sink. Records that were not delivered
+// stay buffered and can still be inspected.
+func (h *NetworkHandler) Close() error {
+	h.stop()
+	<-h.done
+	return h.writer.close()
+}
+
+// disconnected makes handler buffer records and asks background goroutine to reconnect.
+func (h *NetworkHandler) disconnected() {
+	h.Pause()
+	select {
+	case h.reconnect <- struct{}{}:
+	default:
+		// reconnect is already pending
+	}
+}
+
+// run (re)establishes connection whenever it is lost and replays records that were not
+// delivered while it was down, until handler is closed.
+func (h *NetworkHandler) run(ctx context.Context) {
+	defer close(h.done)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.reconnect:
+		}
+
+		conn, err := h.dial(ctx)
+		if err != nil {
+			return
+		}
+		// handler might have resumed since connection was lost, but records must not
+		// be written to new connection before the backlog
+		h.Pause()
+		h.writer.connect(conn)
+
+		// records that failed to be written go first, then records buffered while paused.
+		// If connection breaks again, writer asks for reconnect and records stay buffered.
+		if h.RetryFlush(ctx, RetryPolicy{}) == nil {
+			_ = h.Resume(ctx)
+		}
+	}
+}
+
+// dial connects to the sink, retrying with backoff until it succeeds or context is done.
+func (h *NetworkHandler) dial(ctx context.Context) (net.Conn, error) {
+	dialer := net.Dialer{Timeout: h.opts.dialTimeout}
+	for attempt := 0; ; attempt++ {
+		conn, err := dialer.DialContext(ctx, h.network, h.address)
+		if err == nil {
+			return conn, nil
+		}
+
+		timer := time.NewTimer(h.opts.reconnect.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// networkWriter writes to current connection of NetworkHandler. Connection is dropped on
+// first failed write and disconnected function is called.
+type networkWriter struct {
+	lock         sync.Mutex
+	conn         net.Conn
+	timeout      time.Duration
+	disconnected func()
+}
+
+func (w *networkWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.conn == nil {
+		w.disconnected()
+		return 0, errNotConnected
+	}
+	if w.timeout > 0 {
+		_ = w.conn.SetWriteDeadline(time.Now().Add(w.timeout))
+	}
+	n, err := w.conn.Write(p)
+	if err != nil {
+		_ = w.conn.Close()
+		w.conn = nil
+		w.disconnected()
+	}
+	return n, err
+}
+
+// connect makes writer use provided connection.
+func (w *networkWriter) connect(conn net.Conn) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.conn = conn
+}
+
+func (w *networkWriter) connected() bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.conn != nil
+}
+
+func (w *networkWriter) close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
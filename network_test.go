@@ -0,0 +1,117 @@
+package slogbuffer_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// readMessages reads n newline delimited JSON records from provided connection and returns their messages.
+func readMessages(t *testing.T, r *bufio.Reader, n int) []string {
+	t.Helper()
+	var msgs []string
+	for range n {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			t.Fatalf("failed to read record: %v", err)
+		}
+		var rec struct{ Msg string }
+		if err := json.Unmarshal(line, &rec); err != nil {
+			t.Fatalf("failed to parse record %q: %v", line, err)
+		}
+		msgs = append(msgs, rec.Msg)
+	}
+	return msgs
+}
+
+func expectMessages(t *testing.T, got []string, expected ...string) {
+	t.Helper()
+	if len(got) != len(expected) {
+		t.Fatalf("expected messages %v, got %v", expected, got)
+	}
+	for i := range got {
+		if got[i] != expected[i] {
+			t.Fatalf("expected messages %v, got %v", expected, got)
+		}
+	}
+}
+
+func accept(t *testing.T, ln net.Listener) net.Conn {
+	t.Helper()
+	_ = ln.(interface{ SetDeadline(time.Time) error }).SetDeadline(time.Now().Add(5 * time.Second))
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("failed to accept connection: %v", err)
+	}
+	return conn
+}
+
+func TestNetworkHandler_BuffersUntilConnected(t *testing.T) {
+	// given
+	addr := filepath.Join(t.TempDir(), "sink.sock")
+	h := slogbuffer.NewNetworkHandler("unix", addr, slog.LevelDebug, 0,
+		slogbuffer.WithReconnectPolicy(slogbuffer.RetryPolicy{InitialBackoff: 10 * time.Millisecond}))
+	defer h.Close()
+	l := slog.New(h)
+
+	// when
+	l.Debug("first")
+	l.With("attr", 1).Info("second")
+
+	// then
+	if h.Len() != 2 || h.Connected() {
+		t.Fatalf("expected 2 buffered records while sink is down, got %d", h.Len())
+	}
+
+	// when sink comes up
+	ln, err := net.Listen("unix", addr)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer ln.Close()
+	conn := accept(t, ln)
+	defer conn.Close()
+
+	// then backlog is replayed and live records follow it
+	r := bufio.NewReader(conn)
+	expectMessages(t, readMessages(t, r, 2), "first", "second")
+	l.Info("third")
+	expectMessages(t, readMessages(t, r, 1), "third")
+}
+
+func TestNetworkHandler_Reconnect(t *testing.T) {
+	// given
+	addr := filepath.Join(t.TempDir(), "sink.sock")
+	ln, err := net.Listen("unix", addr)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer ln.Close()
+	h := slogbuffer.NewNetworkHandler("unix", addr, slog.LevelDebug, 0,
+		slogbuffer.WithReconnectPolicy(slogbuffer.RetryPolicy{InitialBackoff: 10 * time.Millisecond}))
+	defer h.Close()
+	l := slog.New(h)
+
+	conn := accept(t, ln)
+	l.Info("before")
+	expectMessages(t, readMessages(t, bufio.NewReader(conn), 1), "before")
+
+	// when connection breaks
+	_ = conn.Close()
+	l.Info("down 1")
+	l.Info("down 2")
+	l.Info("down 3")
+
+	// then records are replayed on new connection, in order
+	conn = accept(t, ln)
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	expectMessages(t, readMessages(t, r, 3), "down 1", "down 2", "down 3")
+	l.Info("after")
+	expectMessages(t, readMessages(t, r, 1), "after")
+}
@@ -0,0 +1,7 @@
+//go:build !race
+
+package slogbuffer_test
+
+// raceEnabled reports if tests are built with race detector, which makes allocation counts
+// unreliable.
+const raceEnabled = false
@@ -0,0 +1,79 @@
+package slogbuffer
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Observer receives notifications about lifecycle events of the handler (see WithObserver),
+// so external code can instrument or react to them. Records passed to observer are
+// self-contained, attributes and groups of the logger are folded into attributes of records.
+// Implementations must be safe for concurrent use and must not log using observed handler,
+// since some notifications are delivered while buffer is locked. NopObserver can be embedded
+// by implementations interested only in some of the events.
+type Observer interface {
+	// OnBuffered is called for every record stored in the buffer.
+	OnBuffered(r slog.Record)
+	// OnDropped is called for every record evicted from bound buffer to make space for newer
+	// records or skipped because of sampling.
+	OnDropped(r slog.Record)
+	// OnFlushStart is called before provided number of buffered records is flushed to real handler.
+	OnFlushStart(n int)
+	// OnFlushEnd is called after flush finished, with error returned by flush (if any).
+	OnFlushEnd(err error)
+	// OnHandoff is called when handler starts passing records to provided real handler directly,
+	// after SetRealHandler or Resume.
+	OnHandoff(real slog.Handler)
+}
+
+// NopObserver is Observer that ignores all events.
+type NopObserver struct{}
+
+func (NopObserver) OnBuffered(slog.Record) {}
+func (NopObserver) OnDropped(slog.Record)  {}
+func (NopObserver) OnFlushStart(int)       {}
+func (NopObserver) OnFlushEnd(error)       {}
+func (NopObserver) OnHandoff(slog.Handler) {}
+
+// buffered notifies metrics and observers that record was buffered.
+func (o *options) buffered(ctx context.Context, rec record) {
+	o.metrics.Buffered(ctx, rec.Level)
+	if len(o.observers) > 0 {
+		r := rec.materialize()
+		for _, observer := range o.observers {
+			observer.OnBuffered(r)
+		}
+	}
+}
+
+// dropped notifies metrics and observers that record was dropped.
+func (o *options) dropped(ctx context.Context, rec record) {
+	o.metrics.Dropped(ctx, rec.Level)
+	if len(o.observers) > 0 {
+		r := rec.materialize()
+		for _, observer := range o.observers {
+			observer.OnDropped(r)
+		}
+	}
+}
+
+// flushStarted notifies observers that provided number of records is being flushed.
+func (o *options) flushStarted(n int) {
+	for _, observer := range o.observers {
+		observer.OnFlushStart(n)
+	}
+}
+
+// flushEnded notifies observers that flush finished with provided error.
+func (o *options) flushEnded(err error) {
+	for _, observer := range o.observers {
+		observer.OnFlushEnd(err)
+	}
+}
+
+// handedOff notifies observers that records are passed to provided real handler directly.
+func (o *options) handedOff(real slog.Handler) {
+	for _, observer := range o.observers {
+		observer.OnHandoff(real)
+	}
+}
@@ -0,0 +1,88 @@
+package slogbuffer_test
+
+import (
+	"context"
+	"errors"
+	"github.com/delicb/slogbuffer"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+)
+
+// recordingObserver records all events it is notified about.
+type recordingObserver struct {
+	slogbuffer.NopObserver
+	lock     sync.Mutex
+	events   []string
+	buffered []slog.Record
+	dropped  []slog.Record
+	flushErr error
+	real     slog.Handler
+}
+
+func (o *recordingObserver) event(name string) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.events = append(o.events, name)
+}
+
+func (o *recordingObserver) OnBuffered(r slog.Record) {
+	o.event("buffered")
+	o.buffered = append(o.buffered, r)
+}
+
+func (o *recordingObserver) OnDropped(r slog.Record) {
+	o.event("dropped")
+	o.dropped = append(o.dropped, r)
+}
+
+func (o *recordingObserver) OnFlushStart(n int) {
+	o.event("flush start")
+}
+
+func (o *recordingObserver) OnFlushEnd(err error) {
+	o.event("flush end")
+	o.flushErr = err
+}
+
+func (o *recordingObserver) OnHandoff(real slog.Handler) {
+	o.event("handoff")
+	o.real = real
+}
+
+func TestWithObserver(t *testing.T) {
+	// given
+	observer := new(recordingObserver)
+	h := slogbuffer.NewBoundBufferLogHandler(slog.LevelDebug, 1, slogbuffer.WithObserver(observer))
+	l := slog.New(h).With("common", "attr")
+
+	// when
+	l.Debug("evicted msg")
+	l.Info("info msg")
+	real := newFailingHandler(slog.NewTextHandler(io.Discard, nil), 1)
+	err := h.SetRealHandler(context.Background(), real)
+
+	// then
+	// oldest record is evicted while new one is being buffered
+	expected := []string{"buffered", "dropped", "buffered", "flush start", "flush end", "handoff"}
+	if len(observer.events) != len(expected) {
+		t.Fatalf("expected events %v, got %v", expected, observer.events)
+	}
+	for i := range expected {
+		if observer.events[i] != expected[i] {
+			t.Fatalf("expected events %v, got %v", expected, observer.events)
+		}
+	}
+	// records are self-contained
+	expectRecordAttr(t, observer.buffered[1], "common", slog.StringValue("attr"))
+	if observer.dropped[0].Message != "evicted msg" {
+		t.Fatalf("unexpected dropped record %v", observer.dropped[0])
+	}
+	if !errors.Is(err, errHandlerFailed) || !errors.Is(observer.flushErr, errHandlerFailed) {
+		t.Fatalf("expected flush error, got %v (observed %v)", err, observer.flushErr)
+	}
+	if observer.real != real {
+		t.Fatalf("expected handoff to real handler, got %v", observer.real)
+	}
+}
@@ -0,0 +1,611 @@
+package slogbuffer
+
+import (
+	"cmp"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"runtime"
+	"time"
+)
+
+// Option configures optional behaviour of BufferLogHandler.
+type Option func(*options)
+
+// options holds optional configuration of BufferLogHandler. It is shared between
+// handler and all handlers derived from it and it is not changed after creation.
+type options struct {
+	// failover receives records that real handler failed to handle during flush.
+	failover slog.Handler
+	// resolveValues controls if attribute values are resolved when record is buffered.
+	resolveValues bool
+	// compress controls if records are kept compressed in memory, in blocks of compressionBlockSize.
+	compress             bool
+	compressionBlockSize int
+	// emergency receives records at or above emergencyLeveler immediately, instead of buffering them.
+	emergency        slog.Handler
+	emergencyLeveler slog.Leveler
+	// promote becomes real handler as soon as record at or above promoteLeveler is buffered.
+	promote        slog.Handler
+	promoteLeveler slog.Leveler
+	// correlationAttrs and correlationGroup are applied to real handler when it is set.
+	correlationAttrs []slog.Attr
+	correlationGroup string
+	// sortByTime controls if records are sorted by time before they are flushed.
+	sortByTime bool
+	// replayMarker is key of attribute added to replayed records, empty if disabled.
+	replayMarker string
+	// bufferDelayKey is key of attribute with time record spent in buffer, empty if disabled.
+	bufferDelayKey string
+	// originalTimeKey is key of attribute with original time of record whose time is rewritten
+	// to time of replay, empty if time is not rewritten.
+	originalTimeKey string
+	// replaceAttr rewrites attributes of records passed to real handler, nil if disabled.
+	replaceAttr func(groups []string, a slog.Attr) slog.Attr
+	// dedup is policy of removing attributes with duplicate keys from records passed to real
+	// handler, nil if disabled.
+	dedup *DedupPolicy
+	// flattenSeparator joins keys of groups and attributes of records passed to real handler
+	// into single key, empty if groups are passed to real handler.
+	flattenSeparator string
+	// redaction are rules applied to attributes before records are stored.
+	redaction []RedactionRule
+	// maxValueSize is maximum size of message and string attribute values of buffered records,
+	// 0 if not limited.
+	maxValueSize int
+	// contextAttrs extract attributes from context of records when they are handled.
+	contextAttrs []func(context.Context) []slog.Attr
+	// middleware is chain of functions records go through when they are handled.
+	middleware []RecordMiddleware
+	// filters are rules that select records that are dropped instead of being buffered.
+	filters []FilterRule
+	// stackLeveler is level at or above which buffered records get stack trace attribute with
+	// key stackKey, nil if disabled.
+	stackLeveler slog.Leveler
+	stackKey     string
+	// samplingRates holds sampling rate (keep one of every n records) per level.
+	samplingRates map[slog.Level]int
+	// flushBatchSize and flushProgress control flushing records in batches with progress reports.
+	flushBatchSize int
+	flushProgress  func(FlushProgress) bool
+	// flushWorkers is number of goroutines that emit records concurrently during flush.
+	flushWorkers int
+	// flushLevelCheck controls if flush skips records real handler is not enabled for.
+	flushLevelCheck bool
+	// stopOnError controls if flush stops at the first record real handler fails to handle.
+	stopOnError bool
+	// shards is number of buffers records are spread over, 0 or 1 if records are kept in single buffer.
+	shards int
+	// chunkSize is number of records in chunks records are kept in, 0 if records are kept in single buffer.
+	chunkSize int
+	// levelCapacities holds capacity of buffer for each band of levels, nil if records are kept together.
+	levelCapacities map[slog.Level]int
+	// pin are rules that select records that are never evicted.
+	pin []PinRule
+	// evictionSummary is message of record summarizing evicted records, empty if disabled.
+	evictionSummary string
+	// watermarkReached is called when bound buffer is filled up to watermarkPercent of its
+	// capacity, nil if disabled.
+	watermarkPercent int
+	watermarkReached func(context.Context, *BufferLogHandler)
+	// watchdogAfter is duration after which warning is written to watchdogOutput if real
+	// handler is not set, 0 if disabled.
+	watchdogAfter  time.Duration
+	watchdogOutput io.Writer
+	// maxAge and maxBytes limit age and size of buffered records, zero if not limited.
+	maxAge   time.Duration
+	maxBytes int64
+	// clock provides time to time-dependent features.
+	clock Clock
+	// metrics receives measurements of handler operation.
+	metrics Metrics
+	// observers are notified about lifecycle events of the handler.
+	observers []Observer
+	// rebuffer controls retrying of failing real handler while records are buffered, nil if disabled.
+	rebuffer *RetryPolicy
+	// breakerPolicy configures circuit breaker around real handler, nil if disabled.
+	breakerPolicy *CircuitBreakerPolicy
+	// healthCheck is run before records are flushed to real handler, nil if disabled.
+	healthCheck func(context.Context) error
+	// expvarName is name under which statistics are published using expvar, empty if disabled.
+	expvarName string
+	// maxKeys is maximum number of buffers of KeyedBufferHandler, 0 if not limited.
+	maxKeys int
+}
+
+// defaultOptions are used by handlers that were not created using constructor functions.
+var defaultOptions = &options{resolveValues: true, metrics: noMetrics{}, clock: systemClock{}}
+
+// newOptions returns options with all provided Option values applied.
+func newOptions(opts []Option) *options {
+	o := &options{resolveValues: true, metrics: noMetrics{}, clock: systemClock{}}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithFailoverHandler configures secondary handler that receives records real handler
+// failed to handle during flush (e.g. stderr handler, in case network sink is failing).
+// Records successfully handled by failover handler are not retained for RetryFlush.
+func WithFailoverHandler(failover slog.Handler) Option {
+	return func(o *options) {
+		o.failover = failover
+	}
+}
+
+// WithValueResolution controls if attribute values implementing [slog.LogValuer] are
+// resolved at the time record is buffered (default) or only when buffered record reaches
+// real handler. Resolving early makes flushed records reflect values at the moment of
+// logging, instead of potentially stale or mutated state at the time of flush.
+func WithValueResolution(enabled bool) Option {
+	return func(o *options) {
+		o.resolveValues = enabled
+	}
+}
+
+// WithCompression makes handler keep buffered records in memory serialized (the same way
+// as by Encode) and compressed in blocks of roughly blockSize bytes of serialized records.
+// This trades CPU time for much smaller memory footprint, when a lot of records is buffered.
+// If blockSize is zero or lower, 64 KiB is used. Like with Encode, arbitrary attribute
+// values (e.g. structs) are kept as text.
+func WithCompression(blockSize int) Option {
+	return func(o *options) {
+		o.compress = true
+		o.compressionBlockSize = blockSize
+	}
+}
+
+// WithEmergencyHandler makes records at or above threshold level bypass the buffer and
+// go directly to emergency handler (e.g. stderr handler), while records of lower level are
+// buffered as usual. This way critical errors never wait for SetRealHandler. Once real
+// handler is set, all records go to real handler.
+func WithEmergencyHandler(threshold slog.Leveler, emergency slog.Handler) Option {
+	return func(o *options) {
+		o.emergencyLeveler = threshold
+		o.emergency = emergency
+	}
+}
+
+// WithAutoPromote makes handler set provided fallback handler (e.g. one writing to stderr) as
+// its real handler as soon as record at or above provided level is buffered, before real handler
+// was set. All buffered records, including the one that triggered promotion, are flushed to
+// fallback handler and records logged afterward are passed to it directly, which suits CLIs that
+// stay silent unless something goes wrong. Once handler is promoted, SetRealHandler returns
+// ErrAlreadyBound.
+func WithAutoPromote(threshold slog.Leveler, fallback slog.Handler) Option {
+	return func(o *options) {
+		o.promoteLeveler = threshold
+		o.promote = fallback
+	}
+}
+
+// WithCorrelationAttrs adds provided attributes to all records passed to real handler,
+// both flushed and logged after real handler was set. When many scoped buffers (e.g. one per
+// request) flush concurrently to the same real handler, attribute like request ID keeps
+// interleaved records attributable.
+func WithCorrelationAttrs(attrs ...slog.Attr) Option {
+	return func(o *options) {
+		o.correlationAttrs = attrs
+	}
+}
+
+// WithCorrelationGroup puts all records passed to real handler in group of provided name
+// (e.g. request ID), both flushed and logged after real handler was set. If correlation
+// attributes are configured as well, they are not part of the group.
+func WithCorrelationGroup(name string) Option {
+	return func(o *options) {
+		o.correlationGroup = name
+	}
+}
+
+// WithChronologicalFlush makes handler sort buffered records by their time before they are
+// flushed. When multiple goroutines log using the same handler, order in which records are
+// buffered can differ from order of their timestamps. Records with equal time keep their order.
+func WithChronologicalFlush() Option {
+	return func(o *options) {
+		o.sortByTime = true
+	}
+}
+
+// WithReplayMarker makes handler add boolean attribute with provided key (e.g. "buffered")
+// and value true to every record it replays to real handler (during SetRealHandler, Resume
+// or RetryFlush), so consumers of logs can tell replayed records from live ones.
+// If key is empty, "buffered" is used.
+func WithReplayMarker(key string) Option {
+	return func(o *options) {
+		o.replayMarker = cmp.Or(key, "buffered")
+	}
+}
+
+// WithBufferDelay makes handler add attribute with provided key (e.g. "buffer_delay") to every
+// record it replays to real handler, with duration between time of the record and time it was
+// replayed, so operators can see how long records were kept in memory.
+// If key is empty, "buffer_delay" is used.
+func WithBufferDelay(key string) Option {
+	return func(o *options) {
+		o.bufferDelayKey = cmp.Or(key, "buffer_delay")
+	}
+}
+
+// WithReplayTime makes handler set time of every record it replays to real handler to the
+// time of replay, since some sinks reject records with old timestamps. Original time of the
+// record is preserved as attribute with provided key. If key is empty, "original_time" is used.
+func WithReplayTime(originalTimeKey string) Option {
+	return func(o *options) {
+		o.originalTimeKey = cmp.Or(originalTimeKey, "original_time")
+	}
+}
+
+// WithReplaceAttr configures function that rewrites attributes of all records passed to real
+// handler, both flushed and logged after real handler was set. It works the same way as
+// [slog.HandlerOptions.ReplaceAttr] (attribute is dropped if returned key is empty), but it
+// is applied by this handler, so attributes are rewritten uniformly regardless of what real
+// handler supports. Unlike with slog handlers, built-in attributes (time, level, message and
+// source) are not passed to provided function.
+func WithReplaceAttr(replace func(groups []string, a slog.Attr) slog.Attr) Option {
+	return func(o *options) {
+		o.replaceAttr = replace
+	}
+}
+
+// WithDedup makes handler remove attributes with duplicate keys (e.g. added both to the logger
+// and to the record) from all records passed to real handler, keeping one according to provided
+// policy, since some backends reject JSON objects with duplicate keys. Groups with the same key
+// are merged. Attributes and groups are passed to real handler as part of each record, instead
+// of being applied to real handler using WithAttrs and WithGroup.
+func WithDedup(policy DedupPolicy) Option {
+	return func(o *options) {
+		o.dedup = &policy
+	}
+}
+
+// WithFlattenGroups makes handler pass records to real handler without groups, with keys of
+// attributes prefixed by keys of groups they belong to, joined using provided separator (e.g.
+// "g1.g2.key"), for real handlers that mishandle nested groups. If separator is empty, "." is
+// used. It applies both to groups opened using WithGroup and to group attributes.
+func WithFlattenGroups(separator string) Option {
+	return func(o *options) {
+		o.flattenSeparator = cmp.Or(separator, ".")
+	}
+}
+
+// WithRedaction configures rules applied to attributes before records are stored in memory
+// (see RedactKeys, RedactPattern or write custom RedactionRule). Buffered records might be
+// kept for a long time or dumped on crash, so sensitive values have to be scrubbed when
+// record is captured, not when it is emitted. Values are resolved before rules are applied,
+// even if value resolution is disabled using WithValueResolution.
+func WithRedaction(rules ...RedactionRule) Option {
+	return func(o *options) {
+		o.redaction = append(o.redaction, rules...)
+	}
+}
+
+// WithMaxValueSize limits size (in bytes) of message and string attribute values of buffered
+// records, so single giant value (e.g. payload dump) can not exhaust memory or be rejected by
+// sink. Longer values are truncated and TruncatedKey ("truncated") attribute with value true is
+// added to the record. Values of attributes of the logger and of records passed directly to
+// real handler are not affected.
+func WithMaxValueSize(maxBytes int) Option {
+	return func(o *options) {
+		o.maxValueSize = max(maxBytes, 0)
+	}
+}
+
+// WithContextAttrs configures functions that extract attributes (e.g. request or user ID)
+// from context passed to Handle and add them to the record. Context is gone by the time
+// buffered records are flushed, so values have to be captured when record is logged. For
+// consistent output, attributes are added to records passed directly to real handler too.
+// It can be used multiple times to register multiple extractors.
+func WithContextAttrs(extractors ...func(context.Context) []slog.Attr) Option {
+	return func(o *options) {
+		o.contextAttrs = append(o.contextAttrs, extractors...)
+	}
+}
+
+// WithRecordMiddleware configures chain of functions every record goes through when it is
+// handled, before it is buffered or passed to real handler, so records can be enriched,
+// changed or dropped uniformly, regardless of mode of the handler. Middleware is called after
+// attributes are extracted from context (see WithContextAttrs), in order it was configured.
+// Dropped records are not reported as dropped to metrics and observers, since dropping them
+// is deliberate. It can be used multiple times to extend the chain.
+func WithRecordMiddleware(middleware ...RecordMiddleware) Option {
+	return func(o *options) {
+		o.middleware = append(o.middleware, middleware...)
+	}
+}
+
+// WithFilter configures rules that select records that are dropped instead of being buffered
+// (see FilterMessage, FilterAttr and FilterGroup or write custom FilterRule), e.g. to suppress
+// known noisy library during startup, so its records do not take space in the buffer. Records
+// passed directly to real handler are not filtered. Filtered records are not reported as dropped
+// to metrics and observers. It can be used multiple times to add more rules.
+func WithFilter(rules ...FilterRule) Option {
+	return func(o *options) {
+		o.filters = append(o.filters, rules...)
+	}
+}
+
+// WithStackTrace makes handler attach stack trace of the goroutine that logged the record, as
+// attribute with provided key, to records at or above provided level that are buffered, since
+// code path that produced the record is long gone by the time it is flushed. Frames of slog and
+// this package are omitted. Records passed directly to real handler are not affected. If key is
+// empty, "stack" is used.
+func WithStackTrace(threshold slog.Leveler, key string) Option {
+	return func(o *options) {
+		o.stackLeveler = threshold
+		o.stackKey = cmp.Or(key, "stack")
+	}
+}
+
+// WithSampling makes handler buffer only one of every n records of provided level (records
+// of other levels are not affected), so extremely chatty code paths do not evict everything
+// else from bound buffer. It can be used multiple times for different levels, e.g. to keep
+// one of every 100 debug records and one of every 10 info records, but all warnings and errors.
+func WithSampling(level slog.Level, n int) Option {
+	return func(o *options) {
+		if o.samplingRates == nil {
+			o.samplingRates = make(map[slog.Level]int)
+		}
+		o.samplingRates[level] = n
+	}
+}
+
+// WithFlushProgress makes handler flush records in batches of batchSize records and call
+// provided progress function after each batch, so long flushes can report progress. If progress
+// function returns false (or context passed to flush is cancelled), flush is aborted and returns
+// ErrFlushAborted. Records that were not flushed are kept, so they can be delivered later using
+// RetryFlush. Progress function can be nil, in which case flush is only checking context between
+// batches. If batchSize is zero or lower, all records are flushed as single batch.
+func WithFlushProgress(batchSize int, progress func(FlushProgress) bool) Option {
+	return func(o *options) {
+		o.flushBatchSize = batchSize
+		o.flushProgress = progress
+	}
+}
+
+// WithFlushLevelCheck makes flush skip buffered records that real handler is not enabled for
+// (according to its Enabled method), the same way slog.Logger skips them when real handler is
+// used directly. By default, all buffered records are passed to real handler, since level of
+// buffer handler decides which records are kept. Skipped records are counted in FlushReport.
+func WithFlushLevelCheck(enabled bool) Option {
+	return func(o *options) {
+		o.flushLevelCheck = enabled
+	}
+}
+
+// WithStopOnError makes flush stop at the first record real handler fails to handle, instead of
+// trying to deliver all records. Failed record and all records after it are kept in order (see
+// DeadLetters), without being sent to failover handler, and flush returns FlushError together with
+// ErrFlushAborted. Handler is then left paused, so records logged in the meantime are buffered
+// behind kept records instead of overtaking them. Delivery can be continued using RetryFlush,
+// followed by Resume. With WithParallelFlush, each worker stops at its first failed record.
+func WithStopOnError(enabled bool) Option {
+	return func(o *options) {
+		o.stopOnError = enabled
+	}
+}
+
+// WithParallelFlush makes handler flush buffered records using provided number of concurrent
+// workers, which greatly reduces flush time when handling each record involves network latency.
+// Records are no longer delivered in order, so this is suitable only for order-insensitive sinks.
+// Real handler (and failover handler) has to be safe for concurrent use. When used together with
+// WithFlushProgress, each batch is split between workers.
+func WithParallelFlush(workers int) Option {
+	return func(o *options) {
+		o.flushWorkers = workers
+	}
+}
+
+// WithShards makes handler spread buffered records over provided number of buffers (shards),
+// each with its own lock, which reduces contention when many goroutines log concurrently.
+// If shards is zero or lower, runtime.GOMAXPROCS is used. Order of records is preserved, but
+// for bound buffer, eviction of the oldest records is approximate, since each shard holds equal
+// part of maximum number of records. It has no effect together with WithCompression.
+func WithShards(shards int) Option {
+	return func(o *options) {
+		o.shards = shards
+		if shards <= 0 {
+			o.shards = runtime.GOMAXPROCS(0)
+		}
+	}
+}
+
+// WithChunkedGrowth makes handler keep buffered records in linked list of chunks, each holding
+// chunkSize records, instead of single buffer that is copied to twice as large one whenever it
+// is full. Buffer then grows incrementally, without copy spikes and temporarily doubled memory,
+// which matters for unbound buffers holding many records. If chunkSize is zero or lower, 1024 is
+// used. Option is ignored if records are compressed, sharded or kept per level.
+func WithChunkedGrowth(chunkSize int) Option {
+	return func(o *options) {
+		o.chunkSize = chunkSize
+		if chunkSize <= 0 {
+			o.chunkSize = defaultChunkSize
+		}
+	}
+}
+
+// WithLevelCapacity makes handler keep records at or above provided level (and below the next
+// level configured by this option) in separate buffer, that holds at most maxRecords records (0
+// means unbound), so e.g. thousands of debug records can not evict few warnings. It can be used
+// multiple times to configure bands of levels, e.g. debug, info and warning with 10000, 1000 and
+// 100 records. Records below the lowest configured level are kept in buffer of the lowest band.
+// Records of all bands are still flushed in the order they were logged. When this option is used,
+// maximum number of records provided to constructor is ignored and WithShards has no effect.
+// It has no effect together with WithCompression.
+func WithLevelCapacity(level slog.Level, maxRecords int) Option {
+	return func(o *options) {
+		if o.levelCapacities == nil {
+			o.levelCapacities = make(map[slog.Level]int)
+		}
+		o.levelCapacities[level] = maxRecords
+	}
+}
+
+// WithPin configures rules that select records that are never evicted from bound buffer (see
+// PinLevel and PinAttr or write custom PinRule), e.g. so errors survive bursts of less important
+// records. Pinned records are kept in addition to maximum number of records of the buffer, so
+// they can grow without limit. Records are still flushed in the order they were logged.
+// WithShards has no effect when this option is used and this option has no effect together
+// with WithCompression.
+func WithPin(rules ...PinRule) Option {
+	return func(o *options) {
+		o.pin = append(o.pin, rules...)
+	}
+}
+
+// WithHighWatermark configures function that is called when bound buffer gets filled up to provided
+// percentage of its capacity (e.g. 80), so application can react before records start being evicted,
+// e.g. by setting real handler or moving records elsewhere using DrainTo. Function is called from
+// logging call that reached the watermark, with root handler, and it is not called again until
+// buffer is found below watermark. It has no effect for unbound buffers.
+func WithHighWatermark(percent int, reached func(ctx context.Context, h *BufferLogHandler)) Option {
+	return func(o *options) {
+		o.watermarkPercent = min(max(percent, 0), 100)
+		o.watermarkReached = reached
+	}
+}
+
+// WithWatchdog makes handler write one-time warning (e.g. "120 log records buffered for 5m0s, no
+// handler configured") to provided writer if real handler is still not set once provided duration
+// elapses since handler was created, so misconfigured application does not silently swallow all its
+// records. If writer is nil, os.Stderr is used.
+func WithWatchdog(after time.Duration, w io.Writer) Option {
+	return func(o *options) {
+		o.watchdogAfter = after
+		o.watchdogOutput = w
+		if w == nil {
+			o.watchdogOutput = os.Stderr
+		}
+	}
+}
+
+// WithEvictionSummary makes handler keep track of records evicted from bound buffer and emit
+// single record with provided message summarizing them (number of evicted records in total and
+// per level and time of the oldest and newest evicted record) at the head of the flush, so output
+// documents what was lost. Summary has level of the most severe evicted record. If msg is empty,
+// "records evicted from buffer" is used.
+func WithEvictionSummary(msg string) Option {
+	return func(o *options) {
+		o.evictionSummary = cmp.Or(msg, "records evicted from buffer")
+	}
+}
+
+// WithMaxAge limits age of buffered records. Records older than provided duration are evicted
+// when new records are buffered and before records are flushed. It can be combined with maximum
+// number of records and WithMaxBytes, in which case records are evicted when any of the limits
+// is exceeded. Like other evicted records, they are counted by Dropped.
+func WithMaxAge(maxAge time.Duration) Option {
+	return func(o *options) {
+		o.maxAge = maxAge
+	}
+}
+
+// WithMaxBytes limits approximate size of buffered records (as estimated for records held in
+// memory uncompressed, even if WithCompression is used). The oldest records are evicted when new
+// records are buffered, as long as limit is exceeded. It can be combined with maximum number of
+// records and WithMaxAge, in which case records are evicted when any of the limits is exceeded.
+func WithMaxBytes(maxBytes int64) Option {
+	return func(o *options) {
+		o.maxBytes = maxBytes
+	}
+}
+
+// WithClock sets clock used by time-dependent features of the handler (WithMaxAge, WithBufferDelay,
+// WithReplayTime, RetryFlush, WithAutoRebuffer and WithCircuitBreaker) instead of system clock, so
+// they can be tested without sleeping (e.g. using clock from slogbuffertest package).
+func WithClock(clock Clock) Option {
+	return func(o *options) {
+		if clock != nil {
+			o.clock = clock
+		}
+	}
+}
+
+// WithExpvar publishes statistics of the handler (number of buffered records, capacity, number of
+// dropped records, approximate size of buffered records and state) using package expvar under
+// provided name, so they are visible on standard /debug/vars endpoint. If handler with the same
+// name was published before, it is replaced by the new one. Like expvar.Publish, handler
+// creation panics if name is already used by variable not published by this package.
+func WithExpvar(name string) Option {
+	return func(o *options) {
+		o.expvarName = name
+	}
+}
+
+// WithMaxKeys limits number of buffers KeyedBufferHandler keeps at the same time, so keys of
+// unbounded cardinality (e.g. request IDs) can not exhaust memory. Records of new keys are
+// dropped with ErrBufferFull once limit is reached, until some buffer is discarded. It has no
+// effect on other handlers.
+func WithMaxKeys(maxKeys int) Option {
+	return func(o *options) {
+		o.maxKeys = max(maxKeys, 0)
+	}
+}
+
+// WithMetrics makes handler report number of buffered and dropped records and duration and
+// result of flushes to provided Metrics implementation (e.g. one exporting them using
+// OpenTelemetry).
+func WithMetrics(metrics Metrics) Option {
+	return func(o *options) {
+		if metrics != nil {
+			o.metrics = metrics
+		}
+	}
+}
+
+// WithObserver registers observer that is notified about lifecycle events of the handler: records
+// being buffered or dropped, flushes and switching to real handler. It can be used multiple times
+// to register multiple observers.
+func WithObserver(observer Observer) Option {
+	return func(o *options) {
+		if observer != nil {
+			o.observers = append(o.observers, observer)
+		}
+	}
+}
+
+// WithAutoRebuffer makes handler switch back to buffering as soon as real handler fails to handle
+// a record logged after it was set (e.g. because network sink went down). Failed record is kept
+// and delivery is re-attempted in background, waiting between attempts according to backoff of
+// provided policy (MaxAttempts is ignored, attempts continue until real handler recovers). Once
+// it succeeds, records buffered in the meantime are flushed and records are passed to real
+// handler again. Buffer limits apply while real handler is failing, like before it was set.
+// Close stops re-attempting delivery, so handler does not keep background goroutine running
+// if real handler never recovers.
+func WithAutoRebuffer(policy RetryPolicy) Option {
+	return func(o *options) {
+		o.rebuffer = &policy
+	}
+}
+
+// WithCircuitBreaker wraps real handler in circuit breaker. When too many of the most recent
+// records passed to real handler fail (according to provided policy), circuit opens: real handler
+// is bypassed and records are buffered (subject to buffer limits), so misbehaving sink does not
+// slow down every logging call. After cooldown, delivery of failed records is attempted again and,
+// if it succeeds, buffered records are flushed and circuit is closed.
+func WithCircuitBreaker(policy CircuitBreakerPolicy) Option {
+	return func(o *options) {
+		o.breakerPolicy = &policy
+	}
+}
+
+// newCircuitBreaker returns circuit breaker configured by options, nil if it is disabled.
+func (o *options) newCircuitBreaker() *circuitBreaker {
+	if o.breakerPolicy == nil {
+		return nil
+	}
+	return newCircuitBreaker(*o.breakerPolicy)
+}
+
+// WithHealthCheck configures function that checks if real handler (or sink behind it) is ready,
+// e.g. by pinging log collector. It is run by SetRealHandler, Resume and FlushMerged before any
+// record is flushed. If it returns an error, records remain buffered, handler is not switched to
+// real handler and error wrapping ErrHealthCheckFailed is returned, so the call can be repeated
+// later, instead of flushing part of the buffer to sink that was not ready.
+func WithHealthCheck(check func(ctx context.Context) error) Option {
+	return func(o *options) {
+		o.healthCheck = check
+	}
+}
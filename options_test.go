@@ -0,0 +1,319 @@
+package slogbuffer_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBufferLogHandler_WithFailoverHandler(t *testing.T) {
+	// given
+	failover, failoverReader := getSimplifiedTextHandler()
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithFailoverHandler(failover))
+	l := slog.New(h)
+
+	l.Info("first msg")
+	l.WithGroup("g1").Warn("second msg", "foo", "bar")
+	l.Error("third msg")
+
+	// when
+	rh, reader := getSimplifiedTextHandler()
+	err := h.SetRealHandler(context.Background(), newFailingHandler(rh, 2))
+
+	// then
+	if err == nil {
+		t.Fatalf("expected error from flush")
+	}
+
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 1)
+	expectMsg(t, lines[0], "third msg")
+
+	failoverLines := getLines(t, failoverReader)
+	expectLinesNo(t, failoverLines, 2)
+	expectMsg(t, failoverLines[0], "first msg")
+	expectMsg(t, failoverLines[1], "second msg")
+	expectAttr(t, failoverLines[1], "g1.foo", "bar")
+
+	// records delivered to failover handler are not retained for retry
+	if err := h.RetryFlush(context.Background(), slogbuffer.RetryPolicy{}); err != nil {
+		t.Fatalf("retrying flush: %v", err)
+	}
+	expectLinesNo(t, getLines(t, reader), 0)
+}
+
+// mutableValuer is [slog.LogValuer] whose value can change after it was logged.
+type mutableValuer struct {
+	value string
+}
+
+func (v *mutableValuer) LogValue() slog.Value {
+	return slog.StringValue(v.value)
+}
+
+func TestBufferLogHandler_WithValueResolution(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		opts     []slogbuffer.Option
+		expected string
+	}{
+		{name: "default", opts: nil, expected: "logged"},
+		{name: "enabled", opts: []slogbuffer.Option{slogbuffer.WithValueResolution(true)}, expected: "logged"},
+		{name: "disabled", opts: []slogbuffer.Option{slogbuffer.WithValueResolution(false)}, expected: "mutated"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// given
+			h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, tc.opts...)
+			l := slog.New(h)
+
+			recordValue := &mutableValuer{value: "logged"}
+			loggerValue := &mutableValuer{value: "logged"}
+			l.With("logger-value", loggerValue).Info("info msg", slog.Group("g1", "record-value", recordValue))
+			recordValue.value = "mutated"
+			loggerValue.value = "mutated"
+
+			// when
+			rh, reader := getSimplifiedTextHandler()
+			setRealHandler(t, h, rh)
+			lines := getLines(t, reader)
+
+			// then
+			expectLinesNo(t, lines, 1)
+			expectAttr(t, lines[0], "g1.record-value", tc.expected)
+			expectAttr(t, lines[0], "logger-value", tc.expected)
+		})
+	}
+}
+
+func TestBufferLogHandler_WithEmergencyHandler(t *testing.T) {
+	// given
+	emergency, emergencyReader := getSimplifiedTextHandler()
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithEmergencyHandler(slog.LevelError, emergency))
+	l := slog.New(h)
+
+	// when
+	l.Info("info msg")
+	l.WithGroup("g1").With("common", "attr").Error("error msg")
+
+	// then
+	// error is written immediately and not buffered
+	emergencyLines := getLines(t, emergencyReader)
+	expectLinesNo(t, emergencyLines, 1)
+	expectMsg(t, emergencyLines[0], "error msg")
+	expectAttr(t, emergencyLines[0], "g1.common", "attr")
+
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandler(t, h, rh)
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 1)
+	expectMsg(t, lines[0], "info msg")
+
+	// after real handler is set, all records go to real handler
+	l.Error("second error msg")
+	expectLinesNo(t, getLines(t, emergencyReader), 0)
+	lines = getLines(t, reader)
+	expectLinesNo(t, lines, 1)
+	expectMsg(t, lines[0], "second error msg")
+}
+
+func TestBufferLogHandler_WithAutoPromote(t *testing.T) {
+	// given
+	fallback, fallbackReader := getSimplifiedTextHandler()
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithAutoPromote(slog.LevelError, fallback))
+	l := slog.New(h)
+
+	// when
+	l.Info("info msg")
+	l.Warn("warn msg")
+
+	// then
+	// nothing is written until something goes wrong
+	expectLinesNo(t, getLines(t, fallbackReader), 0)
+
+	l.WithGroup("g1").With("common", "attr").Error("error msg")
+	lines := getLines(t, fallbackReader)
+	expectLinesNo(t, lines, 3)
+	expectMsg(t, lines[0], "info msg")
+	expectMsg(t, lines[1], "warn msg")
+	expectMsg(t, lines[2], "error msg")
+	expectAttr(t, lines[2], "g1.common", "attr")
+
+	// after promotion, records go to fallback handler directly
+	l.Info("late msg")
+	lines = getLines(t, fallbackReader)
+	expectLinesNo(t, lines, 1)
+	expectMsg(t, lines[0], "late msg")
+
+	rh, _ := getSimplifiedTextHandler()
+	if err := h.SetRealHandler(context.Background(), rh); !errors.Is(err, slogbuffer.ErrAlreadyBound) {
+		t.Fatalf("expected ErrAlreadyBound, got %v", err)
+	}
+}
+
+func TestBufferLogHandler_WithCorrelation(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug,
+		slogbuffer.WithCorrelationAttrs(slog.String("request_id", "abc")),
+		slogbuffer.WithCorrelationGroup("req"),
+	)
+	l := slog.New(h)
+	l.Info("buffered msg", "foo", "bar")
+
+	// when
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandler(t, h, rh)
+	l.Info("after flush msg", "foo", "baz")
+
+	// then
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 2)
+	expectMsg(t, lines[0], "buffered msg")
+	expectAttr(t, lines[0], "request_id", "abc")
+	expectAttr(t, lines[0], "req.foo", "bar")
+	expectMsg(t, lines[1], "after flush msg")
+	expectAttr(t, lines[1], "request_id", "abc")
+	expectAttr(t, lines[1], "req.foo", "baz")
+}
+
+func TestBufferLogHandler_WithChronologicalFlush(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithChronologicalFlush())
+	now := time.Now()
+	for _, rec := range []struct {
+		msg  string
+		time time.Time
+	}{
+		{msg: "second msg", time: now.Add(time.Second)},
+		{msg: "first msg", time: now},
+		{msg: "third msg", time: now.Add(2 * time.Second)},
+		{msg: "also third msg", time: now.Add(2 * time.Second)},
+	} {
+		if err := h.Handle(context.Background(), slog.NewRecord(rec.time, slog.LevelInfo, rec.msg, 0)); err != nil {
+			t.Fatalf("handling record: %v", err)
+		}
+	}
+
+	// when
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandler(t, h, rh)
+
+	// then
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 4)
+	expectMsg(t, lines[0], "first msg")
+	expectMsg(t, lines[1], "second msg")
+	expectMsg(t, lines[2], "third msg")
+	expectMsg(t, lines[3], "also third msg")
+}
+
+func TestBufferLogHandler_WithReplaceAttr(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug,
+		slogbuffer.WithReplaceAttr(func(groups []string, a slog.Attr) slog.Attr {
+			switch a.Key {
+			case "password":
+				return slog.String(a.Key, "***")
+			case "internal":
+				return slog.Attr{}
+			}
+			return a
+		}),
+	)
+	l := slog.New(h)
+	l.WithGroup("g1").With("password", "secret").Info("buffered msg", "internal", 1, "foo", "bar")
+
+	// when
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandler(t, h, rh)
+	l.Info("after flush msg", slog.Group("user", "password", "secret", "internal", 2))
+
+	// then
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 2)
+	expectMsg(t, lines[0], "buffered msg")
+	expectAttr(t, lines[0], "g1.password", "***")
+	expectAttr(t, lines[0], "g1.foo", "bar")
+	expectNoAttr(t, lines[0], "g1.internal", "1")
+	expectMsg(t, lines[1], "after flush msg")
+	expectAttr(t, lines[1], "user.password", "***")
+	expectNoAttr(t, lines[1], "user.internal", "2")
+}
+
+func TestBufferLogHandler_WithSampling(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithSampling(slog.LevelDebug, 3))
+	l := slog.New(h)
+
+	// when
+	for i := range 7 {
+		l.Debug(fmt.Sprintf("debug msg %d", i))
+		l.WithGroup("g1").Warn(fmt.Sprintf("warn msg %d", i))
+	}
+
+	// then
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandler(t, h, rh)
+	lines := getLines(t, reader)
+	var debug, warn []string
+	for _, line := range lines {
+		if strings.Contains(line, "level=DEBUG") {
+			debug = append(debug, line)
+		} else {
+			warn = append(warn, line)
+		}
+	}
+	expectLinesNo(t, warn, 7)
+	expectLinesNo(t, debug, 3)
+	expectMsg(t, debug[0], "debug msg 0")
+	expectMsg(t, debug[1], "debug msg 3")
+	expectMsg(t, debug[2], "debug msg 6")
+}
+
+// slowHandler is [slog.Handler] that takes provided time to handle each record.
+type slowHandler struct {
+	slog.Handler
+	delay time.Duration
+	lock  *sync.Mutex
+}
+
+func (h *slowHandler) Handle(ctx context.Context, r slog.Record) error {
+	time.Sleep(h.delay)
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.Handler.Handle(ctx, r)
+}
+
+func TestBufferLogHandler_WithParallelFlush(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithParallelFlush(10))
+	l := slog.New(h)
+	for i := range 20 {
+		l.Info(fmt.Sprintf("msg %d", i))
+	}
+
+	// when
+	rh, reader := getSimplifiedTextHandler()
+	start := time.Now()
+	setRealHandler(t, h, &slowHandler{Handler: rh, delay: 50 * time.Millisecond, lock: &sync.Mutex{}})
+	elapsed := time.Since(start)
+
+	// then
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 20)
+	for i := range 20 {
+		if !slices.ContainsFunc(lines, func(line string) bool { return strings.Contains(line, fmt.Sprintf(`"msg %d"`, i)) }) {
+			t.Fatalf("expected msg %d to be flushed", i)
+		}
+	}
+	// sequential flush would take at least a second
+	if elapsed > 500*time.Millisecond {
+		t.Fatalf("expected records to be flushed concurrently, flush took %s", elapsed)
+	}
+}
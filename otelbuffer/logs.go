@@ -0,0 +1,52 @@
+package otelbuffer
+
+import (
+	"context"
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"log/slog"
+)
+
+// LogHandler is [slog.Handler] that sends records to OpenTelemetry collector (or any other
+// OpenTelemetry logs backend) using provided exporter. It is suitable as real handler of
+// [slogbuffer.BufferLogHandler], so buffered records are shipped to collector once its endpoint
+// is known. Records are exported in batches in background, so Shutdown should be called before
+// application exits to export remaining ones.
+type LogHandler struct {
+	slog.Handler
+	provider *sdklog.LoggerProvider
+}
+
+// NewLogHandler returns LogHandler that exports records using provided exporter, in batches
+// configured by provided options. Name identifies instrumentation scope of exported records,
+// usually it is import path of the package that logs.
+func NewLogHandler(name string, exporter sdklog.Exporter, opts ...sdklog.BatchProcessorOption) *LogHandler {
+	provider := sdklog.NewLoggerProvider(sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter, opts...)))
+	return &LogHandler{
+		Handler:  otelslog.NewHandler(name, otelslog.WithLoggerProvider(provider)),
+		provider: provider,
+	}
+}
+
+// NewOTLPLogHandler returns LogHandler that exports records using OTLP over HTTP, configured
+// using provided options (e.g. otlploghttp.WithEndpointURL). Without options, configuration is
+// read from standard OTEL_EXPORTER_OTLP_* environment variables.
+func NewOTLPLogHandler(ctx context.Context, name string, opts ...otlploghttp.Option) (*LogHandler, error) {
+	exporter, err := otlploghttp.New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewLogHandler(name, exporter), nil
+}
+
+// ForceFlush exports all records that were handled, but not exported yet.
+func (h *LogHandler) ForceFlush(ctx context.Context) error {
+	return h.provider.ForceFlush(ctx)
+}
+
+// Shutdown exports all remaining records and releases exporter. Records handled after
+// Shutdown are ignored.
+func (h *LogHandler) Shutdown(ctx context.Context) error {
+	return h.provider.Shutdown(ctx)
+}
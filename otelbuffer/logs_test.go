@@ -0,0 +1,57 @@
+package otelbuffer_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/delicb/slogbuffer"
+	"github.com/delicb/slogbuffer/otelbuffer"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestNewOTLPLogHandler(t *testing.T) {
+	// given
+	var (
+		lock     sync.Mutex
+		received []byte
+	)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		lock.Lock()
+		received = append(received, body...)
+		lock.Unlock()
+		w.Header().Set("Content-Type", "application/x-protobuf")
+	}))
+	defer collector.Close()
+
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	slog.New(h).With("common", "attr").Info("buffered msg")
+
+	// when
+	ctx := context.Background()
+	real, err := otelbuffer.NewOTLPLogHandler(ctx, "test", otlploghttp.WithEndpointURL(collector.URL+"/v1/logs"))
+	if err != nil {
+		t.Fatalf("creating handler: %v", err)
+	}
+	if err := h.SetRealHandler(ctx, real); err != nil {
+		t.Fatalf("setting real handler: %v", err)
+	}
+	slog.New(h).Info("live msg")
+	if err := real.Shutdown(ctx); err != nil {
+		t.Fatalf("shutting down: %v", err)
+	}
+
+	// then
+	lock.Lock()
+	defer lock.Unlock()
+	for _, expected := range []string{"buffered msg", "common", "attr", "live msg"} {
+		if !bytes.Contains(received, []byte(expected)) {
+			t.Fatalf("collector did not receive %q", expected)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Package otelbuffer integrates [slogbuffer.BufferLogHandler] with OpenTelemetry.
+//
+// It is a separate module, so slogbuffer itself does not depend on OpenTelemetry.
+package otelbuffer
+
+import (
+	"context"
+	"github.com/delicb/slogbuffer"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"log/slog"
+	"time"
+)
+
+// levelKey is key of attribute with level of buffered and dropped records.
+const levelKey = "level"
+
+// metrics is slogbuffer.Metrics implementation that records measurements using
+// OpenTelemetry instruments.
+type metrics struct {
+	buffered      metric.Int64Counter
+	dropped       metric.Int64Counter
+	flushed       metric.Int64Counter
+	flushFailures metric.Int64Counter
+	flushDuration metric.Float64Histogram
+}
+
+// NewMetrics returns slogbuffer.Metrics implementation (see slogbuffer.WithMetrics) that
+// records number of buffered and dropped records (by level), number of flushed records,
+// number of records real handler failed to handle and duration of flushes using instruments
+// created by provided meter.
+func NewMetrics(meter metric.Meter) (slogbuffer.Metrics, error) {
+	var (
+		m   metrics
+		err error
+	)
+	if m.buffered, err = meter.Int64Counter("slogbuffer.records.buffered",
+		metric.WithDescription("Number of records stored in the buffer."),
+		metric.WithUnit("{record}")); err != nil {
+		return nil, err
+	}
+	if m.dropped, err = meter.Int64Counter("slogbuffer.records.dropped",
+		metric.WithDescription("Number of records evicted from the buffer or skipped by sampling."),
+		metric.WithUnit("{record}")); err != nil {
+		return nil, err
+	}
+	if m.flushed, err = meter.Int64Counter("slogbuffer.flush.records",
+		metric.WithDescription("Number of records flushed to real handler."),
+		metric.WithUnit("{record}")); err != nil {
+		return nil, err
+	}
+	if m.flushFailures, err = meter.Int64Counter("slogbuffer.flush.failures",
+		metric.WithDescription("Number of flushed records real handler failed to handle."),
+		metric.WithUnit("{record}")); err != nil {
+		return nil, err
+	}
+	if m.flushDuration, err = meter.Float64Histogram("slogbuffer.flush.duration",
+		metric.WithDescription("Duration of flushes of buffered records."),
+		metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (m *metrics) Buffered(ctx context.Context, level slog.Level) {
+	m.buffered.Add(ctx, 1, levelOption(level))
+}
+
+func (m *metrics) Dropped(ctx context.Context, level slog.Level) {
+	m.dropped.Add(ctx, 1, levelOption(level))
+}
+
+func (m *metrics) Flushed(ctx context.Context, records, failed int, duration time.Duration) {
+	m.flushed.Add(ctx, int64(records))
+	if failed > 0 {
+		m.flushFailures.Add(ctx, int64(failed))
+	}
+	m.flushDuration.Record(ctx, duration.Seconds())
+}
+
+// levelOption returns measurement option with level attribute.
+func levelOption(level slog.Level) metric.MeasurementOption {
+	return metric.WithAttributes(attribute.String(levelKey, level.String()))
+}
@@ -0,0 +1,77 @@
+package otelbuffer_test
+
+import (
+	"context"
+	"github.com/delicb/slogbuffer"
+	"github.com/delicb/slogbuffer/otelbuffer"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+func TestNewMetrics(t *testing.T) {
+	// given
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	metrics, err := otelbuffer.NewMetrics(provider.Meter("test"))
+	if err != nil {
+		t.Fatalf("creating metrics: %v", err)
+	}
+	h := slogbuffer.NewBoundBufferLogHandler(slog.LevelDebug, 2, slogbuffer.WithMetrics(metrics))
+	l := slog.New(h)
+
+	// when
+	l.Debug("evicted msg")
+	l.Info("info msg")
+	l.Info("info msg")
+	if err := h.SetRealHandler(context.Background(), slog.NewTextHandler(io.Discard, nil)); err != nil {
+		t.Fatalf("setting real handler: %v", err)
+	}
+
+	// then
+	var data metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &data); err != nil {
+		t.Fatalf("collecting metrics: %v", err)
+	}
+	collected := map[string]metricdata.Aggregation{}
+	for _, scope := range data.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			collected[m.Name] = m.Data
+		}
+	}
+
+	expectSum(t, collected["slogbuffer.records.buffered"], "INFO", 2)
+	expectSum(t, collected["slogbuffer.records.buffered"], "DEBUG", 1)
+	expectSum(t, collected["slogbuffer.records.dropped"], "DEBUG", 1)
+	expectSum(t, collected["slogbuffer.flush.records"], "", 2)
+	if _, ok := collected["slogbuffer.flush.failures"]; ok {
+		t.Fatalf("unexpected flush failures")
+	}
+	histogram, ok := collected["slogbuffer.flush.duration"].(metricdata.Histogram[float64])
+	if !ok || len(histogram.DataPoints) != 1 || histogram.DataPoints[0].Count != 1 {
+		t.Fatalf("unexpected flush duration %+v", collected["slogbuffer.flush.duration"])
+	}
+}
+
+// expectSum checks that counter has expected value for data point with provided level
+// (or without attributes, if level is empty).
+func expectSum(t *testing.T, data metricdata.Aggregation, level string, expected int64) {
+	t.Helper()
+	sum, ok := data.(metricdata.Sum[int64])
+	if !ok {
+		t.Fatalf("expected sum, got %+v", data)
+	}
+	for _, point := range sum.DataPoints {
+		value, _ := point.Attributes.Value(attribute.Key("level"))
+		if value.AsString() == level {
+			if point.Value != expected {
+				t.Fatalf("expected %d for level %q, got %d", expected, level, point.Value)
+			}
+			return
+		}
+	}
+	t.Fatalf("data point for level %q not found in %+v", level, sum.DataPoints)
+}
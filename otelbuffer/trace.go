@@ -0,0 +1,36 @@
+package otelbuffer
+
+import (
+	"context"
+	"github.com/delicb/slogbuffer"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+)
+
+// Keys of attributes with IDs of trace and span active when record was logged, named
+// after corresponding fields of OpenTelemetry log data model.
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+// TraceAttrs returns attributes with IDs of trace and span active in provided context, or
+// nil if context does not carry valid span context. It is meant to be used with
+// slogbuffer.WithContextAttrs (see WithTraceContext).
+func TraceAttrs(ctx context.Context) []slog.Attr {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return []slog.Attr{
+		slog.String(TraceIDKey, sc.TraceID().String()),
+		slog.String(SpanIDKey, sc.SpanID().String()),
+	}
+}
+
+// WithTraceContext returns option that makes buffer handler capture IDs of trace and span
+// active when record is logged, so replayed records still correlate with traces, even
+// though span has ended long before they are flushed.
+func WithTraceContext() slogbuffer.Option {
+	return slogbuffer.WithContextAttrs(TraceAttrs)
+}
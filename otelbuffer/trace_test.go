@@ -0,0 +1,46 @@
+package otelbuffer_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/delicb/slogbuffer"
+	"github.com/delicb/slogbuffer/otelbuffer"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestWithTraceContext(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, otelbuffer.WithTraceContext())
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x01, 0x02, 0x03},
+		SpanID:     trace.SpanID{0x04, 0x05},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+
+	// when
+	slog.New(h).InfoContext(ctx, "traced msg")
+	slog.New(h).InfoContext(context.Background(), "untraced msg")
+
+	var out bytes.Buffer
+	if err := h.SetRealHandler(context.Background(), slog.NewTextHandler(&out, nil)); err != nil {
+		t.Fatalf("setting real handler: %v", err)
+	}
+
+	// then
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", out.String())
+	}
+	for _, expected := range []string{"trace_id=" + sc.TraceID().String(), "span_id=" + sc.SpanID().String()} {
+		if !strings.Contains(lines[0], expected) {
+			t.Fatalf("expected %q in %q", expected, lines[0])
+		}
+	}
+	if strings.Contains(lines[1], "trace_id") {
+		t.Fatalf("expected no trace ID in %q", lines[1])
+	}
+}
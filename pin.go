@@ -0,0 +1,32 @@
+package slogbuffer
+
+import (
+	"log/slog"
+)
+
+// PinRule decides if record should be pinned, which means that it is never evicted from bound
+// buffer. Record passed to the rule has only its own attributes, attributes of the logger
+// (added using [slog.Logger.With]) are not included.
+type PinRule func(r slog.Record) bool
+
+// PinLevel returns rule that pins records at or above provided level.
+func PinLevel(level slog.Leveler) PinRule {
+	return func(r slog.Record) bool {
+		return r.Level >= level.Level()
+	}
+}
+
+// PinAttr returns rule that pins records with attribute with provided key and value, e.g.
+// PinAttr("audit", true). Only top level attributes of the record are considered and value
+// has to be comparable (e.g. string, number or bool).
+func PinAttr(key string, value any) PinRule {
+	expected := slog.AnyValue(value)
+	return func(r slog.Record) bool {
+		found := false
+		r.Attrs(func(a slog.Attr) bool {
+			found = a.Key == key && a.Value.Resolve().Equal(expected)
+			return !found
+		})
+		return found
+	}
+}
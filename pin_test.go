@@ -0,0 +1,63 @@
+package slogbuffer_test
+
+import (
+	"fmt"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"testing"
+)
+
+func TestBufferLogHandler_WithPin(t *testing.T) {
+	// given
+	h := slogbuffer.NewBoundBufferLogHandler(slog.LevelDebug, 2,
+		slogbuffer.WithPin(slogbuffer.PinLevel(slog.LevelError), slogbuffer.PinAttr("audit", true)))
+	l := slog.New(h)
+
+	// when
+	l.Info("info 1")
+	l.Error("error")
+	l.Info("audited", "audit", true)
+	l.Info("not audited", "audit", false)
+	for i := range 10 {
+		l.Debug(fmt.Sprintf("debug %d", i))
+	}
+
+	// then
+	if h.Len() != 4 || h.Cap() != 2 {
+		t.Fatalf("expected 2 pinned and 2 other records, got %d of %d", h.Len(), h.Cap())
+	}
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandler(t, h, rh)
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 4)
+	for i, expected := range []string{"error", "audited", "debug 8", "debug 9"} {
+		expectMsg(t, lines[i], expected)
+	}
+}
+
+func TestBufferLogHandler_WithPin_LevelCapacity(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug,
+		slogbuffer.WithLevelCapacity(slog.LevelDebug, 1),
+		slogbuffer.WithLevelCapacity(slog.LevelInfo, 1),
+		slogbuffer.WithPin(slogbuffer.PinAttr("keep", "yes")))
+	l := slog.New(h)
+
+	// when
+	l.Debug("kept debug", "keep", "yes")
+	l.Debug("debug 1")
+	l.Debug("debug 2")
+	l.Info("info 1")
+	l.Info("info 2")
+
+	// then
+	records := h.Records()
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(records))
+	}
+	for i, expected := range []string{"kept debug", "debug 2", "info 2"} {
+		if records[i].Message != expected {
+			t.Fatalf("expected %q at position %d, got %q", expected, i, records[i].Message)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package slogbuffer
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrFlushAborted is returned when flush is aborted by progress callback (see WithFlushProgress)
+// or cancellation of context. Records that were not flushed are kept, so they can be delivered
+// later using RetryFlush.
+var ErrFlushAborted = errors.New("slogbuffer: flush aborted")
+
+// FlushProgress describes progress of flush, reported after each batch of records.
+type FlushProgress struct {
+	// Emitted is number of records emitted so far, including ones real handler failed to handle.
+	Emitted int
+	// Remaining is number of records still to be emitted.
+	Remaining int
+	// Failed is number of records real handler failed to handle so far.
+	Failed int
+}
+
+// FlushReport summarizes flush of buffered records to real handler (see
+// SetRealHandlerWithReport and ResumeWithReport).
+type FlushReport struct {
+	// Emitted is number of records passed to real handler, including ones it failed to handle.
+	Emitted int
+	// Skipped is number of records real handler was not enabled for (see WithFlushLevelCheck).
+	Skipped int
+	// Failed is number of records real handler failed to handle.
+	Failed int
+	// Duration is time flush took.
+	Duration time.Duration
+	// Bytes is approximate size of emitted records, estimated the same way as by ApproxBytes.
+	Bytes int64
+}
+
+// add adds counts of provided report to the report.
+func (r *FlushReport) add(other FlushReport) {
+	r.Emitted += other.Emitted
+	r.Skipped += other.Skipped
+	r.Failed += other.Failed
+	r.Bytes += other.Bytes
+}
@@ -0,0 +1,129 @@
+package slogbuffer_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/delicb/slogbuffer"
+	"github.com/delicb/slogbuffer/slogbuffertest"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestBufferLogHandler_WithFlushProgress(t *testing.T) {
+	// given
+	var reports []slogbuffer.FlushProgress
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug,
+		slogbuffer.WithFlushProgress(2, func(p slogbuffer.FlushProgress) bool {
+			reports = append(reports, p)
+			return p.Emitted < 4
+		}),
+	)
+	l := slog.New(h)
+	for i := range 7 {
+		l.Info(fmt.Sprintf("msg %d", i))
+	}
+
+	// when
+	rh, reader := getSimplifiedTextHandler()
+	err := h.SetRealHandler(context.Background(), newFailingHandler(rh, 1))
+
+	// then
+	if !errors.Is(err, slogbuffer.ErrFlushAborted) || !errors.Is(err, errHandlerFailed) {
+		t.Fatalf("expected flush to be aborted, got: %v", err)
+	}
+	expected := []slogbuffer.FlushProgress{
+		{Emitted: 2, Remaining: 5, Failed: 1},
+		{Emitted: 4, Remaining: 3, Failed: 1},
+	}
+	if fmt.Sprint(reports) != fmt.Sprint(expected) {
+		t.Fatalf("expected progress reports %v, got %v", expected, reports)
+	}
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 3)
+	expectMsg(t, lines[0], "msg 1")
+	expectMsg(t, lines[2], "msg 3")
+
+	// records that were not flushed are kept for retry
+	if err := h.RetryFlush(context.Background(), slogbuffer.RetryPolicy{}); err != nil {
+		t.Fatalf("retrying flush: %v", err)
+	}
+	lines = getLines(t, reader)
+	expectLinesNo(t, lines, 4)
+	expectMsg(t, lines[0], "msg 0")
+	expectMsg(t, lines[1], "msg 4")
+	expectMsg(t, lines[3], "msg 6")
+}
+
+// advancingHandler advances clock whenever it handles record, simulating slow sink.
+type advancingHandler struct {
+	slog.Handler
+	clock *slogbuffertest.Clock
+}
+
+func (h *advancingHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.clock.Advance(time.Second)
+	return h.Handler.Handle(ctx, r)
+}
+
+func TestBufferLogHandler_SetRealHandlerWithReport(t *testing.T) {
+	// given
+	clock := slogbuffertest.NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug,
+		slogbuffer.WithFlushLevelCheck(true), slogbuffer.WithClock(clock))
+	l := slog.New(h)
+	l.Debug("debug msg")
+	l.Info("info msg 1")
+	l.Debug("debug msg")
+	l.Warn("warn msg 2")
+	l.Error("error msg 3")
+	expectedBytes := h.ApproxBytes()
+
+	// when
+	rh, reader := getSimplifiedTextHandler()
+	real := &advancingHandler{Handler: newFailingHandler(rh, 1), clock: clock}
+	report, err := h.SetRealHandlerWithReport(context.Background(), real)
+	lines := getLines(t, reader)
+
+	// then
+	if !errors.Is(err, errHandlerFailed) {
+		t.Fatalf("expected handler error, got %v", err)
+	}
+	expectLinesNo(t, lines, 2)
+	expected := slogbuffer.FlushReport{Emitted: 3, Skipped: 2, Failed: 1, Duration: 3 * time.Second}
+	if report.Bytes <= 0 || report.Bytes >= expectedBytes {
+		t.Fatalf("expected bytes of emitted records to be between 0 and %d, got %d", expectedBytes, report.Bytes)
+	}
+	report.Bytes = 0
+	if report != expected {
+		t.Fatalf("expected report %+v, got %+v", expected, report)
+	}
+}
+
+func TestBufferLogHandler_ResumeWithReport(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	if _, err := h.ResumeWithReport(context.Background()); !errors.Is(err, slogbuffer.ErrNoRealHandler) {
+		t.Fatalf("expected ErrNoRealHandler, got %v", err)
+	}
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandler(t, h, rh)
+	h.Pause()
+	l := slog.New(h)
+	for i := range 3 {
+		l.Info("msg", "no", i)
+	}
+
+	// when
+	report, err := h.ResumeWithReport(context.Background())
+
+	// then
+	if err != nil {
+		t.Fatalf("resuming: %v", err)
+	}
+	expectLinesNo(t, getLines(t, reader), 3)
+	if report.Emitted != 3 || report.Skipped != 0 || report.Failed != 0 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+}
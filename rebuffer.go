@@ -0,0 +1,61 @@
+package slogbuffer
+
+import (
+	"context"
+)
+
+// rebuffer switches handler back to buffering after real handler failed to handle a record and
+// starts background recovery, if it is not running already. It should be called on root handler.
+func (h *BufferLogHandler) rebuffer(policy RetryPolicy) {
+	h.setPaused(true)
+	if h.recovering.CompareAndSwap(false, true) {
+		go h.recover(policy)
+	}
+}
+
+// recover periodically re-attempts delivery of records that real handler failed to handle and
+// resumes passing records to real handler once it succeeds or handler is closed. It should be
+// called on root handler.
+func (h *BufferLogHandler) recover(policy RetryPolicy) {
+	ctx := context.Background()
+	for {
+		for attempt := 0; ; attempt++ {
+			select {
+			case <-h.closed:
+				return
+			case <-h.getOptions().clock.After(policy.backoff(attempt)):
+			}
+			if h.isClosed() {
+				// both channels might have been ready, closing takes precedence
+				return
+			}
+			// dead letters are delivered first and delivery stops at first failure, so records
+			// buffered in the meantime are not flushed to real handler that is still failing
+			if h.RetryFlush(ctx, RetryPolicy{}) != nil {
+				continue
+			}
+			if h.Resume(ctx) == nil {
+				break
+			}
+			h.setPaused(true)
+		}
+		h.recovering.Store(false)
+
+		// real handler might have failed again after recovery was done, but before it
+		// was marked as such, in which case nobody else would start recovery
+		if h.deadLetters.Len() == 0 || !h.recovering.CompareAndSwap(false, true) {
+			return
+		}
+		h.setPaused(true)
+	}
+}
+
+// isClosed reports if handler was closed. It should be called on root handler.
+func (h *BufferLogHandler) isClosed() bool {
+	select {
+	case <-h.closed:
+		return true
+	default:
+		return false
+	}
+}
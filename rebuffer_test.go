@@ -0,0 +1,118 @@
+package slogbuffer_test
+
+import (
+	"context"
+	"github.com/delicb/slogbuffer"
+	"github.com/delicb/slogbuffer/slogbuffertest"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakySink is [slog.Handler] that collects messages of records, unless it is down.
+// It is safe for concurrent use.
+type flakySink struct {
+	lock     sync.Mutex
+	down     bool
+	messages []string
+}
+
+func (s *flakySink) setDown(down bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.down = down
+}
+
+func (s *flakySink) received() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return slices.Clone(s.messages)
+}
+
+func (s *flakySink) Enabled(context.Context, slog.Level) bool { return true }
+
+func (s *flakySink) Handle(_ context.Context, r slog.Record) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.down {
+		return errHandlerFailed
+	}
+	s.messages = append(s.messages, r.Message)
+	return nil
+}
+
+func (s *flakySink) WithAttrs([]slog.Attr) slog.Handler { return s }
+
+func (s *flakySink) WithGroup(string) slog.Handler { return s }
+
+func TestBufferLogHandler_WithAutoRebuffer(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug,
+		slogbuffer.WithAutoRebuffer(slogbuffer.RetryPolicy{InitialBackoff: time.Millisecond}))
+	l := slog.New(h)
+	sink := &flakySink{}
+	setRealHandler(t, h, sink)
+	l.Info("first")
+
+	// when
+	sink.setDown(true)
+	l.Info("second")
+	l.Info("third")
+	l.Info("fourth")
+
+	// then
+	if !h.IsBuffering() {
+		t.Fatalf("expected handler to buffer records while real handler is failing")
+	}
+
+	// when
+	sink.setDown(false)
+
+	// then
+	expected := []string{"first", "second", "third", "fourth"}
+	deadline := time.Now().Add(5 * time.Second)
+	for !slices.Equal(sink.received(), expected) {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %v to be delivered, got %v", expected, sink.received())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	for h.IsBuffering() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	l.Info("fifth")
+	if got := sink.received(); got[len(got)-1] != "fifth" {
+		t.Fatalf("expected record to be passed to real handler after recovery, got %v", got)
+	}
+}
+
+func TestBufferLogHandler_WithAutoRebuffer_Close(t *testing.T) {
+	// given
+	clock := slogbuffertest.NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithClock(clock),
+		slogbuffer.WithAutoRebuffer(slogbuffer.RetryPolicy{InitialBackoff: time.Second}))
+	sink := &flakySink{}
+	setRealHandler(t, h, sink)
+	sink.setDown(true)
+	slog.New(h).Info("failed")
+	clock.WaitForTimers(1)
+
+	// when
+	if err := h.Close(); err != nil {
+		t.Fatalf("closing handler: %v", err)
+	}
+	sink.setDown(false)
+	clock.Advance(time.Minute)
+
+	// then
+	// recovery stopped, so failed record is not delivered even though real handler recovered
+	time.Sleep(50 * time.Millisecond)
+	if received := sink.received(); len(received) != 0 {
+		t.Fatalf("expected no delivery after handler was closed, got %v", received)
+	}
+	if !h.IsBuffering() {
+		t.Fatalf("expected handler to keep buffering after it was closed")
+	}
+}
@@ -0,0 +1,25 @@
+package slogbuffer
+
+import (
+	"context"
+	"log/slog"
+)
+
+// RecordMiddleware is called for every record handled by BufferLogHandler, before it is stored
+// in the buffer or passed to real handler. It returns record to continue with, which can be
+// enriched or otherwise changed, and reports false if record should be dropped. Record is
+// already cloned, so it can be modified in place.
+type RecordMiddleware func(ctx context.Context, r slog.Record) (slog.Record, bool)
+
+// applyMiddleware runs provided record through chain of middleware, in order. It reports false
+// if any of them dropped the record, in which case remaining ones are not called.
+func applyMiddleware(ctx context.Context, r slog.Record, middleware []RecordMiddleware) (slog.Record, bool) {
+	r = r.Clone()
+	for _, mw := range middleware {
+		var keep bool
+		if r, keep = mw(ctx, r); !keep {
+			return r, false
+		}
+	}
+	return r, true
+}
@@ -0,0 +1,51 @@
+package slogbuffer_test
+
+import (
+	"context"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestBufferLogHandler_WithRecordMiddleware(t *testing.T) {
+	// given
+	var calls []string
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithRecordMiddleware(
+		func(_ context.Context, r slog.Record) (slog.Record, bool) {
+			calls = append(calls, "drop")
+			return r, !strings.HasPrefix(r.Message, "noisy")
+		},
+		func(_ context.Context, r slog.Record) (slog.Record, bool) {
+			calls = append(calls, "enrich")
+			r.AddAttrs(slog.String("enriched", "yes"))
+			return r, true
+		},
+	))
+	l := slog.New(h).WithGroup("g1")
+
+	// when
+	l.Info("buffered msg")
+	l.Info("noisy buffered msg")
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandler(t, h, rh)
+	l.Info("direct msg")
+	l.Info("noisy direct msg")
+
+	// then
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 2)
+	expectMsg(t, lines[0], "buffered msg")
+	expectAttr(t, lines[0], "g1.enriched", "yes")
+	expectMsg(t, lines[1], "direct msg")
+	expectAttr(t, lines[1], "g1.enriched", "yes")
+
+	// dropped records do not reach the rest of the chain
+	expected := []string{"drop", "enrich", "drop", "drop", "enrich", "drop"}
+	if strings.Join(calls, ",") != strings.Join(expected, ",") {
+		t.Fatalf("unexpected middleware calls %v", calls)
+	}
+	if h.Dropped() != 0 {
+		t.Fatalf("expected no records reported as dropped, got %d", h.Dropped())
+	}
+}
@@ -0,0 +1,66 @@
+package slogbuffer
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// Redacted is value that replaces redacted values.
+const Redacted = "[REDACTED]"
+
+// RedactionRule rewrites attribute, e.g. replacing sensitive value with Redacted. Groups
+// are names of groups attribute belongs to. Like with [slog.HandlerOptions.ReplaceAttr],
+// attribute is dropped if returned key is empty. Attribute values are already resolved.
+type RedactionRule func(groups []string, a slog.Attr) slog.Attr
+
+// RedactKeys returns rule that redacts values of attributes with provided keys
+// (compared case-insensitively), regardless of their groups.
+func RedactKeys(keys ...string) RedactionRule {
+	return func(_ []string, a slog.Attr) slog.Attr {
+		for _, key := range keys {
+			if strings.EqualFold(a.Key, key) {
+				return slog.String(a.Key, Redacted)
+			}
+		}
+		return a
+	}
+}
+
+// RedactPattern returns rule that redacts parts of string values that match provided
+// regular expression (e.g. tokens or credit card numbers).
+func RedactPattern(pattern *regexp.Regexp) RedactionRule {
+	return func(_ []string, a slog.Attr) slog.Attr {
+		if a.Value.Kind() != slog.KindString {
+			return a
+		}
+		if s := a.Value.String(); pattern.MatchString(s) {
+			return slog.String(a.Key, pattern.ReplaceAllLiteralString(s, Redacted))
+		}
+		return a
+	}
+}
+
+// redact applies all provided rules to attribute, in order.
+func redact(rules []RedactionRule) func([]string, slog.Attr) slog.Attr {
+	return func(groups []string, a slog.Attr) slog.Attr {
+		for _, rule := range rules {
+			if a = rule(groups, a); len(a.Key) == 0 {
+				return a
+			}
+		}
+		return a
+	}
+}
+
+// redactRecord returns new record with attributes redacted using provided rules.
+func redactRecord(r slog.Record, groups []string, rules []RedactionRule) slog.Record {
+	res := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		if a, ok := replaceAttr(groups, a, redact(rules)); ok {
+			res.AddAttrs(a)
+		}
+		return true
+	})
+	return res
+}
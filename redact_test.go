@@ -0,0 +1,60 @@
+package slogbuffer_test
+
+import (
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestBufferLogHandler_WithRedaction(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithRedaction(
+		slogbuffer.RedactKeys("password"),
+		slogbuffer.RedactPattern(regexp.MustCompile(`tok_[a-z0-9]+`)),
+		func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) > 0 && groups[len(groups)-1] == "card" {
+				return slog.Attr{}
+			}
+			return a
+		},
+	))
+	l := slog.New(h)
+
+	// when
+	l.WithGroup("g1").With("Password", "secret").Info("first msg",
+		"auth", "bearer tok_abc123",
+		slog.Group("card", "number", "4111111111111111"),
+		"foo", "bar",
+	)
+
+	// then
+	// records are redacted while buffered
+	for _, r := range h.Records() {
+		expectNoContains(t, r, "secret", "tok_abc123", "4111111111111111")
+	}
+
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandler(t, h, rh)
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 1)
+	expectAttr(t, lines[0], "g1.Password", slogbuffer.Redacted)
+	expectAttr(t, lines[0], "g1.auth", "bearer "+slogbuffer.Redacted)
+	expectAttr(t, lines[0], "g1.foo", "bar")
+	expectNoAttr(t, lines[0], "g1.card.number", "4111111111111111")
+}
+
+// expectNoContains fails if textual representation of any attribute of record contains
+// any of provided values.
+func expectNoContains(t *testing.T, r slog.Record, values ...string) {
+	t.Helper()
+	r.Attrs(func(a slog.Attr) bool {
+		for _, v := range values {
+			if strings.Contains(a.String(), v) {
+				t.Fatalf("unexpected value %s in attribute %s", v, a)
+			}
+		}
+		return true
+	})
+}
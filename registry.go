@@ -0,0 +1,57 @@
+package slogbuffer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// registry holds named handlers registered using Register.
+var registry = struct {
+	handlers map[string]*BufferLogHandler
+	lock     sync.Mutex
+}{handlers: make(map[string]*BufferLogHandler)}
+
+// Register adds handler to global registry under provided name, replacing handler previously
+// registered under the same name. This is useful for applications with many subsystems that
+// buffer records independently, since all of them can be bound to real handler using FlushAll.
+func Register(name string, h *BufferLogHandler) {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+	registry.handlers[name] = h
+}
+
+// Unregister removes handler registered under provided name from global registry.
+func Unregister(name string) {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+	delete(registry.handlers, name)
+}
+
+// Registered returns handler registered under provided name, or nil if there is none.
+func Registered(name string) *BufferLogHandler {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+	return registry.handlers[name]
+}
+
+// FlushAll sets provided real handler on all registered handlers (see SetRealHandler),
+// in order of their names. Errors of all handlers are combined into returned error.
+func FlushAll(ctx context.Context, real slog.Handler) error {
+	// handlers are flushed without holding the lock, so real handler is free to use registry
+	registry.lock.Lock()
+	handlers := maps.Clone(registry.handlers)
+	registry.lock.Unlock()
+
+	var flushErr error
+	for _, name := range slices.Sorted(maps.Keys(handlers)) {
+		if err := handlers[name].SetRealHandler(ctx, real); err != nil {
+			flushErr = errors.Join(flushErr, fmt.Errorf("flushing %q: %w", name, err))
+		}
+	}
+	return flushErr
+}
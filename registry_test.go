@@ -0,0 +1,45 @@
+package slogbuffer_test
+
+import (
+	"context"
+	"errors"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"testing"
+)
+
+func TestFlushAll(t *testing.T) {
+	// given
+	db := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	http := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	slogbuffer.Register("http", http)
+	slogbuffer.Register("db", db)
+	t.Cleanup(func() {
+		slogbuffer.Unregister("db")
+		slogbuffer.Unregister("http")
+	})
+	if slogbuffer.Registered("db") != db {
+		t.Fatalf("expected registered handler to be returned")
+	}
+
+	slog.New(http).Info("http msg")
+	slog.New(db).Info("db msg")
+
+	// when
+	rh, reader := getSimplifiedTextHandler()
+	err := slogbuffer.FlushAll(context.Background(), newFailingHandler(rh, 1))
+
+	// then
+	// handlers are flushed in order of names, so record of "db" handler failed
+	if !errors.Is(err, errHandlerFailed) {
+		t.Fatalf("expected error of failing handler, got: %v", err)
+	}
+	expectContains(t, err.Error(), `"db"`)
+
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 1)
+	expectMsg(t, lines[0], "http msg")
+	if db.IsBuffering() || http.IsBuffering() {
+		t.Fatalf("expected all registered handlers to be flushed")
+	}
+}
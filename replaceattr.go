@@ -0,0 +1,81 @@
+package slogbuffer
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+)
+
+// replaceAttrHandler is [slog.Handler] that rewrites attributes using provided function
+// before passing them to wrapped handler, the same way as [slog.HandlerOptions.ReplaceAttr].
+type replaceAttrHandler struct {
+	handler slog.Handler
+	replace func(groups []string, a slog.Attr) slog.Attr
+	// groups are names of groups opened using WithGroup
+	groups []string
+}
+
+// compile time check that replaceAttrHandler implements slog.Handler interface.
+var _ slog.Handler = &replaceAttrHandler{}
+
+func (h *replaceAttrHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *replaceAttrHandler) Handle(ctx context.Context, r slog.Record) error {
+	res := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		if a, ok := replaceAttr(h.groups, a, h.replace); ok {
+			res.AddAttrs(a)
+		}
+		return true
+	})
+	return h.handler.Handle(ctx, res)
+}
+
+func (h *replaceAttrHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &replaceAttrHandler{
+		handler: h.handler.WithAttrs(replaceAttrs(h.groups, attrs, h.replace)),
+		replace: h.replace,
+		groups:  h.groups,
+	}
+}
+
+func (h *replaceAttrHandler) WithGroup(name string) slog.Handler {
+	if len(name) == 0 {
+		return h
+	}
+	return &replaceAttrHandler{
+		handler: h.handler.WithGroup(name),
+		replace: h.replace,
+		groups:  append(slices.Clip(h.groups), name),
+	}
+}
+
+// replaceAttr rewrites provided attribute, or members of group, recursively, using provided
+// function. It reports false if attribute should be dropped.
+func replaceAttr(groups []string, a slog.Attr, replace func([]string, slog.Attr) slog.Attr) (slog.Attr, bool) {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		// like with slog handlers, function is called for members of group, not for group itself
+		memberGroups := groups
+		if len(a.Key) > 0 {
+			memberGroups = append(slices.Clip(groups), a.Key)
+		}
+		a.Value = slog.GroupValue(replaceAttrs(memberGroups, a.Value.Group(), replace)...)
+		return a, true
+	}
+	a = replace(groups, a)
+	return a, len(a.Key) > 0
+}
+
+// replaceAttrs returns new slice with provided attributes rewritten using provided function.
+func replaceAttrs(groups []string, attrs []slog.Attr, replace func([]string, slog.Attr) slog.Attr) []slog.Attr {
+	res := make([]slog.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		if a, ok := replaceAttr(groups, a, replace); ok {
+			res = append(res, a)
+		}
+	}
+	return res
+}
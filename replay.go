@@ -0,0 +1,28 @@
+package slogbuffer
+
+import (
+	"log/slog"
+	"time"
+)
+
+// replayed returns copy of buffered record as it should be emitted when it is replayed
+// (flushed) at provided time, with replay related attributes added, if configured.
+// Provided record is not changed, so it can be retained if emitting fails.
+func (h *BufferLogHandler) replayed(rec record, now time.Time) record {
+	o := h.getOptions()
+	if len(o.replayMarker) == 0 && len(o.bufferDelayKey) == 0 && len(o.originalTimeKey) == 0 {
+		return rec
+	}
+	rec.Record = rec.Clone()
+	if len(o.replayMarker) > 0 {
+		rec.AddAttrs(slog.Bool(o.replayMarker, true))
+	}
+	if len(o.bufferDelayKey) > 0 {
+		rec.AddAttrs(slog.Duration(o.bufferDelayKey, now.Sub(rec.Time)))
+	}
+	if len(o.originalTimeKey) > 0 {
+		rec.AddAttrs(slog.Time(o.originalTimeKey, rec.Time))
+		rec.Time = now
+	}
+	return rec
+}
@@ -0,0 +1,96 @@
+package slogbuffer_test
+
+import (
+	"context"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBufferLogHandler_WithReplayMarker(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithReplayMarker("replayed"))
+	l := slog.New(h)
+	l.Info("first msg")
+	l.Info("second msg")
+
+	// when
+	rh, reader := getSimplifiedTextHandler()
+	err := h.SetRealHandler(context.Background(), newFailingHandler(rh, 1))
+	if err == nil {
+		t.Fatalf("expected error from flush")
+	}
+	if err := h.RetryFlush(context.Background(), slogbuffer.RetryPolicy{}); err != nil {
+		t.Fatalf("retrying flush: %v", err)
+	}
+	l.Info("live msg")
+
+	// then
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 3)
+	expectMsg(t, lines[0], "second msg")
+	expectAttr(t, lines[0], "replayed", "true")
+	// marker is added once, even though record was replayed twice
+	expectMsg(t, lines[1], "first msg")
+	expectAttr(t, lines[1], "replayed", "true")
+	if strings.Count(lines[1], "replayed=") != 1 {
+		t.Fatalf("expected single marker, line is %s", lines[1])
+	}
+	expectMsg(t, lines[2], "live msg")
+	expectNoAttr(t, lines[2], "replayed", "true")
+}
+
+func TestBufferLogHandler_WithBufferDelay(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithBufferDelay(""))
+	r := slog.NewRecord(time.Now().Add(-time.Hour), slog.LevelInfo, "old msg", 0)
+	if err := h.Handle(context.Background(), r); err != nil {
+		t.Fatalf("handling record: %v", err)
+	}
+
+	// when
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandler(t, h, rh)
+
+	// then
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 1)
+	match := regexp.MustCompile(`buffer_delay=(\S+)`).FindStringSubmatch(lines[0])
+	if match == nil {
+		t.Fatalf("expected buffer_delay attribute, line is %s", lines[0])
+	}
+	delay, err := time.ParseDuration(match[1])
+	if err != nil {
+		t.Fatalf("parsing delay: %v", err)
+	}
+	if delay < time.Hour || delay > time.Hour+time.Minute {
+		t.Fatalf("expected delay of about an hour, got %s", delay)
+	}
+}
+
+func TestBufferLogHandler_WithReplayTime(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithReplayTime(""))
+	original := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := h.Handle(context.Background(), slog.NewRecord(original, slog.LevelInfo, "old msg", 0)); err != nil {
+		t.Fatalf("handling record: %v", err)
+	}
+
+	// when
+	collector := &collectingHandler{}
+	before := time.Now()
+	setRealHandler(t, h, collector)
+	replayed := collector.records
+
+	// then
+	if len(replayed) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(replayed))
+	}
+	if replayed[0].Time.Before(before) {
+		t.Fatalf("expected time of record to be time of replay, got %s", replayed[0].Time)
+	}
+	expectRecordAttr(t, replayed[0], "original_time", slog.TimeValue(original))
+}
@@ -0,0 +1,21 @@
+package slogbuffer
+
+import (
+	"errors"
+)
+
+// ErrResizeNotSupported is returned by Resize if capacity of storage used by the handler can
+// not be changed, e.g. for file storage (which is bound by size of the file) or storage with
+// per-level capacities.
+var ErrResizeNotSupported = errors.New("slogbuffer: resize not supported")
+
+// Resize changes maximum number of records handler can buffer, so limit can be raised when
+// application detects that real handler will be late. If there are more records buffered than
+// new limit allows, the oldest ones are evicted. Zero makes buffer unbound.
+func (h *BufferLogHandler) Resize(maxRecords int) error {
+	r, ok := h.buffer.(resizer)
+	if !ok {
+		return ErrResizeNotSupported
+	}
+	return r.resize(max(maxRecords, 0))
+}
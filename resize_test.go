@@ -0,0 +1,89 @@
+package slogbuffer_test
+
+import (
+	"errors"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"path/filepath"
+	"testing"
+)
+
+func TestBufferLogHandler_Resize(t *testing.T) {
+	configs := map[string][]slogbuffer.Option{
+		"memory":     nil,
+		"compressed": {slogbuffer.WithCompression(128)},
+		"sharded":    {slogbuffer.WithShards(2)},
+		"chunked":    {slogbuffer.WithChunkedGrowth(3)},
+		"pinned":     {slogbuffer.WithPin(slogbuffer.PinLevel(slog.LevelError))},
+	}
+	for name, opts := range configs {
+		t.Run(name, func(t *testing.T) {
+			// given
+			h := slogbuffer.NewBoundBufferLogHandler(slog.LevelDebug, 4, opts...)
+			l := slog.New(h)
+			for i := range 6 {
+				l.Info("msg", "no", i)
+			}
+
+			// when
+			// growing keeps buffered records and makes space for new ones
+			if err := h.Resize(8); err != nil {
+				t.Fatalf("growing buffer: %v", err)
+			}
+			for i := 6; i < 10; i++ {
+				l.Info("msg", "no", i)
+			}
+			grown := h.Len()
+			// shrinking evicts the oldest records
+			if err := h.Resize(2); err != nil {
+				t.Fatalf("shrinking buffer: %v", err)
+			}
+
+			// then
+			if grown != 8 {
+				t.Fatalf("expected 8 records after growing, got %d", grown)
+			}
+			if h.Len() != 2 || h.Cap() != 2 {
+				t.Fatalf("unexpected len %d and cap %d after shrinking", h.Len(), h.Cap())
+			}
+			records := h.Records()
+			expectRecordAttr(t, records[0], "no", slog.IntValue(8))
+			expectRecordAttr(t, records[1], "no", slog.IntValue(9))
+			if h.Dropped() != 8 {
+				t.Fatalf("expected 8 dropped records, got %d", h.Dropped())
+			}
+
+			// making buffer unbound keeps all records
+			if err := h.Resize(0); err != nil {
+				t.Fatalf("unbinding buffer: %v", err)
+			}
+			for i := range 100 {
+				l.Info("msg", "no", i)
+			}
+			if h.Len() != 102 || h.Cap() != 0 {
+				t.Fatalf("unexpected len %d and cap %d of unbound buffer", h.Len(), h.Cap())
+			}
+		})
+	}
+}
+
+func TestBufferLogHandler_Resize_NotSupported(t *testing.T) {
+	// given
+	leveled := slogbuffer.NewBoundBufferLogHandler(slog.LevelDebug, 10,
+		slogbuffer.WithLevelCapacity(slog.LevelDebug, 5), slogbuffer.WithLevelCapacity(slog.LevelWarn, 5))
+	file, err := slogbuffer.NewFileBufferLogHandler(filepath.Join(t.TempDir(), "buffer"), 1024, slog.LevelDebug)
+	if err != nil {
+		t.Fatalf("creating handler: %v", err)
+	}
+	defer file.Close()
+
+	// when
+	for _, h := range []*slogbuffer.BufferLogHandler{leveled, file} {
+		err := h.Resize(20)
+
+		// then
+		if !errors.Is(err, slogbuffer.ErrResizeNotSupported) {
+			t.Fatalf("expected ErrResizeNotSupported, got %v", err)
+		}
+	}
+}
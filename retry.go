@@ -0,0 +1,88 @@
+package slogbuffer
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// ErrNoRealHandler is returned by operations that require real handler when it is not set yet.
+var ErrNoRealHandler = errors.New("slogbuffer: real handler not set")
+
+// RetryPolicy controls how RetryFlush re-attempts delivery of records that
+// real handler failed to handle.
+type RetryPolicy struct {
+	// MaxAttempts is maximum number of delivery attempts. Values lower than 1 mean single attempt.
+	MaxAttempts int
+	// InitialBackoff is time to wait after first failed attempt.
+	InitialBackoff time.Duration
+	// MaxBackoff is upper limit for time between attempts. Zero means no limit.
+	MaxBackoff time.Duration
+	// Multiplier is factor by which backoff grows after each failed attempt.
+	// Values lower than 1 default to 2.
+	Multiplier float64
+}
+
+// backoff returns time to wait after given (zero based) failed attempt.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+	backoff := float64(p.InitialBackoff)
+	for range attempt {
+		backoff *= multiplier
+		if p.MaxBackoff > 0 && backoff >= float64(p.MaxBackoff) {
+			return p.MaxBackoff
+		}
+	}
+	return time.Duration(backoff)
+}
+
+// RetryFlush re-attempts delivery of records that real handler failed to handle, either
+// during previous flush or after real handler was set (see DeadLetters). Failed deliveries
+// are retried according to provided policy, waiting between attempts with exponential
+// backoff. Each attempt stops at first failed record, so records are delivered in the
+// order they were logged. Records that could not be delivered after all attempts are
+// kept, so RetryFlush can be called again later.
+func (h *BufferLogHandler) RetryFlush(ctx context.Context, policy RetryPolicy) error {
+	real := h.getRealHandler()
+	if real == nil {
+		return ErrNoRealHandler
+	}
+
+	attempts := max(policy.MaxAttempts, 1)
+	var flushErr error
+	for attempt := range attempts {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return errors.Join(flushErr, ctx.Err())
+			case <-h.getOptions().clock.After(policy.backoff(attempt - 1)):
+			}
+		}
+
+		flushErr = h.retryFailed(ctx, real)
+		if flushErr == nil {
+			return nil
+		}
+	}
+	return flushErr
+}
+
+// retryFailed tries to deliver dead letter records to real handler. Delivery stops at first
+// error and all remaining records are kept (in original order) for next attempt.
+func (h *BufferLogHandler) retryFailed(ctx context.Context, real slog.Handler) error {
+	records := h.deadLetters.Take()
+	now := h.now()
+	for i, rec := range records {
+		if err := h.replayed(rec, now).emit(ctx, real); err != nil {
+			for _, remaining := range records[i:] {
+				h.deadLetters.Add(remaining)
+			}
+			return newFlushError(1, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package slogbuffer_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestBufferLogHandler_RetryFlush(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	l := slog.New(h)
+
+	l.Info("first msg")
+	l.With("common", "attr").Warn("second msg")
+
+	rh, reader := getSimplifiedTextHandler()
+	if err := h.SetRealHandler(context.Background(), newFailingHandler(rh, 3)); err == nil {
+		t.Fatalf("expected error from flush")
+	}
+	expectLinesNo(t, getLines(t, reader), 0)
+
+	// when
+	err := h.RetryFlush(context.Background(), slogbuffer.RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("retrying flush: %v", err)
+	}
+	lines := getLines(t, reader)
+
+	// then
+	expectLinesNo(t, lines, 2)
+
+	expectMsg(t, lines[0], "first msg")
+	expectMsg(t, lines[1], "second msg")
+	expectAttr(t, lines[1], "common", "attr")
+}
+
+func TestBufferLogHandler_RetryFlush_ExhaustedAttempts(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	l := slog.New(h)
+
+	l.Info("info msg")
+
+	rh, reader := getSimplifiedTextHandler()
+	if err := h.SetRealHandler(context.Background(), newFailingHandler(rh, 3)); err == nil {
+		t.Fatalf("expected error from flush")
+	}
+
+	// when
+	err := h.RetryFlush(context.Background(), slogbuffer.RetryPolicy{MaxAttempts: 1})
+
+	// then
+	if !errors.Is(err, errHandlerFailed) {
+		t.Fatalf("expected handler error, got %v", err)
+	}
+	expectLinesNo(t, getLines(t, reader), 0)
+
+	// record is still kept for later retry
+	if err := h.RetryFlush(context.Background(), slogbuffer.RetryPolicy{MaxAttempts: 2}); err != nil {
+		t.Fatalf("retrying flush: %v", err)
+	}
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 1)
+	expectMsg(t, lines[0], "info msg")
+}
+
+func TestBufferLogHandler_RetryFlush_NoRealHandler(t *testing.T) {
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+
+	err := h.RetryFlush(context.Background(), slogbuffer.RetryPolicy{})
+	if !errors.Is(err, slogbuffer.ErrNoRealHandler) {
+		t.Fatalf("expected ErrNoRealHandler, got %v", err)
+	}
+}
+
+func TestBufferLogHandler_WithStopOnError(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithStopOnError(true))
+	l := slog.New(h)
+	for i := range 4 {
+		l.Info(fmt.Sprintf("msg %d", i))
+	}
+	rh, reader := getSimplifiedTextHandler()
+
+	// when
+	report, err := h.SetRealHandlerWithReport(context.Background(), newFailingHandler(rh, 1))
+
+	// then
+	if !errors.Is(err, slogbuffer.ErrFlushAborted) || !errors.Is(err, errHandlerFailed) {
+		t.Fatalf("expected aborted flush, got %v", err)
+	}
+	if report.Emitted != 1 || report.Failed != 1 {
+		t.Fatalf("expected flush to stop at first record, got %+v", report)
+	}
+	if h.State() != slogbuffer.Paused || len(h.DeadLetters()) != 4 {
+		t.Fatalf("expected paused handler with 4 kept records, got %s and %d", h.State(), len(h.DeadLetters()))
+	}
+
+	// records logged in the meantime are delivered after kept ones
+	l.Info("msg 4")
+	if err := h.RetryFlush(context.Background(), slogbuffer.RetryPolicy{}); err != nil {
+		t.Fatalf("retrying flush: %v", err)
+	}
+	if err := h.Resume(context.Background()); err != nil {
+		t.Fatalf("resuming: %v", err)
+	}
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 5)
+	for i, line := range lines {
+		expectMsg(t, line, fmt.Sprintf("msg %d", i))
+	}
+}
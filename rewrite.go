@@ -0,0 +1,73 @@
+package slogbuffer
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+)
+
+// rewriteHandler is [slog.Handler] that rewrites all attributes of the record (including ones
+// added using WithAttrs, nested in groups) before passing it to wrapped handler. Attributes and
+// groups are not passed to wrapped handler as they are added, since rewriting (e.g. removing
+// duplicate keys) might require all attributes of the record.
+type rewriteHandler struct {
+	handler slog.Handler
+	rewrite func([]slog.Attr) []slog.Attr
+	// attrs and groups are attributes and groups of this handler, attributes nested as by nest
+	attrs  []slog.Attr
+	groups []string
+}
+
+// compile time check that rewriteHandler implements slog.Handler interface.
+var _ slog.Handler = &rewriteHandler{}
+
+func (h *rewriteHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *rewriteHandler) Handle(ctx context.Context, r slog.Record) error {
+	res := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	res.AddAttrs(h.rewrite(record{Record: r, attrs: h.attrs, groups: h.groups}.allAttrs())...)
+	return h.handler.Handle(ctx, res)
+}
+
+func (h *rewriteHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return &rewriteHandler{
+		handler: h.handler,
+		rewrite: h.rewrite,
+		attrs:   nest(h.attrs, h.groups, attrs),
+		groups:  h.groups,
+	}
+}
+
+func (h *rewriteHandler) WithGroup(name string) slog.Handler {
+	if len(name) == 0 {
+		return h
+	}
+	return &rewriteHandler{
+		handler: h.handler,
+		rewrite: h.rewrite,
+		attrs:   h.attrs,
+		groups:  append(slices.Clip(h.groups), name),
+	}
+}
+
+// attrsRewrite returns function that rewrites attributes of records passed to real handler
+// according to options, or nil if attributes are passed unchanged.
+func (o *options) attrsRewrite() func([]slog.Attr) []slog.Attr {
+	if o.dedup == nil && len(o.flattenSeparator) == 0 {
+		return nil
+	}
+	return func(attrs []slog.Attr) []slog.Attr {
+		if o.dedup != nil {
+			attrs = dedupAttrs(attrs, *o.dedup)
+		}
+		if len(o.flattenSeparator) > 0 {
+			attrs = flattenAttrs("", attrs, o.flattenSeparator)
+		}
+		return attrs
+	}
+}
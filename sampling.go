@@ -0,0 +1,42 @@
+package slogbuffer
+
+import (
+	"log/slog"
+	"sync/atomic"
+)
+
+// sampler decides which records are buffered, based on sampling rates per level.
+type sampler struct {
+	// rates holds sampling rate for levels that are sampled, it is not changed after creation
+	rates map[slog.Level]int
+	// counters holds number of records seen per sampled level
+	counters map[slog.Level]*atomic.Uint64
+}
+
+// newSampler returns sampler for provided rates, or nil if no level is sampled.
+func newSampler(rates map[slog.Level]int) *sampler {
+	if len(rates) == 0 {
+		return nil
+	}
+	s := &sampler{
+		rates:    rates,
+		counters: make(map[slog.Level]*atomic.Uint64, len(rates)),
+	}
+	for level := range rates {
+		s.counters[level] = new(atomic.Uint64)
+	}
+	return s
+}
+
+// keep reports if record of provided level should be buffered. First record of each
+// level is always kept, followed by every n-th one, where n is sampling rate of the level.
+func (s *sampler) keep(level slog.Level) bool {
+	if s == nil {
+		return true
+	}
+	rate, ok := s.rates[level]
+	if !ok || rate <= 1 {
+		return true
+	}
+	return (s.counters[level].Add(1)-1)%uint64(rate) == 0
+}
@@ -0,0 +1,198 @@
+package slogbuffer
+
+import (
+	"cmp"
+	"slices"
+	"sync/atomic"
+)
+
+// shardedStorage keeps records in memory, spread over multiple buffers (shards), each with
+// its own lock, so concurrent logging calls do not all wait for the same lock. Records are
+// numbered when they are added and distributed between shards in round-robin fashion, so
+// order is restored when records are read. For bound storage, each shard holds equal part of
+// maximum number of records, so eviction of the oldest records is approximate.
+type shardedStorage struct {
+	shards []*buffer[sequencedRecord]
+	// seq is number of the last added record
+	seq atomic.Uint64
+	// maxRecords is maximum number of records across all shards, 0 if not limited
+	maxRecords atomic.Int64
+}
+
+// sequencedRecord is record with number that defines its position among all records.
+type sequencedRecord struct {
+	record
+	seq uint64
+}
+
+// newShardedStorage creates storage with provided number of shards, which calls provided
+// hooks (if not nil) for every added, removed and evicted record.
+func newShardedStorage(maxRecords, shards int, onAdd, onRemove, onEvict func(record)) *shardedStorage {
+	s := &shardedStorage{}
+	s.maxRecords.Store(int64(maxRecords))
+	for range shards {
+		s.shards = append(s.shards, newSequencedBuffer(shardCapacity(maxRecords, shards), onAdd, onRemove, onEvict))
+	}
+	return s
+}
+
+// shardCapacity returns maximum number of records of each of provided number of shards, so
+// they can hold at least maxRecords records together.
+func shardCapacity(maxRecords, shards int) int {
+	if maxRecords <= 0 {
+		return 0
+	}
+	// rounding up, so storage can hold at least maxRecords records
+	return (maxRecords + shards - 1) / shards
+}
+
+// newSequencedBuffer creates buffer of sequenced records, which calls provided hooks (if not nil)
+// for every added, removed and evicted record.
+func newSequencedBuffer(maxRecords int, onAdd, onRemove, onEvict func(record)) *buffer[sequencedRecord] {
+	buf := newBuffer[sequencedRecord](maxRecords)
+	if onAdd != nil {
+		buf.onAdd = func(r sequencedRecord) { onAdd(r.record) }
+	}
+	if onRemove != nil {
+		buf.onRemove = func(r sequencedRecord) { onRemove(r.record) }
+	}
+	if onEvict != nil {
+		buf.onEvict = func(r sequencedRecord) { onEvict(r.record) }
+	}
+	return buf
+}
+
+func (s *shardedStorage) Add(rec record) error {
+	seq := s.seq.Add(1)
+	s.shards[seq%uint64(len(s.shards))].Add(sequencedRecord{record: rec, seq: seq})
+	return nil
+}
+
+func (s *shardedStorage) Take() []record {
+	return mergeSequenced(s.shards, func(b *buffer[sequencedRecord]) []sequencedRecord { return b.Take() })
+}
+
+func (s *shardedStorage) Snapshot() []record {
+	return mergeSequenced(s.shards, func(b *buffer[sequencedRecord]) []sequencedRecord { return b.Snapshot() })
+}
+
+func (s *shardedStorage) Head(n int) []record {
+	records := s.Snapshot()
+	return records[:min(max(n, 0), len(records))]
+}
+
+func (s *shardedStorage) Tail(n int) []record {
+	records := s.Snapshot()
+	return records[len(records)-min(max(n, 0), len(records)):]
+}
+
+func (s *shardedStorage) PopOldest() (record, bool) {
+	return popSequenced(s.shards)
+}
+
+func (s *shardedStorage) Clear() {
+	for _, shard := range s.shards {
+		shard.Clear()
+	}
+}
+
+func (s *shardedStorage) Len() int {
+	total := 0
+	for _, shard := range s.shards {
+		total += shard.Len()
+	}
+	return total
+}
+
+func (s *shardedStorage) Cap() int {
+	return int(s.maxRecords.Load())
+}
+
+func (s *shardedStorage) resize(maxRecords int) error {
+	s.maxRecords.Store(int64(maxRecords))
+	for _, shard := range s.shards {
+		shard.resize(shardCapacity(maxRecords, len(s.shards)))
+	}
+	return nil
+}
+
+// mergeSequenced collects records from all provided buffers using provided function and
+// returns them in the order they were added.
+func mergeSequenced(buffers []*buffer[sequencedRecord], collect func(*buffer[sequencedRecord]) []sequencedRecord) []record {
+	var all []sequencedRecord
+	for _, b := range buffers {
+		all = append(all, collect(b)...)
+	}
+	slices.SortFunc(all, func(a, b sequencedRecord) int { return cmp.Compare(a.seq, b.seq) })
+	res := make([]record, 0, len(all))
+	for _, r := range all {
+		res = append(res, r.record)
+	}
+	return res
+}
+
+// popSequenced removes the oldest record across all provided buffers and returns it. Records
+// removed concurrently might make it return record that is not the oldest one.
+func popSequenced(buffers []*buffer[sequencedRecord]) (record, bool) {
+	for {
+		var oldest *buffer[sequencedRecord]
+		var seq uint64
+		for _, b := range buffers {
+			if head, ok := b.PeekOldest(); ok && (oldest == nil || head.seq < seq) {
+				oldest, seq = b, head.seq
+			}
+		}
+		if oldest == nil {
+			return record{}, false
+		}
+		if r, ok := oldest.PopOldest(); ok {
+			return r.record, true
+		}
+	}
+}
+
+func (s *shardedStorage) evictWhile(evict func(oldest record) bool) {
+	evictSequenced(s.shards, evict)
+}
+
+// evictSequenced evicts the oldest records across all provided buffers as long as provided
+// function returns true for them. Records added concurrently might stop eviction early.
+func evictSequenced(buffers []*buffer[sequencedRecord], evict func(oldest record) bool) {
+	for {
+		var oldest *buffer[sequencedRecord]
+		var seq uint64
+		for _, b := range buffers {
+			if head, ok := b.PeekOldest(); ok && (oldest == nil || head.seq < seq) {
+				oldest, seq = b, head.seq
+			}
+		}
+		if oldest == nil {
+			return
+		}
+		evicted := false
+		oldest.evictWhile(func(r sequencedRecord) bool {
+			// only the oldest record across all buffers can be evicted
+			if evicted || r.seq != seq {
+				return false
+			}
+			evicted = evict(r.record)
+			return evicted
+		})
+		if !evicted {
+			return
+		}
+	}
+}
+
+func (s *shardedStorage) removeIf(remove func(rec record) bool) {
+	removeSequenced(s.shards, remove)
+}
+
+// removeSequenced removes records for which provided function returns true from all buffers.
+func removeSequenced(buffers []*buffer[sequencedRecord], remove func(rec record) bool) {
+	for _, buf := range buffers {
+		buf.removeIf(func(r sequencedRecord) bool {
+			return remove(r.record)
+		})
+	}
+}
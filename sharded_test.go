@@ -0,0 +1,87 @@
+package slogbuffer_test
+
+import (
+	"fmt"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"sync"
+	"testing"
+)
+
+func TestBufferLogHandler_WithShards(t *testing.T) {
+	// given
+	h := slogbuffer.NewBoundBufferLogHandler(slog.LevelDebug, 8, slogbuffer.WithShards(4))
+	l := slog.New(h)
+
+	// when
+	for i := range 12 {
+		l.WithGroup("g1").Info(fmt.Sprintf("msg %d", i))
+	}
+
+	// then
+	if h.Len() != 8 || h.Cap() != 8 {
+		t.Fatalf("expected 8 of 8 records, got %d of %d", h.Len(), h.Cap())
+	}
+	records := h.Records()
+	for i, r := range records {
+		if expected := fmt.Sprintf("msg %d", i+4); r.Message != expected {
+			t.Fatalf("expected %q at position %d, got %q", expected, i, r.Message)
+		}
+	}
+	if tail := h.Tail(2); len(tail) != 2 || tail[1].Message != "msg 11" {
+		t.Fatalf("unexpected tail: %v", tail)
+	}
+
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandler(t, h, rh)
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 8)
+	expectMsg(t, lines[0], "msg 4")
+	expectMsg(t, lines[7], "msg 11")
+}
+
+func TestBufferLogHandler_WithShards_Concurrent(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithShards(0))
+	l := slog.New(h)
+
+	// when
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 100 {
+				l.Info("msg", "goroutine", g, "i", i)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// then
+	if h.Len() != 800 {
+		t.Fatalf("expected 800 records, got %d", h.Len())
+	}
+}
+
+func BenchmarkBufferLogHandler_Parallel(b *testing.B) {
+	for _, bc := range []struct {
+		name string
+		opts []slogbuffer.Option
+	}{
+		{name: "single buffer", opts: nil},
+		{name: "sharded", opts: []slogbuffer.Option{slogbuffer.WithShards(0)}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			h := slogbuffer.NewBoundBufferLogHandler(slog.LevelDebug, 10000, bc.opts...)
+			l := slog.New(h)
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					l.Info("benchmark msg", "key", "value")
+				}
+			})
+		})
+	}
+}
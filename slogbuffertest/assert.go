@@ -0,0 +1,103 @@
+// Package slogbuffertest provides helpers for using [slogbuffer.BufferLogHandler] as test
+// double, so tests can assert on log output of code under test.
+package slogbuffertest
+
+import (
+	"fmt"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// AssertLogged checks that provided handler buffered record of given level, whose message
+// contains msgSubstr and which has all provided attributes (with equal values). Attributes in
+// groups are matched by key joined with group names using ".", e.g. slog.String("user.id", "1")
+// matches attribute "id" in group "user", and so does slog.Group("user", slog.String("id", "1")).
+// Test is marked as failed if there is no such record. Returns true if record was found.
+func AssertLogged(t testing.TB, h *slogbuffer.BufferLogHandler, level slog.Level, msgSubstr string, attrs ...slog.Attr) bool {
+	t.Helper()
+	if count(h, level, msgSubstr, attrs) > 0 {
+		return true
+	}
+	t.Errorf("expected record at level %s with message containing %q and attributes %v, got:\n%s",
+		level, msgSubstr, attrs, h)
+	return false
+}
+
+// AssertNotLogged checks that provided handler did not buffer record matching provided level,
+// message and attributes (see AssertLogged). Test is marked as failed if there is such record.
+// Returns true if no record was found.
+func AssertNotLogged(t testing.TB, h *slogbuffer.BufferLogHandler, level slog.Level, msgSubstr string, attrs ...slog.Attr) bool {
+	t.Helper()
+	if count(h, level, msgSubstr, attrs) == 0 {
+		return true
+	}
+	t.Errorf("expected no record at level %s with message containing %q and attributes %v, got:\n%s",
+		level, msgSubstr, attrs, h)
+	return false
+}
+
+// RequireCount checks that provided handler buffered exactly n records of given level and
+// stops the test if it did not.
+func RequireCount(t testing.TB, h *slogbuffer.BufferLogHandler, level slog.Level, n int) {
+	t.Helper()
+	if c := count(h, level, "", nil); c != n {
+		t.Fatalf("expected %d records at level %s, got %d:\n%s", n, level, c, h)
+	}
+}
+
+// count returns number of buffered records matching provided level, message and attributes.
+func count(h *slogbuffer.BufferLogHandler, level slog.Level, msgSubstr string, attrs []slog.Attr) int {
+	expected := make(map[string]slog.Value)
+	for _, a := range attrs {
+		flatten("", a, expected)
+	}
+
+	n := 0
+	for _, r := range h.Records() {
+		if r.Level != level || !strings.Contains(r.Message, msgSubstr) {
+			continue
+		}
+		actual := make(map[string]slog.Value)
+		r.Attrs(func(a slog.Attr) bool {
+			flatten("", a, actual)
+			return true
+		})
+		if hasAttrs(actual, expected) {
+			n++
+		}
+	}
+	return n
+}
+
+// flatten adds provided attribute to attrs, with keys of attributes in groups prefixed
+// with group names.
+func flatten(prefix string, a slog.Attr, attrs map[string]slog.Value) {
+	v := a.Value.Resolve()
+	if v.Kind() != slog.KindGroup {
+		attrs[prefix+a.Key] = v
+		return
+	}
+	if a.Key != "" {
+		prefix += a.Key + "."
+	}
+	for _, ga := range v.Group() {
+		flatten(prefix, ga, attrs)
+	}
+}
+
+// hasAttrs reports if actual attributes contain all expected ones with equal values.
+// Values of different kinds (e.g. int and int64) are compared using their text representation.
+func hasAttrs(actual, expected map[string]slog.Value) bool {
+	for key, ev := range expected {
+		av, ok := actual[key]
+		if !ok {
+			return false
+		}
+		if !av.Equal(ev) && fmt.Sprint(av.Any()) != fmt.Sprint(ev.Any()) {
+			return false
+		}
+	}
+	return true
+}
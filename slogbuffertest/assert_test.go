@@ -0,0 +1,94 @@
+package slogbuffertest_test
+
+import (
+	"fmt"
+	"github.com/delicb/slogbuffer"
+	"github.com/delicb/slogbuffer/slogbuffertest"
+	"log/slog"
+	"testing"
+)
+
+// recordingT is testing.TB that records failures instead of failing the test.
+type recordingT struct {
+	testing.TB
+	errors []string
+	fatal  bool
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *recordingT) Fatalf(format string, args ...any) {
+	t.Errorf(format, args...)
+	t.fatal = true
+}
+
+func newLogger() (*slog.Logger, *slogbuffer.BufferLogHandler) {
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	l := slog.New(h)
+	l.Debug("starting", "attempt", 1)
+	l.WithGroup("user").With("id", "42").Info("user logged in", "admin", true)
+	l.Error("request failed", slog.Group("http", slog.Int("status", 500)))
+	return l, h
+}
+
+func TestAssertLogged(t *testing.T) {
+	// given
+	_, h := newLogger()
+
+	for _, tc := range []struct {
+		name   string
+		level  slog.Level
+		msg    string
+		attrs  []slog.Attr
+		logged bool
+	}{
+		{name: "message substring", level: slog.LevelInfo, msg: "logged in", logged: true},
+		{name: "wrong level", level: slog.LevelWarn, msg: "logged in"},
+		{name: "wrong message", level: slog.LevelInfo, msg: "logged out"},
+		{name: "attribute", level: slog.LevelDebug, msg: "", attrs: []slog.Attr{slog.Int("attempt", 1)}, logged: true},
+		{name: "wrong attribute value", level: slog.LevelDebug, msg: "", attrs: []slog.Attr{slog.Int("attempt", 2)}},
+		{name: "dotted key", level: slog.LevelInfo, msg: "", attrs: []slog.Attr{slog.String("user.id", "42"), slog.Bool("user.admin", true)}, logged: true},
+		{name: "group", level: slog.LevelError, msg: "failed", attrs: []slog.Attr{slog.Group("http", slog.Int("status", 500))}, logged: true},
+		{name: "missing attribute", level: slog.LevelError, msg: "failed", attrs: []slog.Attr{slog.Int("status", 500)}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// when
+			logged := &recordingT{TB: t}
+			slogbuffertest.AssertLogged(logged, h, tc.level, tc.msg, tc.attrs...)
+			notLogged := &recordingT{TB: t}
+			slogbuffertest.AssertNotLogged(notLogged, h, tc.level, tc.msg, tc.attrs...)
+
+			// then
+			if (len(logged.errors) == 0) != tc.logged {
+				t.Fatalf("AssertLogged reported %v, expected record to be logged: %t", logged.errors, tc.logged)
+			}
+			if (len(notLogged.errors) == 0) == tc.logged {
+				t.Fatalf("AssertNotLogged reported %v, expected record to be logged: %t", notLogged.errors, tc.logged)
+			}
+		})
+	}
+}
+
+func TestRequireCount(t *testing.T) {
+	// given
+	l, h := newLogger()
+	l.Info("another one")
+
+	// when
+	ok := &recordingT{TB: t}
+	slogbuffertest.RequireCount(ok, h, slog.LevelInfo, 2)
+	failed := &recordingT{TB: t}
+	slogbuffertest.RequireCount(failed, h, slog.LevelError, 2)
+
+	// then
+	if ok.fatal || len(ok.errors) > 0 {
+		t.Fatalf("expected count to match, got %v", ok.errors)
+	}
+	if !failed.fatal {
+		t.Fatalf("expected test to be stopped")
+	}
+}
@@ -0,0 +1,81 @@
+package slogbuffertest
+
+import (
+	"github.com/delicb/slogbuffer"
+	"sync"
+	"time"
+)
+
+// Clock is [slogbuffer.Clock] whose time changes only when it is advanced, so time-dependent
+// features of buffer handler (see slogbuffer.WithClock) can be tested without sleeping.
+// It is safe for concurrent use.
+type Clock struct {
+	lock sync.Mutex
+	// changed is signalled when timer is added
+	changed *sync.Cond
+	now     time.Time
+	timers  []clockTimer
+}
+
+// clockTimer is channel waiting for clock to reach some time.
+type clockTimer struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// compile time check that Clock implements slogbuffer.Clock interface.
+var _ slogbuffer.Clock = &Clock{}
+
+// NewClock returns clock set to provided time.
+func NewClock(now time.Time) *Clock {
+	c := &Clock{now: now}
+	c.changed = sync.NewCond(&c.lock)
+	return c
+}
+
+// Now returns current time of the clock.
+func (c *Clock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+// After returns channel that receives time of the clock once it is advanced by provided duration.
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.timers = append(c.timers, clockTimer{at: c.now.Add(d), ch: ch})
+	c.changed.Broadcast()
+	return ch
+}
+
+// Advance moves the clock forward by provided duration, firing timers that expired.
+func (c *Clock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = c.now.Add(d)
+	pending := c.timers[:0]
+	for _, timer := range c.timers {
+		if timer.at.After(c.now) {
+			pending = append(pending, timer)
+		} else {
+			timer.ch <- c.now
+		}
+	}
+	c.timers = pending
+}
+
+// WaitForTimers blocks until at least n timers are waiting for the clock to be advanced, which
+// is useful when timer is started by background goroutine of the handler.
+func (c *Clock) WaitForTimers(n int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for len(c.timers) < n {
+		c.changed.Wait()
+	}
+}
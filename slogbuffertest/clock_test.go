@@ -0,0 +1,33 @@
+package slogbuffertest_test
+
+import (
+	"github.com/delicb/slogbuffer/slogbuffertest"
+	"testing"
+	"time"
+)
+
+func TestClock(t *testing.T) {
+	// given
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := slogbuffertest.NewClock(start)
+	short, long := c.After(time.Second), c.After(time.Minute)
+
+	// when
+	c.Advance(2 * time.Second)
+
+	// then
+	if fired := <-short; !fired.Equal(start.Add(2 * time.Second)) {
+		t.Fatalf("unexpected time of timer %s", fired)
+	}
+	select {
+	case <-long:
+		t.Fatalf("timer fired too early")
+	default:
+	}
+	c.WaitForTimers(1)
+	c.Advance(time.Minute)
+	<-long
+	if !c.Now().Equal(start.Add(62 * time.Second)) {
+		t.Fatalf("unexpected time %s", c.Now())
+	}
+}
@@ -0,0 +1,86 @@
+package slogbuffertest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"github.com/delicb/slogbuffer"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// updateFlag is name of flag that makes AssertGolden update golden files instead of comparing them.
+const updateFlag = "update"
+
+func init() {
+	// flag might already be defined by tests using this package
+	if flag.Lookup(updateFlag) == nil {
+		flag.Bool(updateFlag, false, "update golden files used by slogbuffertest.AssertGolden")
+	}
+}
+
+// AssertGolden renders records buffered by provided handler and compares them with content of
+// golden file at provided path (typically in testdata directory). Test is marked as failed if
+// they differ. When tests are run with -update flag, golden file is (re)written instead.
+//
+// Records are rendered deterministically, one per line in logfmt style, without time and with
+// attributes (including ones in groups, with keys joined using ".") sorted by key, so output does
+// not depend on time of the test run or order in which attributes were added. Returns true if
+// rendered records match golden file.
+func AssertGolden(t testing.TB, h *slogbuffer.BufferLogHandler, path string) bool {
+	t.Helper()
+	actual := renderGolden(h)
+
+	if f := flag.Lookup(updateFlag); f != nil && f.Value.String() == "true" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("creating directory of golden file: %v", err)
+		}
+		if err := os.WriteFile(path, actual, 0o644); err != nil {
+			t.Fatalf("updating golden file: %v", err)
+		}
+		return true
+	}
+
+	expected, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("golden file %s does not exist, run tests with -%s flag to create it", path, updateFlag)
+		return false
+	}
+	if err != nil {
+		t.Fatalf("reading golden file: %v", err)
+	}
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("records do not match golden file %s (run tests with -%s flag to update it)\nexpected:\n%s\ngot:\n%s",
+			path, updateFlag, expected, actual)
+		return false
+	}
+	return true
+}
+
+// renderGolden renders buffered records in deterministic format used by golden files.
+func renderGolden(h *slogbuffer.BufferLogHandler) []byte {
+	var buf bytes.Buffer
+	// level is not checked, since records are passed to Handle directly
+	th := slog.NewTextHandler(&buf, nil)
+	for _, r := range h.Records() {
+		attrs := make(map[string]slog.Value)
+		r.Attrs(func(a slog.Attr) bool {
+			flatten("", a, attrs)
+			return true
+		})
+		// zero time is omitted by text handler
+		rendered := slog.NewRecord(time.Time{}, r.Level, r.Message, 0)
+		for _, key := range slices.Sorted(maps.Keys(attrs)) {
+			rendered.AddAttrs(slog.Attr{Key: key, Value: attrs[key]})
+		}
+		_ = th.Handle(context.Background(), rendered)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,75 @@
+package slogbuffertest_test
+
+import (
+	"flag"
+	"github.com/delicb/slogbuffer"
+	"github.com/delicb/slogbuffer/slogbuffertest"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func goldenLogger() *slogbuffer.BufferLogHandler {
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	l := slog.New(h)
+	l.Debug("starting", "b", 2, "a", 1)
+	l.WithGroup("user").With("id", "42").Info("user logged in", "admin", true)
+	l.Error("request failed", slog.Group("http", slog.Int("status", 500), slog.String("method", "GET")))
+	return h
+}
+
+func TestAssertGolden(t *testing.T) {
+	// given
+	h := goldenLogger()
+
+	// when
+	matched := slogbuffertest.AssertGolden(t, h, filepath.Join("testdata", "records.golden"))
+
+	// then
+	if !matched {
+		t.Fatalf("expected records to match golden file")
+	}
+}
+
+func TestAssertGolden_Mismatch(t *testing.T) {
+	// given
+	h := goldenLogger()
+	slog.New(h).Info("unexpected")
+
+	// when
+	tt := &recordingT{TB: t}
+	matched := slogbuffertest.AssertGolden(tt, h, filepath.Join("testdata", "records.golden"))
+
+	// then
+	if matched || len(tt.errors) != 1 {
+		t.Fatalf("expected mismatch to be reported, got %v", tt.errors)
+	}
+}
+
+func TestAssertGolden_Update(t *testing.T) {
+	// given
+	h := goldenLogger()
+	path := filepath.Join(t.TempDir(), "testdata", "records.golden")
+	update := flag.Lookup("update").Value.String()
+	if err := flag.Set("update", "true"); err != nil {
+		t.Fatalf("setting update flag: %v", err)
+	}
+	defer func() { _ = flag.Set("update", update) }()
+
+	// when
+	slogbuffertest.AssertGolden(t, h, path)
+
+	// then
+	actual, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file: %v", err)
+	}
+	expected, err := os.ReadFile(filepath.Join("testdata", "records.golden"))
+	if err != nil {
+		t.Fatalf("reading golden file: %v", err)
+	}
+	if string(actual) != string(expected) {
+		t.Fatalf("expected golden file\n%s\ngot\n%s", expected, actual)
+	}
+}
@@ -0,0 +1,38 @@
+package slogbuffertest
+
+import (
+	"github.com/delicb/slogbuffer"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// New returns logger whose records at or above provided level are buffered for the duration of
+// the test and written to test log (using t.Log) when test finishes, but only if it failed. This
+// keeps output of passing tests quiet, while failures come with complete log output of code under
+// test. Returned buffer handler can be used with assertions of this package.
+func New(t testing.TB, level slog.Leveler) (*slog.Logger, *slogbuffer.BufferLogHandler) {
+	t.Helper()
+	h := slogbuffer.NewBufferLogHandler(level)
+	t.Cleanup(func() {
+		if !t.Failed() {
+			return
+		}
+		_ = h.DumpTo(testWriter{t}, func(w io.Writer) slog.Handler {
+			return slog.NewTextHandler(w, &slog.HandlerOptions{Level: level})
+		})
+	})
+	return slog.New(h), h
+}
+
+// testWriter writes each line to test log.
+type testWriter struct {
+	t testing.TB
+}
+
+func (w testWriter) Write(p []byte) (int, error) {
+	w.t.Helper()
+	w.t.Log(strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
@@ -0,0 +1,67 @@
+package slogbuffertest_test
+
+import (
+	"fmt"
+	"github.com/delicb/slogbuffer/slogbuffertest"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// loggingT is testing.TB that records logged lines and cleanup functions, so they
+// can be run explicitly.
+type loggingT struct {
+	testing.TB
+	failed   bool
+	lines    []string
+	cleanups []func()
+}
+
+func (t *loggingT) Helper() {}
+
+func (t *loggingT) Failed() bool { return t.failed }
+
+func (t *loggingT) Log(args ...any) {
+	t.lines = append(t.lines, fmt.Sprint(args...))
+}
+
+func (t *loggingT) Cleanup(f func()) {
+	t.cleanups = append(t.cleanups, f)
+}
+
+func (t *loggingT) finish() {
+	for _, f := range t.cleanups {
+		f()
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, failed := range []bool{false, true} {
+		t.Run(fmt.Sprintf("failed=%t", failed), func(t *testing.T) {
+			// given
+			tt := &loggingT{TB: t, failed: failed}
+			l, h := slogbuffertest.New(tt, slog.LevelInfo)
+
+			// when
+			l.Debug("debug msg")
+			l.Info("info msg", "key", "value")
+			l.Warn("warn msg")
+			slogbuffertest.RequireCount(t, h, slog.LevelInfo, 1)
+			tt.finish()
+
+			// then
+			if !failed {
+				if len(tt.lines) > 0 {
+					t.Fatalf("expected no output for passing test, got %v", tt.lines)
+				}
+				return
+			}
+			if len(tt.lines) != 2 {
+				t.Fatalf("expected 2 lines for failed test, got %v", tt.lines)
+			}
+			if !strings.Contains(tt.lines[0], `msg="info msg" key=value`) || !strings.Contains(tt.lines[1], `msg="warn msg"`) {
+				t.Fatalf("unexpected output %v", tt.lines)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package slogbuffertest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"testing/slogtest"
+)
+
+// TestHandler runs [slogtest] suite (that checks if handler follows rules of slog.Handler)
+// against handler created by provided build function, e.g. buffer handler with options used
+// by application. Build function receives real handler that captures output, which has to be
+// used as real handler (directly or wrapped) of created handler. Since buffering handlers pass
+// records to real handler only when flushed, build function also returns function that is
+// called after each record is logged to flush it, which can be nil for handlers that do not
+// need it.
+func TestHandler(t *testing.T, build func(t *testing.T, real slog.Handler) (slog.Handler, func(context.Context) error)) {
+	t.Helper()
+	type capture struct {
+		out   *bytes.Buffer
+		flush func(context.Context) error
+	}
+	captures := make(map[*testing.T]capture)
+
+	slogtest.Run(t, func(t *testing.T) slog.Handler {
+		out := &bytes.Buffer{}
+		h, flush := build(t, slog.NewJSONHandler(out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		captures[t] = capture{out: out, flush: flush}
+		return h
+	}, func(t *testing.T) map[string]any {
+		c := captures[t]
+		delete(captures, t)
+		if c.flush != nil {
+			if err := c.flush(context.Background()); err != nil {
+				t.Fatalf("flushing records: %v", err)
+			}
+		}
+		var m map[string]any
+		if err := json.Unmarshal(c.out.Bytes(), &m); err != nil {
+			t.Fatalf("parsing output %q: %v", c.out.String(), err)
+		}
+		return m
+	})
+}
@@ -0,0 +1,151 @@
+package slogbuffer_test
+
+import (
+	"context"
+	"errors"
+	"github.com/delicb/slogbuffer"
+	"github.com/delicb/slogbuffer/slogbuffertest"
+	"io"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// contextInjector passes provided context to wrapped handler instead of the one records are
+// logged with, since slogtest logs records with context that does not carry buffer handler.
+type contextInjector struct {
+	slog.Handler
+	ctx context.Context
+}
+
+func (h contextInjector) Enabled(_ context.Context, level slog.Level) bool {
+	return h.Handler.Enabled(h.ctx, level)
+}
+
+func (h contextInjector) Handle(_ context.Context, r slog.Record) error {
+	return h.Handler.Handle(h.ctx, r)
+}
+
+func (h contextInjector) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextInjector{Handler: h.Handler.WithAttrs(attrs), ctx: h.ctx}
+}
+
+func (h contextInjector) WithGroup(name string) slog.Handler {
+	return contextInjector{Handler: h.Handler.WithGroup(name), ctx: h.ctx}
+}
+
+func TestSlogtest(t *testing.T) {
+	tests := map[string]func(t *testing.T, real slog.Handler) (slog.Handler, func(context.Context) error){
+		"buffered": func(t *testing.T, real slog.Handler) (slog.Handler, func(context.Context) error) {
+			h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+			return h, func(ctx context.Context) error { return h.SetRealHandler(ctx, real) }
+		},
+		"flushed": func(t *testing.T, real slog.Handler) (slog.Handler, func(context.Context) error) {
+			h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+			setRealHandler(t, h, real)
+			return h, nil
+		},
+		"paused": func(t *testing.T, real slog.Handler) (slog.Handler, func(context.Context) error) {
+			h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+			setRealHandler(t, h, real)
+			h.Pause()
+			return h, h.Resume
+		},
+		"compressed": func(t *testing.T, real slog.Handler) (slog.Handler, func(context.Context) error) {
+			h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithCompression(0))
+			return h, func(ctx context.Context) error { return h.SetRealHandler(ctx, real) }
+		},
+		"sharded": func(t *testing.T, real slog.Handler) (slog.Handler, func(context.Context) error) {
+			h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithShards(4))
+			return h, func(ctx context.Context) error { return h.SetRealHandler(ctx, real) }
+		},
+		"chunked": func(t *testing.T, real slog.Handler) (slog.Handler, func(context.Context) error) {
+			h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithChunkedGrowth(2))
+			return h, func(ctx context.Context) error { return h.SetRealHandler(ctx, real) }
+		},
+		"lazy values": func(t *testing.T, real slog.Handler) (slog.Handler, func(context.Context) error) {
+			h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithValueResolution(false))
+			return h, func(ctx context.Context) error { return h.SetRealHandler(ctx, real) }
+		},
+		"file": func(t *testing.T, real slog.Handler) (slog.Handler, func(context.Context) error) {
+			h, err := slogbuffer.NewFileBufferLogHandler(filepath.Join(t.TempDir(), "buffer"), 64*1024, slog.LevelDebug)
+			if err != nil {
+				t.Fatalf("creating handler: %v", err)
+			}
+			return h, func(ctx context.Context) error { return h.SetRealHandler(ctx, real) }
+		},
+		"wal": func(t *testing.T, real slog.Handler) (slog.Handler, func(context.Context) error) {
+			h, err := slogbuffer.NewWALBufferLogHandler(filepath.Join(t.TempDir(), "wal"), 0, slog.LevelDebug)
+			if err != nil {
+				t.Fatalf("creating handler: %v", err)
+			}
+			return h, func(ctx context.Context) error { return h.SetRealHandler(ctx, real) }
+		},
+		"tee": func(t *testing.T, real slog.Handler) (slog.Handler, func(context.Context) error) {
+			h := slogbuffer.NewTeeBufferHandler(slog.NewTextHandler(io.Discard, nil), slog.LevelDebug, 0)
+			return h, func(ctx context.Context) error { return h.SetRealHandler(ctx, real) }
+		},
+		"context": func(t *testing.T, real slog.Handler) (slog.Handler, func(context.Context) error) {
+			bh := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+			h := slogbuffer.NewContextHandler(slog.NewTextHandler(io.Discard, nil))
+			return contextInjector{Handler: h, ctx: slogbuffer.NewContext(context.Background(), bh)},
+				func(ctx context.Context) error { return bh.SetRealHandler(ctx, real) }
+		},
+		"context fallback": func(t *testing.T, real slog.Handler) (slog.Handler, func(context.Context) error) {
+			return slogbuffer.NewContextHandler(real), nil
+		},
+		"keyed": func(t *testing.T, real slog.Handler) (slog.Handler, func(context.Context) error) {
+			// records do not carry tenant attribute, so all of them are stored under empty key
+			h := slogbuffer.NewKeyedBufferHandler(slogbuffer.KeyFromAttr("tenant"), slog.LevelDebug, 0)
+			return h, func(ctx context.Context) error { return h.Flush(ctx, "", real) }
+		},
+		"network": func(t *testing.T, real slog.Handler) (slog.Handler, func(context.Context) error) {
+			addr := filepath.Join(t.TempDir(), "sink.sock")
+			// records are formatted by real handler instead of being written to the connection,
+			// so connection only decides when they are delivered
+			h := slogbuffer.NewNetworkHandler("unix", addr, slog.LevelDebug, 0,
+				slogbuffer.WithNetworkFormat(func(io.Writer) slog.Handler { return real }),
+				slogbuffer.WithReconnectPolicy(slogbuffer.RetryPolicy{InitialBackoff: 10 * time.Millisecond}))
+			t.Cleanup(func() { _ = h.Close() })
+			return h, func(ctx context.Context) error {
+				ln, err := net.Listen("unix", addr)
+				if err != nil {
+					return err
+				}
+				t.Cleanup(func() { _ = ln.Close() })
+				conn := accept(t, ln)
+				t.Cleanup(func() { _ = conn.Close() })
+				// buffered records are delivered once handler resumes after connecting
+				for deadline := time.Now().Add(5 * time.Second); h.State() != slogbuffer.Flushed; {
+					if time.Now().After(deadline) {
+						return errors.New("records not delivered after connecting")
+					}
+					time.Sleep(time.Millisecond)
+				}
+				return nil
+			}
+		},
+		"multi": func(t *testing.T, real slog.Handler) (slog.Handler, func(context.Context) error) {
+			return slogbuffer.NewMultiHandler(real), nil
+		},
+		"async": func(t *testing.T, real slog.Handler) (slog.Handler, func(context.Context) error) {
+			h := slogbuffer.NewAsyncHandler(real)
+			t.Cleanup(func() { _ = h.Close(context.Background()) })
+			return h, h.Flush
+		},
+		"flight recorder": func(t *testing.T, real slog.Handler) (slog.Handler, func(context.Context) error) {
+			return slogbuffer.NewFlightRecorderHandler(real, slog.LevelDebug, 10), nil
+		},
+		"flight recorder dump": func(t *testing.T, real slog.Handler) (slog.Handler, func(context.Context) error) {
+			h := slogbuffer.NewFlightRecorderHandler(slog.NewTextHandler(io.Discard, nil), slog.LevelDebug, 10)
+			return h, func(ctx context.Context) error { return h.Dump(ctx, real) }
+		},
+	}
+	for name, build := range tests {
+		t.Run(name, func(t *testing.T) {
+			slogbuffertest.TestHandler(t, build)
+		})
+	}
+}
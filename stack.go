@@ -0,0 +1,35 @@
+package slogbuffer
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// maxStackDepth is maximum number of frames included in captured stack traces.
+const maxStackDepth = 64
+
+// callerStack returns stack trace of the calling goroutine, formatted like debug.Stack, but
+// without leading frames of slog and this package, so it starts at code that logged the record.
+func callerStack() string {
+	pcs := make([]uintptr, maxStackDepth)
+	// skip runtime.Callers and callerStack
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var sb strings.Builder
+	for frame, more := frames.Next(); ; frame, more = frames.Next() {
+		if sb.Len() > 0 || !isLoggingFrame(frame) {
+			_, _ = fmt.Fprintf(&sb, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		}
+		if !more {
+			return sb.String()
+		}
+	}
+}
+
+// isLoggingFrame reports if frame belongs to slog or this package.
+func isLoggingFrame(frame runtime.Frame) bool {
+	return strings.HasPrefix(frame.Function, "log/slog.") ||
+		strings.HasPrefix(frame.Function, "github.com/delicb/slogbuffer.")
+}
@@ -0,0 +1,159 @@
+package slogbuffer
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// bufferStats tracks number of buffered records per level and approximate size of buffered
+// records, so questions about buffered records can be answered without scanning the buffer.
+// Counters are updated without locking, so tracking does not add contention when many
+// goroutines log concurrently.
+type bufferStats struct {
+	// counts maps level to *atomic.Int64 counter. Counters are never removed, there
+	// is just a handful of levels in practice.
+	counts sync.Map
+	// bytes is approximate size of buffered records (see recordSize).
+	bytes atomic.Int64
+	// dropped is number of records that were evicted or not buffered at all.
+	dropped atomic.Uint64
+	// summary accumulates evicted records, nil if summary is disabled.
+	summary *evictionSummary
+	// opts are used to notify metrics and observers about dropped records.
+	opts *options
+}
+
+func newBufferStats(opts *options) *bufferStats {
+	s := &bufferStats{opts: opts}
+	if opts.evictionSummary != "" {
+		s.summary = newEvictionSummary(opts.evictionSummary)
+	}
+	return s
+}
+
+// counter returns counter for provided level, creating it if needed.
+func (s *bufferStats) counter(level slog.Level) *atomic.Int64 {
+	if counter, ok := s.counts.Load(level); ok {
+		return counter.(*atomic.Int64)
+	}
+	counter, _ := s.counts.LoadOrStore(level, new(atomic.Int64))
+	return counter.(*atomic.Int64)
+}
+
+// add records that record was added to the buffer.
+func (s *bufferStats) add(r record) {
+	s.counter(r.Level).Add(1)
+	s.bytes.Add(recordSize(r))
+}
+
+// remove records that record was removed from the buffer.
+func (s *bufferStats) remove(r record) {
+	s.counter(r.Level).Add(-1)
+	s.bytes.Add(-recordSize(r))
+}
+
+// evict records that record was removed to make space for new one.
+func (s *bufferStats) evict(r record) {
+	if s.summary != nil {
+		s.summary.add(r)
+	}
+	// eviction happens while adding record, context of the call is not available
+	s.drop(context.Background(), r)
+}
+
+// drop records that record was dropped.
+func (s *bufferStats) drop(ctx context.Context, r record) {
+	if s != nil {
+		s.dropped.Add(1)
+		s.opts.dropped(ctx, r)
+	}
+}
+
+// hasLevel reports if there is any record at or above provided level.
+// Complexity depends only on number of distinct levels buffered, not number of records.
+func (s *bufferStats) hasLevel(level slog.Level) bool {
+	if s == nil {
+		return false
+	}
+	found := false
+	s.counts.Range(func(l, counter any) bool {
+		found = l.(slog.Level) >= level && counter.(*atomic.Int64).Load() > 0
+		return !found
+	})
+	return found
+}
+
+// HasLevel reports if any currently buffered record is at or above provided level.
+// It does not scan the buffer, so it is cheap to call regardless of number of buffered records.
+func (h *BufferLogHandler) HasLevel(level slog.Level) bool {
+	return h.stats.hasLevel(level)
+}
+
+// Dropped returns number of records that were dropped: evicted from bound buffer (or from
+// dead letters, see DeadLetters) to make space for newer records or skipped because of
+// sampling (see WithSampling).
+func (h *BufferLogHandler) Dropped() uint64 {
+	if h.stats == nil {
+		return 0
+	}
+	return h.stats.dropped.Load()
+}
+
+// sizer is implemented by storages that know how much memory their records occupy
+// better than estimate of decoded records (e.g. when records are compressed).
+type sizer interface {
+	approxBytes() int64
+}
+
+// ApproxBytes returns estimate of memory held by buffered records: their messages and
+// attributes. Attributes of the logger are shared between records, so they are not
+// included, neither is content of values of arbitrary types (e.g. errors or structs).
+// It does not scan the buffer, so it is cheap to call, e.g. periodically to alert when
+// unbound buffer grows too much while waiting for real handler.
+func (h *BufferLogHandler) ApproxBytes() int64 {
+	if s, ok := h.buffer.(sizer); ok {
+		return s.approxBytes()
+	}
+	if h.stats == nil {
+		return 0
+	}
+	return h.stats.bytes.Load()
+}
+
+// sizes of types used for estimates of memory used by records
+const (
+	recordBaseSize = int64(unsafe.Sizeof(record{}))
+	attrSize       = int64(unsafe.Sizeof(slog.Attr{}))
+	// inlineAttrs is number of attributes slog.Record keeps without separate allocation
+	inlineAttrs = 5
+)
+
+// recordSize estimates memory held by provided record, excluding attributes of the logger.
+func recordSize(r record) int64 {
+	size := recordBaseSize + int64(len(r.Message))
+	if n := r.NumAttrs(); n > inlineAttrs {
+		size += int64(n-inlineAttrs) * attrSize
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		size += attrContentSize(a)
+		return true
+	})
+	return size
+}
+
+// attrContentSize estimates memory referenced by attribute, excluding attribute itself.
+func attrContentSize(a slog.Attr) int64 {
+	size := int64(len(a.Key))
+	switch a.Value.Kind() {
+	case slog.KindString:
+		size += int64(len(a.Value.String()))
+	case slog.KindGroup:
+		for _, ga := range a.Value.Group() {
+			size += attrSize + attrContentSize(ga)
+		}
+	}
+	return size
+}
@@ -0,0 +1,65 @@
+package slogbuffer
+
+// storage is place where buffered records are kept.
+// All implementations are safe for concurrent use.
+type storage interface {
+	// Add adds record to the storage, potentially evicting the oldest records.
+	Add(rec record) error
+	// Take removes all records and returns them in the order they were added.
+	Take() []record
+	// Snapshot returns all records in the order they were added, without removing them.
+	Snapshot() []record
+	// Head returns at most n oldest records, oldest first.
+	Head(n int) []record
+	// Tail returns at most n newest records, oldest first.
+	Tail(n int) []record
+	// PopOldest removes the oldest record and returns it. Returns false if storage is empty.
+	PopOldest() (record, bool)
+	// Clear removes all records.
+	Clear()
+	// Len returns number of stored records.
+	Len() int
+	// Cap returns maximum number of records storage can hold, 0 if number is not limited.
+	Cap() int
+}
+
+// evicter is implemented by storages that can evict the oldest records on demand, which is
+// used to enforce limits other than number of records.
+type evicter interface {
+	// evictWhile evicts the oldest records as long as provided function returns true for them.
+	evictWhile(evict func(oldest record) bool)
+}
+
+// remover is implemented by storages that can remove arbitrary records in place, keeping order
+// of remaining records.
+type remover interface {
+	// removeIf removes records for which provided function returns true. Removed records are
+	// not reported as evicted.
+	removeIf(remove func(rec record) bool)
+}
+
+// resizer is implemented by storages whose capacity can be changed after they are created.
+type resizer interface {
+	// resize changes maximum number of records storage can hold, evicting the oldest records
+	// if there are more of them. Zero means number of records is not limited.
+	resize(maxRecords int) error
+}
+
+// memoryStorage keeps records in memory, using buffer.
+type memoryStorage struct {
+	*buffer[record]
+}
+
+func (s memoryStorage) Add(rec record) error {
+	s.buffer.Add(rec)
+	return nil
+}
+
+func (s memoryStorage) resize(maxRecords int) error {
+	s.buffer.resize(maxRecords)
+	return nil
+}
+
+func (s memoryStorage) removeIf(remove func(rec record) bool) {
+	s.buffer.removeIf(remove)
+}
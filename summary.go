@@ -0,0 +1,81 @@
+package slogbuffer
+
+import (
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// evictionSummary accumulates information about records evicted from bound buffer, so it can
+// be reported as single record when buffer is flushed (see WithEvictionSummary).
+type evictionSummary struct {
+	lock     sync.Mutex
+	msg      string
+	counts   map[slog.Level]int
+	earliest time.Time
+	latest   time.Time
+}
+
+func newEvictionSummary(msg string) *evictionSummary {
+	return &evictionSummary{msg: msg, counts: make(map[slog.Level]int)}
+}
+
+// add folds evicted record into the summary.
+func (s *evictionSummary) add(r record) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.counts[r.Level]++
+	if s.earliest.IsZero() || r.Time.Before(s.earliest) {
+		s.earliest = r.Time
+	}
+	if r.Time.After(s.latest) {
+		s.latest = r.Time
+	}
+}
+
+// take returns record summarizing records evicted since previous call and resets the summary.
+// Returns false if no record was evicted.
+func (s *evictionSummary) take() (record, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(s.counts) == 0 {
+		return record{}, false
+	}
+
+	levels := slices.Sorted(maps.Keys(s.counts))
+	total := 0
+	byLevel := make([]slog.Attr, 0, len(levels))
+	for _, level := range levels {
+		total += s.counts[level]
+		byLevel = append(byLevel, slog.Int(level.String(), s.counts[level]))
+	}
+	// summary has level of the most severe evicted record and time of the oldest one, so
+	// it stays at the head of the flush even if records are sorted by time
+	r := slog.NewRecord(s.earliest, levels[len(levels)-1], s.msg, 0)
+	r.AddAttrs(
+		slog.Int("evicted", total),
+		slog.Attr{Key: "evicted_by_level", Value: slog.GroupValue(byLevel...)},
+		slog.Time("first_evicted", s.earliest),
+		slog.Time("last_evicted", s.latest),
+	)
+
+	clear(s.counts)
+	s.earliest, s.latest = time.Time{}, time.Time{}
+	return record{Record: r}, true
+}
+
+// takeRecords removes all buffered records (except ones that exceed age limit) and returns
+// them, preceded by summary of evicted records, if enabled and any record was evicted.
+func (h *BufferLogHandler) takeRecords() []record {
+	h.enforceBounds()
+	records := h.buffer.Take()
+	if h.stats == nil || h.stats.summary == nil {
+		return records
+	}
+	if summary, ok := h.stats.summary.take(); ok {
+		records = slices.Insert(records, 0, summary)
+	}
+	return records
+}
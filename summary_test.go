@@ -0,0 +1,48 @@
+package slogbuffer_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"testing"
+)
+
+func TestBufferLogHandler_WithEvictionSummary(t *testing.T) {
+	// given
+	h := slogbuffer.NewBoundBufferLogHandler(slog.LevelDebug, 2, slogbuffer.WithEvictionSummary(""))
+	l := slog.New(h)
+
+	// when
+	l.Debug("debug 1")
+	l.Warn("warn")
+	for i := range 4 {
+		l.Info(fmt.Sprintf("info %d", i))
+	}
+
+	// then
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandler(t, h, rh)
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 3)
+	expectMsg(t, lines[0], "records evicted from buffer")
+	expectLevel(t, lines[0], slog.LevelWarn)
+	expectAttr(t, lines[0], "evicted", "4")
+	expectAttr(t, lines[0], "evicted_by_level.DEBUG", "1")
+	expectAttr(t, lines[0], "evicted_by_level.INFO", "2")
+	expectAttr(t, lines[0], "evicted_by_level.WARN", "1")
+	expectContains(t, lines[0], "first_evicted=")
+	expectContains(t, lines[0], "last_evicted=")
+	expectMsg(t, lines[1], "info 2")
+	expectMsg(t, lines[2], "info 3")
+
+	// summary is reset after flush
+	h.Pause()
+	l.Info("after pause")
+	if err := h.Resume(context.Background()); err != nil {
+		t.Fatalf("resuming: %v", err)
+	}
+	lines = getLines(t, reader)
+	expectLinesNo(t, lines, 1)
+	expectMsg(t, lines[0], "after pause")
+}
@@ -0,0 +1,70 @@
+package slogbuffer
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// TeeBufferHandler is [slog.Handler] that buffers records like BufferLogHandler, but also
+// emits them immediately to a mirror handler (e.g. plain text handler writing to stderr),
+// so logs can be seen live even before real handler is configured. Once real handler is
+// set (and handler is not paused), mirror is no longer used and records are only passed
+// to real handler.
+//
+// All methods of BufferLogHandler (SetRealHandler, Pause, Records...) are available.
+type TeeBufferHandler struct {
+	*BufferLogHandler
+	// mirror receives records while they are being buffered
+	mirror slog.Handler
+}
+
+// NewTeeBufferHandler creates handler that buffers records with upper limit of maxRecords
+// (0 means unbound) and emits them to mirror handler as well, until real handler is set.
+func NewTeeBufferHandler(mirror slog.Handler, leveler slog.Leveler, maxRecords int, opts ...Option) *TeeBufferHandler {
+	return &TeeBufferHandler{
+		BufferLogHandler: NewBoundBufferLogHandler(leveler, maxRecords, opts...),
+		mirror:           mirror,
+	}
+}
+
+// Implementation of slog.Handler interface.
+
+// compile time check that TeeBufferHandler implements slog.Handler interface.
+var _ slog.Handler = &TeeBufferHandler{}
+
+func (h *TeeBufferHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.BufferLogHandler.Enabled(ctx, level) {
+		return true
+	}
+	return h.IsBuffering() && h.mirror.Enabled(ctx, level)
+}
+
+func (h *TeeBufferHandler) Handle(ctx context.Context, r slog.Record) error {
+	var mirrorErr error
+	if h.IsBuffering() && h.mirror.Enabled(ctx, r.Level) {
+		mirrorErr = h.mirror.Handle(ctx, r)
+	}
+	// mirror might accept records that buffer does not
+	if !h.BufferLogHandler.Enabled(ctx, r.Level) {
+		return mirrorErr
+	}
+	return errors.Join(mirrorErr, h.BufferLogHandler.Handle(ctx, r))
+}
+
+func (h *TeeBufferHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &TeeBufferHandler{
+		BufferLogHandler: h.BufferLogHandler.WithAttrs(attrs).(*BufferLogHandler),
+		mirror:           h.mirror.WithAttrs(attrs),
+	}
+}
+
+func (h *TeeBufferHandler) WithGroup(name string) slog.Handler {
+	if len(name) == 0 {
+		return h
+	}
+	return &TeeBufferHandler{
+		BufferLogHandler: h.BufferLogHandler.WithGroup(name).(*BufferLogHandler),
+		mirror:           h.mirror.WithGroup(name),
+	}
+}
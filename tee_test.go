@@ -0,0 +1,58 @@
+package slogbuffer_test
+
+import (
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"testing"
+)
+
+func TestTeeBufferHandler(t *testing.T) {
+	// given
+	mirror, mirrorReader := getSimplifiedTextHandler() // info level
+	h := slogbuffer.NewTeeBufferHandler(mirror, slog.LevelDebug, 0)
+	l := slog.New(h)
+
+	// when
+	l.Debug("debug msg")
+	l.WithGroup("g1").With("common", "attr").Info("info msg")
+
+	// then
+	// records are mirrored immediately, depending on level of mirror handler
+	mirrored := getLines(t, mirrorReader)
+	expectLinesNo(t, mirrored, 1)
+	expectMsg(t, mirrored[0], "info msg")
+	expectAttr(t, mirrored[0], "g1.common", "attr")
+
+	// and all records are buffered
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandler(t, h.BufferLogHandler, rh)
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 2)
+	expectMsg(t, lines[0], "debug msg")
+	expectMsg(t, lines[1], "info msg")
+	expectAttr(t, lines[1], "g1.common", "attr")
+
+	// after real handler is set, mirror is not used anymore
+	l.Info("after flush msg")
+	expectLinesNo(t, getLines(t, mirrorReader), 0)
+	lines = getLines(t, reader)
+	expectLinesNo(t, lines, 1)
+	expectMsg(t, lines[0], "after flush msg")
+}
+
+func TestTeeBufferHandler_MirrorLevel(t *testing.T) {
+	// given
+	mirror, mirrorReader := getSimplifiedTextHandler() // info level
+	h := slogbuffer.NewTeeBufferHandler(mirror, slog.LevelWarn, 0)
+	l := slog.New(h)
+
+	// when
+	l.Info("info msg")
+	l.Warn("warn msg")
+
+	// then
+	expectLinesNo(t, getLines(t, mirrorReader), 2)
+	if h.Len() != 1 {
+		t.Fatalf("expected 1 buffered record, got %d", h.Len())
+	}
+}
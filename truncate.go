@@ -0,0 +1,72 @@
+package slogbuffer
+
+import (
+	"log/slog"
+	"unicode/utf8"
+)
+
+// TruncatedKey is key of attribute added to records whose message or attribute values were
+// truncated (see WithMaxValueSize).
+const TruncatedKey = "truncated"
+
+// truncateRecord returns record with message and string values of attributes (including
+// members of groups) longer than maxBytes truncated, with TruncatedKey attribute added. If
+// nothing has to be truncated, provided record is returned.
+func truncateRecord(r slog.Record, maxBytes int) slog.Record {
+	msg, truncated := truncateString(r.Message, maxBytes)
+	attrs := make([]slog.Attr, 0, r.NumAttrs()+1)
+	r.Attrs(func(a slog.Attr) bool {
+		var attrTruncated bool
+		a, attrTruncated = truncateAttr(a, maxBytes)
+		truncated = truncated || attrTruncated
+		attrs = append(attrs, a)
+		return true
+	})
+	if !truncated {
+		return r
+	}
+	res := slog.NewRecord(r.Time, r.Level, msg, r.PC)
+	res.AddAttrs(append(attrs, slog.Bool(TruncatedKey, true))...)
+	return res
+}
+
+// truncateAttr returns attribute with string value (or string values of group members)
+// truncated to maxBytes, reporting if anything was truncated.
+func truncateAttr(a slog.Attr, maxBytes int) (slog.Attr, bool) {
+	switch a.Value.Kind() {
+	case slog.KindString:
+		s, truncated := truncateString(a.Value.String(), maxBytes)
+		if truncated {
+			a.Value = slog.StringValue(s)
+		}
+		return a, truncated
+	case slog.KindGroup:
+		members := a.Value.Group()
+		res := make([]slog.Attr, len(members))
+		truncated := false
+		for i, member := range members {
+			var memberTruncated bool
+			res[i], memberTruncated = truncateAttr(member, maxBytes)
+			truncated = truncated || memberTruncated
+		}
+		if truncated {
+			a.Value = slog.GroupValue(res...)
+		}
+		return a, truncated
+	default:
+		return a, false
+	}
+}
+
+// truncateString returns at most maxBytes long prefix of provided string, not splitting
+// multi-byte characters, reporting if string was truncated.
+func truncateString(s string, maxBytes int) (string, bool) {
+	if len(s) <= maxBytes {
+		return s, false
+	}
+	end := maxBytes
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	return s[:end], true
+}
@@ -0,0 +1,36 @@
+package slogbuffer_test
+
+import (
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestBufferLogHandler_WithMaxValueSize(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithMaxValueSize(8))
+	l := slog.New(h)
+
+	// when
+	l.Info("short", "payload", "small", "no", 1)
+	l.Info("very long message", "payload", strings.Repeat("x", 100), slog.Group("g", "nested", "ééééé"))
+
+	// then
+	records := h.Records()
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if records[0].Message != "short" || records[0].NumAttrs() != 2 {
+		t.Fatalf("expected short record to be unchanged")
+	}
+	expectRecordAttr(t, records[0], "payload", slog.StringValue("small"))
+
+	if records[1].Message != "very lon" {
+		t.Fatalf("expected truncated message, got %q", records[1].Message)
+	}
+	expectRecordAttr(t, records[1], "payload", slog.StringValue("xxxxxxxx"))
+	// multi-byte characters are not split
+	expectRecordAttr(t, records[1], "g", slog.GroupValue(slog.String("nested", "éééé")))
+	expectRecordAttr(t, records[1], slogbuffer.TruncatedKey, slog.BoolValue(true))
+}
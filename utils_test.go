@@ -3,14 +3,17 @@ package slogbuffer_test
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"github.com/delicb/slogbuffer"
 	"io"
 	"log/slog"
+	"path/filepath"
 	"regexp"
 	"slices"
 	"strings"
 	"testing"
+	"time"
 )
 
 func getSimplifiedTextHandler() (slog.Handler, io.Reader) {
@@ -101,3 +104,116 @@ func expectNoAttr(t *testing.T, line string, key string, value string) {
 		t.Fatalf("unexpected attribute %s, line is %s", unexpectedAttr, line)
 	}
 }
+
+var errHandlerFailed = errors.New("handler failed")
+
+// failingHandler wraps handler and fails first `failures` calls to Handle.
+// Counter of failures is shared between derived handlers.
+type failingHandler struct {
+	slog.Handler
+	failures *int
+}
+
+func newFailingHandler(h slog.Handler, failures int) *failingHandler {
+	return &failingHandler{Handler: h, failures: &failures}
+}
+
+func (h *failingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if *h.failures > 0 {
+		*h.failures--
+		return errHandlerFailed
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *failingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &failingHandler{Handler: h.Handler.WithAttrs(attrs), failures: h.failures}
+}
+
+func (h *failingHandler) WithGroup(name string) slog.Handler {
+	return &failingHandler{Handler: h.Handler.WithGroup(name), failures: h.failures}
+}
+
+func newRecord(level slog.Level, msg string, args ...any) slog.Record {
+	r := slog.NewRecord(time.Now(), level, msg, 0)
+	r.Add(args...)
+	return r
+}
+
+func expectRecordAttr(t *testing.T, r slog.Record, key string, value slog.Value) {
+	t.Helper()
+	found := false
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == key {
+			found = true
+			if !a.Value.Equal(value) {
+				t.Fatalf("attribute %s has value %v, expected %v", key, a.Value, value)
+			}
+			return false
+		}
+		return true
+	})
+	if !found {
+		t.Fatalf("attribute %s not found in record", key)
+	}
+}
+
+func expectContains(t *testing.T, line string, substr string) {
+	t.Helper()
+	if !strings.Contains(line, substr) {
+		t.Fatalf("expected %s, line is %s", substr, line)
+	}
+}
+
+// collectingHandler is [slog.Handler] that collects all records it handles. Attributes
+// and groups are ignored.
+type collectingHandler struct {
+	records []slog.Record
+}
+
+func (h *collectingHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *collectingHandler) Handle(_ context.Context, r slog.Record) error {
+	h.records = append(h.records, r.Clone())
+	return nil
+}
+
+func (h *collectingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *collectingHandler) WithGroup(string) slog.Handler { return h }
+
+// storageHandlers builds handlers with all kinds of storages records can be kept in.
+var storageHandlers = map[string]func(t *testing.T) *slogbuffer.BufferLogHandler{
+	"memory": func(t *testing.T) *slogbuffer.BufferLogHandler {
+		return slogbuffer.NewBoundBufferLogHandler(slog.LevelDebug, 100)
+	},
+	"compressed": func(t *testing.T) *slogbuffer.BufferLogHandler {
+		return slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithCompression(128))
+	},
+	"chunked": func(t *testing.T) *slogbuffer.BufferLogHandler {
+		return slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithChunkedGrowth(4))
+	},
+	"sharded": func(t *testing.T) *slogbuffer.BufferLogHandler {
+		return slogbuffer.NewBufferLogHandler(slog.LevelDebug, slogbuffer.WithShards(3))
+	},
+	"leveled": func(t *testing.T) *slogbuffer.BufferLogHandler {
+		return slogbuffer.NewBoundBufferLogHandler(slog.LevelDebug, 100,
+			slogbuffer.WithLevelCapacity(slog.LevelWarn, 10), slogbuffer.WithPin(slogbuffer.PinAttr("no", 3)))
+	},
+	"file": func(t *testing.T) *slogbuffer.BufferLogHandler {
+		h, err := slogbuffer.NewFileBufferLogHandler(filepath.Join(t.TempDir(), "buffer"), 64*1024, slog.LevelDebug)
+		if err != nil {
+			t.Fatalf("creating handler: %v", err)
+		}
+		t.Cleanup(func() { _ = h.Close() })
+		return h
+	},
+	"wal": func(t *testing.T) *slogbuffer.BufferLogHandler {
+		h, err := slogbuffer.NewWALBufferLogHandler(filepath.Join(t.TempDir(), "wal"), 0, slog.LevelDebug)
+		if err != nil {
+			t.Fatalf("creating handler: %v", err)
+		}
+		t.Cleanup(func() { _ = h.Close() })
+		return h
+	},
+}
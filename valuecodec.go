@@ -0,0 +1,92 @@
+package slogbuffer
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// valueCodec holds functions that serialize and reconstruct values of a single type.
+type valueCodec struct {
+	name   string
+	encode func(any) ([]byte, error)
+	decode func([]byte) (any, error)
+}
+
+// valueCodecs is registry of codecs for arbitrary attribute values.
+var valueCodecs = struct {
+	byType map[reflect.Type]*valueCodec
+	byName map[string]*valueCodec
+	lock   sync.RWMutex
+}{
+	byType: make(map[reflect.Type]*valueCodec),
+	byName: make(map[string]*valueCodec),
+}
+
+// RegisterValueCodec registers functions that serialize and reconstruct attribute values of
+// concrete type T (created using [slog.Any]). They are used by Encode and Decode (and therefore by
+// file, write-ahead log and compressed storage) and by WriteJSON, so custom values survive
+// persistence deterministically. Name identifies the type in serialized data, so it has to be
+// the same in process that encodes and process that decodes the values. Values of types
+// without registered codec are serialized as text, using [fmt.Sprintf].
+// Registering codec for the same type or name again replaces previous registration.
+func RegisterValueCodec[T any](name string, encode func(T) ([]byte, error), decode func([]byte) (T, error)) {
+	codec := &valueCodec{
+		name:   name,
+		encode: func(v any) ([]byte, error) { return encode(v.(T)) },
+		decode: func(data []byte) (any, error) { return decode(data) },
+	}
+
+	valueCodecs.lock.Lock()
+	defer valueCodecs.lock.Unlock()
+	valueCodecs.byType[reflect.TypeFor[T]()] = codec
+	valueCodecs.byName[name] = codec
+}
+
+// codecForValue returns codec registered for type of provided value, or nil.
+func codecForValue(v any) *valueCodec {
+	valueCodecs.lock.RLock()
+	defer valueCodecs.lock.RUnlock()
+	return valueCodecs.byType[reflect.TypeOf(v)]
+}
+
+// codecForName returns codec registered under provided name, or nil.
+func codecForName(name string) *valueCodec {
+	valueCodecs.lock.RLock()
+	defer valueCodecs.lock.RUnlock()
+	return valueCodecs.byName[name]
+}
+
+// encodeValue serializes arbitrary value using registered codec. If there is no codec
+// registered for type of the value, ok is false.
+func encodeValue(v any) (name string, data []byte, ok bool) {
+	codec := codecForValue(v)
+	if codec == nil {
+		return "", nil, false
+	}
+	data, err := codec.encode(v)
+	if err != nil {
+		return "", nil, false
+	}
+	return codec.name, data, true
+}
+
+// decodeValue reconstructs value serialized by codec registered under provided name.
+// If codec is not registered or fails, provided fallback is returned.
+func decodeValue(name string, data []byte, fallback string) any {
+	codec := codecForName(name)
+	if codec == nil {
+		return fallback
+	}
+	v, err := codec.decode(data)
+	if err != nil {
+		return fallback
+	}
+	return v
+}
+
+// valueText returns textual representation of arbitrary value, used when value can not be
+// serialized otherwise.
+func valueText(v any) string {
+	return fmt.Sprintf("%+v", v)
+}
@@ -0,0 +1,59 @@
+package slogbuffer_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"testing"
+)
+
+type point struct {
+	X, Y int
+}
+
+type unregistered struct {
+	Name string
+}
+
+func init() {
+	slogbuffer.RegisterValueCodec("test.point",
+		func(p point) ([]byte, error) { return json.Marshal(p) },
+		func(data []byte) (point, error) {
+			var p point
+			err := json.Unmarshal(data, &p)
+			return p, err
+		},
+	)
+}
+
+func TestRegisterValueCodec(t *testing.T) {
+	// given
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	slog.New(h).Info("info msg", "point", point{X: 1, Y: 2}, "other", unregistered{Name: "foo"})
+
+	// when
+	encoded := new(bytes.Buffer)
+	if err := h.Encode(encoded); err != nil {
+		t.Fatalf("encoding: %v", err)
+	}
+	decoded, err := slogbuffer.Decode(encoded, slog.LevelDebug)
+	if err != nil {
+		t.Fatalf("decoding: %v", err)
+	}
+
+	// then
+	records := decoded.Records()
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+	expectRecordAttr(t, records[0], "point", slog.AnyValue(point{X: 1, Y: 2}))
+	expectRecordAttr(t, records[0], "other", slog.StringValue(fmt.Sprintf("%+v", unregistered{Name: "foo"})))
+
+	out := new(bytes.Buffer)
+	if err := h.WriteJSON(out); err != nil {
+		t.Fatalf("writing json: %v", err)
+	}
+	expectContains(t, out.String(), `"point":{"X":1,"Y":2}`)
+}
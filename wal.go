@@ -0,0 +1,287 @@
+package slogbuffer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+// walCompactMin is minimal number of records removed from wrapped storage (e.g. evicted) that
+// are kept in write-ahead log before it is compacted.
+const walCompactMin = 1024
+
+// walStorage is storage that synchronously appends every added record to write-ahead log
+// file before adding it to wrapped storage. Log is truncated when records are taken out
+// of storage (e.g. flushed) or cleared, so it holds all records buffered since then.
+// Records removed otherwise (e.g. evicted) stay in the log until it is compacted, which
+// happens once they outnumber records of wrapped storage. Log uses the same format as Encode.
+type walStorage struct {
+	storage
+	path string
+	file *os.File
+	// maxRecords is maximum number of records written to the header of the log
+	maxRecords int
+	// headerSize is size of the header at the start of the log
+	headerSize int64
+	// logged is number of records in the log, including ones already removed from
+	// wrapped storage
+	logged int
+
+	lock sync.Mutex
+}
+
+// openWALStorage opens write-ahead log at provided path and returns storage that wraps
+// provided one. Records found in existing log are added to wrapped storage.
+func openWALStorage(path string, wrapped storage, maxRecords int) (*walStorage, error) {
+	records, err := readWAL(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	s := &walStorage{storage: wrapped, path: path, maxRecords: maxRecords}
+	for _, rec := range records {
+		if err := wrapped.Add(rec); err != nil {
+			return nil, err
+		}
+	}
+	// log is rewritten, which also removes potentially incomplete record at its end and
+	// records that do not fit into wrapped storage, but existing log is kept until new one
+	// is complete, so records are not lost if it fails
+	if err := s.rewrite(wrapped.Snapshot()); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// reset removes all records from the log, keeping the header. Caller must hold the lock.
+func (s *walStorage) reset() {
+	if err := s.file.Truncate(s.headerSize); err == nil {
+		_, _ = s.file.Seek(s.headerSize, io.SeekStart)
+		s.logged = 0
+	}
+}
+
+// compact rewrites the log if records removed from wrapped storage outnumber records that are
+// still in it, so log of bound buffer does not grow without limit. Caller must hold the lock.
+func (s *walStorage) compact() error {
+	live := s.storage.Len()
+	if s.logged-live < max(live, walCompactMin) {
+		return nil
+	}
+	return s.rewrite(s.storage.Snapshot())
+}
+
+// rewrite replaces the log with one holding only provided records. New log is written next to
+// the old one and renamed over it, so crash during rewrite leaves one of them intact. Caller
+// must hold the lock.
+func (s *walStorage) rewrite(records []record) error {
+	tmp := s.path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	var header bytes.Buffer
+	_ = writeHeader(&header, s.maxRecords)
+	s.headerSize = int64(header.Len())
+
+	w := bufio.NewWriter(file)
+	_, err = w.Write(header.Bytes())
+	for _, rec := range records {
+		if err != nil {
+			break
+		}
+		err = writeRecord(w, rec)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if err == nil {
+		err = os.Rename(tmp, s.path)
+	}
+	if err != nil {
+		file.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	// old file was replaced, so error of closing it does not matter
+	if s.file != nil {
+		_ = s.file.Close()
+	}
+	s.file = file
+	s.logged = len(records)
+	return nil
+}
+
+func (s *walStorage) Add(rec record) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := writeRecord(s.file, rec); err != nil {
+		return err
+	}
+	if err := s.file.Sync(); err != nil {
+		return err
+	}
+	s.logged++
+	if err := s.storage.Add(rec); err != nil {
+		return err
+	}
+	// record is already safely logged, failed compaction is attempted again with the next one
+	_ = s.compact()
+	return nil
+}
+
+func (s *walStorage) Take() []record {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.reset()
+	return s.storage.Take()
+}
+
+func (s *walStorage) Clear() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.reset()
+	s.storage.Clear()
+}
+
+// PopOldest removes the oldest record from wrapped storage. Like evicted records, it stays in the
+// log until log is compacted or truncated, which happens once the last record is removed.
+func (s *walStorage) PopOldest() (record, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	rec, ok := s.storage.PopOldest()
+	if s.storage.Len() == 0 {
+		s.reset()
+	} else {
+		_ = s.compact()
+	}
+	return rec, ok
+}
+
+// resize resizes wrapped storage. Like evicted records, records removed from it stay in the
+// log until it is compacted.
+func (s *walStorage) resize(maxRecords int) error {
+	r, ok := s.storage.(resizer)
+	if !ok {
+		return ErrResizeNotSupported
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := r.resize(maxRecords); err != nil {
+		return err
+	}
+	return s.compact()
+}
+
+// evictWhile evicts records from wrapped storage. Like records evicted because storage was
+// full, they stay in the log until it is compacted.
+func (s *walStorage) evictWhile(evict func(oldest record) bool) {
+	e, ok := s.storage.(evicter)
+	if !ok {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	e.evictWhile(evict)
+	_ = s.compact()
+}
+
+// removeIf removes records from wrapped storage and rewrites the log once, instead of
+// re-adding (and syncing) every record that is kept.
+func (s *walStorage) removeIf(remove func(rec record) bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if r, ok := s.storage.(remover); ok {
+		r.removeIf(remove)
+	} else {
+		for _, rec := range s.storage.Take() {
+			if !remove(rec) {
+				_ = s.storage.Add(rec)
+			}
+		}
+	}
+	// if log can not be rewritten, removed records stay in it until it is compacted
+	_ = s.rewrite(s.storage.Snapshot())
+}
+
+// Close closes the log file (and wrapped storage, if it needs closing). Records stay
+// in the log and are recovered when it is opened again.
+func (s *walStorage) Close() error {
+	err := s.file.Close()
+	if closer, ok := s.storage.(io.Closer); ok {
+		err = errors.Join(err, closer.Close())
+	}
+	return err
+}
+
+// readWAL reads all records from write-ahead log at provided path. Incomplete record at
+// the end of the log (e.g. if process crashed while writing it) is ignored.
+func readWAL(path string) ([]record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	_, version, err := readHeader(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading write-ahead log %s: %w", path, err)
+	}
+	var records []record
+	for {
+		rec, err := readRecord(r)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading write-ahead log %s: %w", path, err)
+		}
+		records = append(records, upgradeRecord(rec, version))
+	}
+}
+
+// NewWALBufferLogHandler returns log handler that, in addition to buffering records in memory
+// (bound to maxRecords, if positive), synchronously appends every buffered record to write-ahead
+// log at provided path before Handle returns. Log is truncated when records are flushed or
+// discarded, so in case of crash it holds all records that were never handed to real handler.
+// Records evicted from bound buffer stay in the log until they outnumber buffered records (and
+// at least 1024 of them accumulate), when log is compacted, so its size stays bounded as well.
+// If log at provided path already exists, its records are buffered again, so they are replayed
+// once real handler is set. For offline inspection of the log, see ReplayWriteAheadLog.
+// Close should be called to release the log file.
+func NewWALBufferLogHandler(path string, maxRecords int, leveler slog.Leveler, opts ...Option) (*BufferLogHandler, error) {
+	h := NewBoundBufferLogHandler(leveler, maxRecords, opts...)
+	s, err := openWALStorage(path, h.buffer, maxRecords)
+	if err != nil {
+		return nil, err
+	}
+	h.buffer = s
+	return h, nil
+}
+
+// ReplayWriteAheadLog sends all records from write-ahead log at provided path (see
+// NewWALBufferLogHandler) to provided handler, without changing the log. It is useful for
+// recovering logs of crashed process with separate tool.
+func ReplayWriteAheadLog(ctx context.Context, path string, handler slog.Handler) error {
+	records, err := readWAL(path)
+	if err != nil {
+		return err
+	}
+	var replayErr error
+	for _, rec := range records {
+		replayErr = errors.Join(replayErr, rec.emit(ctx, handler))
+	}
+	return replayErr
+}
@@ -0,0 +1,189 @@
+package slogbuffer_test
+
+import (
+	"context"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newWALHandler(t *testing.T, path string) *slogbuffer.BufferLogHandler {
+	t.Helper()
+	h, err := slogbuffer.NewWALBufferLogHandler(path, 0, slog.LevelDebug)
+	if err != nil {
+		t.Fatalf("creating handler: %v", err)
+	}
+	t.Cleanup(func() { _ = h.Close() })
+	return h
+}
+
+func TestWALBufferLogHandler_Recover(t *testing.T) {
+	// given
+	path := filepath.Join(t.TempDir(), "buffer.wal")
+	crashed := newWALHandler(t, path)
+	slog.New(crashed).With("common", "attr").Info("before crash")
+	// simulate crash in the middle of writing another record
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatalf("opening log: %v", err)
+	}
+	if _, err := f.Write([]byte{100, 1, 2}); err != nil {
+		t.Fatalf("writing to log: %v", err)
+	}
+	_ = f.Close()
+
+	// when
+	h := newWALHandler(t, path)
+	slog.New(h).Info("after crash")
+
+	rh, reader := getSimplifiedTextHandler()
+	setRealHandler(t, h, rh)
+	lines := getLines(t, reader)
+
+	// then
+	expectLinesNo(t, lines, 2)
+	expectMsg(t, lines[0], "before crash")
+	expectAttr(t, lines[0], "common", "attr")
+	expectMsg(t, lines[1], "after crash")
+
+	// flushed records are removed from the log
+	replayed, replayedReader := getSimplifiedTextHandler()
+	if err := slogbuffer.ReplayWriteAheadLog(context.Background(), path, replayed); err != nil {
+		t.Fatalf("replaying log: %v", err)
+	}
+	expectLinesNo(t, getLines(t, replayedReader), 0)
+}
+
+func TestReplayWriteAheadLog(t *testing.T) {
+	// given
+	path := filepath.Join(t.TempDir(), "buffer.wal")
+	h := newWALHandler(t, path)
+	l := slog.New(h)
+	l.Info("first msg")
+	l.WithGroup("g1").Warn("second msg", "foo", "bar")
+
+	// when
+	rh, reader := getSimplifiedTextHandler()
+	if err := slogbuffer.ReplayWriteAheadLog(context.Background(), path, rh); err != nil {
+		t.Fatalf("replaying log: %v", err)
+	}
+	lines := getLines(t, reader)
+
+	// then
+	expectLinesNo(t, lines, 2)
+	expectMsg(t, lines[0], "first msg")
+	expectMsg(t, lines[1], "second msg")
+	expectAttr(t, lines[1], "g1.foo", "bar")
+
+	// log is not changed by replay
+	if h.Len() != 2 {
+		t.Fatalf("expected 2 buffered records, got %d", h.Len())
+	}
+}
+
+// countWALRecords returns number of records in write-ahead log at provided path.
+func countWALRecords(t *testing.T, path string) int {
+	t.Helper()
+	replayed, reader := getSimplifiedTextHandler()
+	if err := slogbuffer.ReplayWriteAheadLog(context.Background(), path, replayed); err != nil {
+		t.Fatalf("replaying log: %v", err)
+	}
+	return len(getLines(t, reader))
+}
+
+func TestWALBufferLogHandler_Compaction(t *testing.T) {
+	// given
+	path := filepath.Join(t.TempDir(), "buffer.wal")
+	h, err := slogbuffer.NewWALBufferLogHandler(path, 10, slog.LevelDebug)
+	if err != nil {
+		t.Fatalf("creating handler: %v", err)
+	}
+	t.Cleanup(func() { _ = h.Close() })
+
+	// when
+	for i := range 5000 {
+		slog.New(h).Info("msg", "no", i)
+	}
+
+	// then
+	// evicted records do not accumulate in the log
+	if n := countWALRecords(t, path); n < 10 || n > 10+1024 {
+		t.Fatalf("expected log to be compacted, it holds %d records", n)
+	}
+	reopened := newWALHandler(t, path)
+	records := reopened.Records()
+	if len(records) < 10 {
+		t.Fatalf("expected buffered records to be recovered, got %d", len(records))
+	}
+	expectRecordAttr(t, records[len(records)-1], "no", slog.Int64Value(4999))
+}
+
+func TestWALBufferLogHandler_DiscardIf(t *testing.T) {
+	// given
+	path := filepath.Join(t.TempDir(), "buffer.wal")
+	h := newWALHandler(t, path)
+	l := slog.New(h)
+	for i := range 10 {
+		l.Info("msg", "no", i)
+	}
+
+	// when
+	err := h.DiscardIf(func(r slog.Record) bool {
+		var odd bool
+		r.Attrs(func(a slog.Attr) bool {
+			odd = a.Key == "no" && a.Value.Int64()%2 == 1
+			return !odd
+		})
+		return odd
+	})
+
+	// then
+	if err != nil {
+		t.Fatalf("discarding records: %v", err)
+	}
+	if h.Len() != 5 {
+		t.Fatalf("expected 5 buffered records, got %d", h.Len())
+	}
+	// removed records are removed from the log as well
+	if n := countWALRecords(t, path); n != 5 {
+		t.Fatalf("expected 5 records in the log, got %d", n)
+	}
+}
+
+func TestWALBufferLogHandler_ReopenFailure(t *testing.T) {
+	// given
+	path := filepath.Join(t.TempDir(), "buffer.wal")
+	crashed := newWALHandler(t, path)
+	slog.New(crashed).Info("first")
+	slog.New(crashed).Info("second")
+	_ = crashed.Close()
+	// new log can not be written next to the existing one
+	if err := os.Mkdir(path+".tmp", 0o700); err != nil {
+		t.Fatalf("creating directory: %v", err)
+	}
+
+	// when
+	_, err := slogbuffer.NewWALBufferLogHandler(path, 0, slog.LevelDebug)
+
+	// then
+	if err == nil {
+		t.Fatalf("expected error opening log")
+	}
+	// records of existing log are not lost
+	if n := countWALRecords(t, path); n != 2 {
+		t.Fatalf("expected 2 records to stay in the log, got %d", n)
+	}
+
+	// when
+	if err := os.Remove(path + ".tmp"); err != nil {
+		t.Fatalf("removing directory: %v", err)
+	}
+	h := newWALHandler(t, path)
+
+	// then
+	if h.Len() != 2 {
+		t.Fatalf("expected 2 recovered records, got %d", h.Len())
+	}
+}
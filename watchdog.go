@@ -0,0 +1,21 @@
+package slogbuffer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// startWatchdog makes handler write warning to watchdog writer if real handler is still not set
+// once provided duration elapses (see WithWatchdog).
+func (h *BufferLogHandler) startWatchdog(after time.Duration) {
+	h.afterFunc(after, func() {
+		if h.getRealHandler() != nil {
+			return
+		}
+		msg := fmt.Sprintf("%d log records buffered for %s, no handler configured", h.Len(), after)
+		warning := slog.NewRecord(h.now(), slog.LevelWarn, msg, 0)
+		_ = slog.NewTextHandler(h.getOptions().watchdogOutput, nil).Handle(context.Background(), warning)
+	})
+}
@@ -0,0 +1,61 @@
+package slogbuffer_test
+
+import (
+	"bytes"
+	"github.com/delicb/slogbuffer"
+	"github.com/delicb/slogbuffer/slogbuffertest"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// signalingWriter is io.Writer that sends everything written to it to channel.
+type signalingWriter chan string
+
+func (w signalingWriter) Write(p []byte) (int, error) {
+	w <- string(bytes.Clone(p))
+	return len(p), nil
+}
+
+func TestBufferLogHandler_WithWatchdog(t *testing.T) {
+	// given
+	clock := slogbuffertest.NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	out := make(signalingWriter, 1)
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug,
+		slogbuffer.WithClock(clock), slogbuffer.WithWatchdog(5*time.Minute, out))
+	l := slog.New(h)
+
+	// when
+	l.Info("first msg")
+	l.Info("second msg")
+	clock.Advance(5 * time.Minute)
+
+	// then
+	select {
+	case warning := <-out:
+		expectMsg(t, warning, "2 log records buffered for 5m0s, no handler configured")
+		expectLevel(t, warning, slog.LevelWarn)
+	case <-time.After(time.Second):
+		t.Fatalf("expected watchdog warning")
+	}
+}
+
+func TestBufferLogHandler_WithWatchdog_RealHandlerSet(t *testing.T) {
+	// given
+	clock := slogbuffertest.NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	out := make(signalingWriter, 1)
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug,
+		slogbuffer.WithClock(clock), slogbuffer.WithWatchdog(5*time.Minute, out))
+
+	// when
+	rh, _ := getSimplifiedTextHandler()
+	setRealHandler(t, h, rh)
+	clock.Advance(5 * time.Minute)
+
+	// then
+	select {
+	case warning := <-out:
+		t.Fatalf("expected no warning, got %q", warning)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
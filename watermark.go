@@ -0,0 +1,38 @@
+package slogbuffer
+
+import (
+	"context"
+	"log/slog"
+)
+
+// checkWatermark calls high watermark callback (see WithHighWatermark) if bound buffer got
+// filled up to watermark since callback was last called. Watermark is re-armed once buffer
+// is found below it again.
+func (h *BufferLogHandler) checkWatermark(ctx context.Context) {
+	o := h.getOptions()
+	capacity := h.buffer.Cap()
+	if o.watermarkReached == nil || capacity == 0 {
+		return
+	}
+	root := h.root()
+	if h.buffer.Len()*100 < o.watermarkPercent*capacity {
+		root.aboveWatermark.Store(false)
+		return
+	}
+	if root.aboveWatermark.CompareAndSwap(false, true) {
+		o.watermarkReached(ctx, root)
+	}
+}
+
+// DrainTo returns high watermark callback (see WithHighWatermark) that moves all buffered records
+// to provided overflow handler (e.g. one writing to local file), making space for new records.
+// Records overflow handler fails to handle are kept as dead letters.
+func DrainTo(overflow slog.Handler) func(context.Context, *BufferLogHandler) {
+	return func(ctx context.Context, h *BufferLogHandler) {
+		for r := range h.Drain() {
+			if err := overflow.Handle(ctx, r); err != nil {
+				h.deadLetters.Add(record{Record: r})
+			}
+		}
+	}
+}
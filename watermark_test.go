@@ -0,0 +1,62 @@
+package slogbuffer_test
+
+import (
+	"context"
+	"github.com/delicb/slogbuffer"
+	"log/slog"
+	"testing"
+)
+
+func TestBufferLogHandler_WithHighWatermark(t *testing.T) {
+	// given
+	var reached []int
+	h := slogbuffer.NewBoundBufferLogHandler(slog.LevelDebug, 10, slogbuffer.WithHighWatermark(80,
+		func(_ context.Context, h *slogbuffer.BufferLogHandler) {
+			reached = append(reached, h.Len())
+		},
+	))
+	l := slog.New(h).WithGroup("g1")
+
+	// when
+	for i := range 20 {
+		l.Info("msg", "no", i)
+	}
+
+	// then
+	// callback is called once, even though buffer stays above watermark
+	if len(reached) != 1 || reached[0] != 8 {
+		t.Fatalf("expected callback to be called once with 8 records, got %v", reached)
+	}
+
+	// watermark is re-armed once buffer is below it
+	h.Discard()
+	for i := range 8 {
+		l.Info("msg", "no", i)
+	}
+	if len(reached) != 2 {
+		t.Fatalf("expected callback to be called again, got %v", reached)
+	}
+}
+
+func TestDrainTo(t *testing.T) {
+	// given
+	overflow, reader := getSimplifiedTextHandler()
+	h := slogbuffer.NewBoundBufferLogHandler(slog.LevelDebug, 4,
+		slogbuffer.WithHighWatermark(75, slogbuffer.DrainTo(overflow)))
+	l := slog.New(h).WithGroup("g1")
+
+	// when
+	for i := range 7 {
+		l.Info("msg", "no", i)
+	}
+
+	// then
+	// nothing is evicted, records are moved to overflow handler instead
+	lines := getLines(t, reader)
+	expectLinesNo(t, lines, 6)
+	expectAttr(t, lines[0], "g1.no", "0")
+	expectAttr(t, lines[5], "g1.no", "5")
+	if h.Len() != 1 || h.Dropped() != 0 {
+		t.Fatalf("expected 1 buffered and no dropped records, got %d and %d", h.Len(), h.Dropped())
+	}
+}
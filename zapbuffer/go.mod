@@ -0,0 +1,10 @@
+module github.com/delicb/slogbuffer/zapbuffer
+
+go 1.23.1
+
+require (
+	github.com/delicb/slogbuffer v0.0.0-20261016134606-73c960bce6bf
+	go.uber.org/zap v1.27.0
+)
+
+require go.uber.org/multierr v1.10.0 // indirect
@@ -0,0 +1,166 @@
+// Package zapbuffer provides [slog.Handler] that writes records to zap logger, so codebases
+// migrating to slog can buffer records using [slogbuffer.BufferLogHandler] while final sink
+// is still zap.
+//
+// It is a separate module, so slogbuffer itself does not depend on zap.
+package zapbuffer
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"log/slog"
+	"runtime"
+)
+
+// Handler is [slog.Handler] that writes records to [zapcore.Core]. Levels are mapped to the
+// closest zap level at or below them (e.g. slog.LevelWarn+2 becomes zapcore.WarnLevel),
+// attributes become zap fields and groups become namespaces (nested objects for JSON encoder).
+// It can be used as real handler of buffer handler using SetRealHandler.
+type Handler struct {
+	core zapcore.Core
+	// groups are opened groups that do not have any attributes yet. They are added to the
+	// core only once attributes are added, since slog omits empty groups.
+	groups []string
+}
+
+// NewHandler creates handler that writes records to provided core.
+func NewHandler(core zapcore.Core) *Handler {
+	return &Handler{core: core}
+}
+
+// FromLogger creates handler that writes records to core of provided logger. Options of the
+// logger (e.g. hooks, caller skip or stack traces) are not used, since records are written
+// to the core directly, but fields added to the logger using With are.
+func FromLogger(logger *zap.Logger) *Handler {
+	return NewHandler(logger.Core())
+}
+
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.core.Enabled(zapLevel(level))
+}
+
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	entry := zapcore.Entry{
+		Level:   zapLevel(r.Level),
+		Time:    r.Time,
+		Message: r.Message,
+	}
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		entry.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+		entry.Caller.Function = frame.Function
+	}
+	checked := h.core.Check(entry, nil)
+	if checked == nil {
+		return nil
+	}
+
+	fields := make([]zap.Field, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendField(fields, a)
+		return true
+	})
+	if len(fields) > 0 {
+		fields = append(namespaces(h.groups), fields...)
+	}
+	checked.Write(fields...)
+	return nil
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var fields []zap.Field
+	for _, a := range attrs {
+		fields = appendField(fields, a)
+	}
+	if len(fields) == 0 {
+		return h
+	}
+	return &Handler{core: h.core.With(append(namespaces(h.groups), fields...))}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &Handler{
+		core:   h.core,
+		groups: append(h.groups[:len(h.groups):len(h.groups)], name),
+	}
+}
+
+// zapLevel returns zap level closest to provided slog level, not above it.
+func zapLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level < slog.LevelInfo:
+		return zapcore.DebugLevel
+	case level < slog.LevelWarn:
+		return zapcore.InfoLevel
+	case level < slog.LevelError:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
+
+// namespaces returns fields that open provided groups.
+func namespaces(groups []string) []zap.Field {
+	fields := make([]zap.Field, 0, len(groups))
+	for _, g := range groups {
+		fields = append(fields, zap.Namespace(g))
+	}
+	return fields
+}
+
+// appendField appends field representing provided attribute, following slog rules for empty
+// attributes and groups.
+func appendField(fields []zap.Field, a slog.Attr) []zap.Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	switch v := a.Value; v.Kind() {
+	case slog.KindGroup:
+		attrs := v.Group()
+		if len(attrs) == 0 {
+			return fields
+		}
+		if a.Key == "" {
+			return append(fields, zap.Inline(group(attrs)))
+		}
+		return append(fields, zap.Object(a.Key, group(attrs)))
+	case slog.KindString:
+		return append(fields, zap.String(a.Key, v.String()))
+	case slog.KindInt64:
+		return append(fields, zap.Int64(a.Key, v.Int64()))
+	case slog.KindUint64:
+		return append(fields, zap.Uint64(a.Key, v.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, zap.Float64(a.Key, v.Float64()))
+	case slog.KindBool:
+		return append(fields, zap.Bool(a.Key, v.Bool()))
+	case slog.KindDuration:
+		return append(fields, zap.Duration(a.Key, v.Duration()))
+	case slog.KindTime:
+		return append(fields, zap.Time(a.Key, v.Time()))
+	default:
+		if err, ok := v.Any().(error); ok {
+			return append(fields, zap.NamedError(a.Key, err))
+		}
+		return append(fields, zap.Any(a.Key, v.Any()))
+	}
+}
+
+// group marshals attributes of slog group as zap object.
+type group []slog.Attr
+
+func (g group) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	var fields []zap.Field
+	for _, a := range g {
+		fields = appendField(fields, a)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return nil
+}
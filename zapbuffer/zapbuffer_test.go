@@ -0,0 +1,73 @@
+package zapbuffer_test
+
+import (
+	"context"
+	"errors"
+	"github.com/delicb/slogbuffer"
+	"github.com/delicb/slogbuffer/zapbuffer"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"log/slog"
+	"reflect"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	// given
+	core, logs := observer.New(zapcore.InfoLevel)
+	h := slogbuffer.NewBufferLogHandler(slog.LevelDebug)
+	l := slog.New(h).With("service", "api").WithGroup("req").With("id", 7)
+
+	// when
+	l.Debug("not enabled in zap")
+	l.Info("info", "path", "/", slog.Group("user", "name", "alice"))
+	l.WithGroup("empty").Warn("warn")
+	l.Log(context.Background(), slog.LevelError+4, "critical", "err", errors.New("boom"))
+	if err := h.SetRealHandler(context.Background(), zapbuffer.NewHandler(core)); err != nil {
+		t.Fatalf("failed to set real handler: %v", err)
+	}
+
+	// then
+	entries := logs.AllUntimed()
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d: %v", len(entries), entries)
+	}
+	for i, expected := range []struct {
+		msg     string
+		level   zapcore.Level
+		context map[string]any
+	}{
+		{"info", zapcore.InfoLevel, map[string]any{"service": "api", "req": map[string]any{
+			"id": int64(7), "path": "/", "user": map[string]any{"name": "alice"}}}},
+		{"warn", zapcore.WarnLevel, map[string]any{"service": "api", "req": map[string]any{"id": int64(7)}}},
+		{"critical", zapcore.ErrorLevel, map[string]any{"service": "api", "req": map[string]any{
+			"id": int64(7), "err": "boom"}}},
+	} {
+		if entries[i].Message != expected.msg || entries[i].Level != expected.level {
+			t.Fatalf("expected %q at %s, got %q at %s", expected.msg, expected.level, entries[i].Message, entries[i].Level)
+		}
+		if ctx := entries[i].ContextMap(); !reflect.DeepEqual(ctx, expected.context) {
+			t.Fatalf("expected fields %v of %q, got %v", expected.context, expected.msg, ctx)
+		}
+	}
+}
+
+func TestFromLogger(t *testing.T) {
+	// given
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(core).With(zap.String("component", "worker"))
+
+	// when
+	slog.New(zapbuffer.FromLogger(logger)).Debug("started", "workers", 4)
+
+	// then
+	entries := logs.AllUntimed()
+	if len(entries) != 1 || entries[0].Level != zapcore.DebugLevel {
+		t.Fatalf("expected single debug entry, got %v", entries)
+	}
+	expected := map[string]any{"component": "worker", "workers": int64(4)}
+	if ctx := entries[0].ContextMap(); !reflect.DeepEqual(ctx, expected) {
+		t.Fatalf("expected fields %v, got %v", expected, ctx)
+	}
+}